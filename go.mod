@@ -4,6 +4,8 @@ go 1.23.0
 
 require (
 	github.com/alcionai/clues v0.0.0-20240919165104-721af7f08f64
+	github.com/rogpeppe/go-internal v1.13.1
+	github.com/stretchr/testify v1.9.0
 	golang.org/x/tools v0.26.0
 	mvdan.cc/sh v2.6.4+incompatible
 )
@@ -13,8 +15,6 @@ require (
 	github.com/google/uuid v1.6.0 // indirect
 	github.com/kr/pretty v0.3.1 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
-	github.com/rogpeppe/go-internal v1.13.1 // indirect
-	github.com/stretchr/testify v1.9.0 // indirect
 	golang.org/x/exp v0.0.0-20230905200255-921286631fa9 // indirect
 	golang.org/x/sys v0.26.0 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
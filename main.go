@@ -1,7 +1,9 @@
 package main
 
 import (
+	"errors"
 	"flag"
+	"fmt"
 	"log"
 	"os"
 	"path/filepath"
@@ -10,6 +12,8 @@ import (
 	"github.com/hiteshrepo/hazardous/pkg/hazardous"
 	"github.com/hiteshrepo/hazardous/pkg/helpers"
 	"github.com/hiteshrepo/hazardous/pkg/issue"
+	"github.com/hiteshrepo/hazardous/pkg/makefile"
+	"github.com/hiteshrepo/hazardous/pkg/report"
 
 	"mvdan.cc/sh/syntax"
 )
@@ -19,40 +23,133 @@ type Config struct {
 	excludeDirs       []string
 }
 
+// Exit codes returned by the hazardous command.
+const (
+	exitOK       = 0
+	exitFindings = 1
+	exitError    = 2
+)
+
+// failNone disables failing on findings when passed to --fail-on.
+const failNone = "none"
+
 func main() {
-	extensions := flag.String("allow-extensions", ".sh,Makefile", "Comma-separated list of allowed file extensions")
-	excludes := flag.String("exclude-dirs", "node_modules,linters", "Comma-separated list of directories to exclude")
-	flag.Parse()
+	os.Exit(run(os.Args[1:]))
+}
+
+// run scans the files requested by args and returns the process exit code:
+// exitError when a file could not be read or parsed, exitFindings when an
+// issue at or above the --fail-on severity was found and exitOK otherwise.
+func run(args []string) int {
+	flags := flag.NewFlagSet("hazardous", flag.ContinueOnError)
+	extensions := flags.String("allow-extensions", ".sh,Makefile", "Comma-separated list of allowed file extensions")
+	excludes := flags.String("exclude-dirs", "node_modules,linters", "Comma-separated list of directories to exclude")
+	disabled := flags.String("disable-rules", "", "Comma-separated list of rule IDs to disable")
+	protected := flags.String("protected-paths", "", "Comma-separated list of paths that must never be deleted")
+	format := flags.String("format", "text", "Output format: "+strings.Join(report.Formats, ", "))
+	failOn := flags.String("fail-on", "warning", "Exit with a non-zero code when issues of this severity or above are found: info, warning, error or "+failNone)
+	if err := flags.Parse(args); err != nil {
+		return exitError
+	}
+
+	// every run gets its own registry, so that the rules it disables and the
+	// paths it protects do not leak into later runs
+	registry := hazardous.NewRegistry(hazardous.DefaultRegistry.Rules()...)
+
+	for _, id := range strings.Split(*disabled, ",") {
+		if len(strings.TrimSpace(id)) == 0 {
+			continue
+		}
+
+		if err := registry.Disable(strings.TrimSpace(id)); err != nil {
+			log.Print(err)
+			return exitError
+		}
+	}
+
+	registry.Protect(strings.Split(*protected, ",")...)
+
+	threshold, err := issue.ParseSeverity(*failOn)
+	if err != nil && *failOn != failNone {
+		log.Print(err)
+		return exitError
+	}
+
+	reporter, err := report.New(*format, os.Stdout, registry)
+	if err != nil {
+		log.Print(err)
+		return exitError
+	}
 
 	config := Config{
 		allowedExtensions: strings.Split(*extensions, ","),
 		excludeDirs:       strings.Split(*excludes, ","),
 	}
 
-	args := flag.Args()
-	if len(args) < 1 {
-		log.Fatal("Please provide a path to scan")
+	if flags.NArg() < 1 {
+		log.Print("Please provide a path to scan")
+		return exitError
+	}
+
+	failed, errored := false, false
+	scan := func(path string) {
+		issues, scanned, err := scanFile(registry, path)
+		if err != nil {
+			log.Print(err)
+			errored = true
+		}
+
+		// files that could not be read or parsed are not counted as scanned
+		if !scanned {
+			return
+		}
+
+		if err := reporter.Report(issues); err != nil {
+			log.Printf("Error reporting issues for %s: %v", path, err)
+			errored = true
+		}
+
+		for _, i := range issues {
+			if *failOn != failNone && i.Severity >= threshold {
+				failed = true
+			}
+		}
 	}
 
-	targetPath := args[0]
+	targetPath := flags.Arg(0)
 	if targetPath == "./..." {
 		err := filepath.Walk(".", func(path string, info os.FileInfo, err error) error {
 			if err != nil {
 				return err
 			}
 			if !info.IsDir() && shouldScanFile(path, config) {
-				scanFile(path)
+				scan(path)
 			}
 			return nil
 		})
 		if err != nil {
-			log.Fatal(err)
+			log.Print(err)
+			errored = true
 		}
 	} else {
 		if shouldScanFile(targetPath, config) {
-			scanFile(targetPath)
+			scan(targetPath)
 		}
 	}
+
+	if err := reporter.Close(); err != nil {
+		log.Print(err)
+		errored = true
+	}
+
+	switch {
+	case errored:
+		return exitError
+	case failed:
+		return exitFindings
+	default:
+		return exitOK
+	}
 }
 
 func shouldScanFile(targetPath string, config Config) bool {
@@ -60,58 +157,52 @@ func shouldScanFile(targetPath string, config Config) bool {
 		!helpers.IsExcludedDir(targetPath, config.excludeDirs)
 }
 
-func scanFile(filepath string) {
+// scanFile checks the file at filepath and reports whether it could be read
+// and parsed. A Makefile is scanned even when some of its recipes are not
+// valid shell, which are returned as errors.
+func scanFile(registry *hazardous.Registry, filepath string) ([]issue.Issue, bool, error) {
 	content, err := os.ReadFile(filepath)
 	if err != nil {
-		log.Printf("Error reading file %s: %v", filepath, err)
-		return
+		return nil, false, fmt.Errorf("reading file %s: %w", filepath, err)
 	}
 
+	var issues []issue.Issue
 	if strings.HasSuffix(filepath, "Makefile") {
-		scanMakefile(string(content), filepath)
+		issues, err = scanMakefile(registry, string(content), filepath)
 	} else {
-		issues := scanShellScript(string(content), filepath)
-		issue.ReportIssues(issues)
+		issues, err = scanShellScript(registry, string(content), filepath)
 	}
+
+	if errors.Is(err, errUnparsed) {
+		return nil, false, err
+	}
+
+	// reporters fingerprint findings with their source lines
+	issue.AttachSource(issues, string(content))
+
+	return issues, true, err
 }
 
-func scanShellScript(content, filepath string) []issue.Issue {
+// errUnparsed is wrapped by the errors of files that could not be parsed.
+var errUnparsed = errors.New("parsing file")
+
+func scanShellScript(registry *hazardous.Registry, content, filepath string) ([]issue.Issue, error) {
 	reader := strings.NewReader(content)
 	file, err := syntax.NewParser().Parse(reader, filepath)
 	if err != nil {
-		log.Printf("Error parsing file %s: %v", filepath, err)
-		return nil
+		return nil, fmt.Errorf("%w %s: %w", errUnparsed, filepath, err)
 	}
 
-	var issues []issue.Issue
-	syntax.Walk(file, func(node syntax.Node) bool {
-		if cmd, ok := node.(*syntax.CallExpr); ok {
-			if issue := hazardous.CheckHazardousCommand(cmd, filepath); issue != nil {
-				issues = append(issues, *issue)
-			}
-		}
-
-		return true
-	})
+	ctx := &hazardous.Context{Filepath: filepath}
 
-	return issues
+	return registry.CheckFile(ctx, file), nil
 }
 
-func scanMakefile(content, filepath string) []issue.Issue {
-	var issues []issue.Issue
-	lines := strings.Split(content, "\n")
-
-	for i, line := range lines {
-		flag, col := hazardous.CheckHazardousLine(line, "rm")
-		if len(flag) > 0 {
-			issues = append(issues, issue.Issue{
-				Filepath: filepath,
-				Line:     uint(i + 1),
-				Col:      col,
-				Command:  "rm " + flag,
-			})
-		}
+func scanMakefile(registry *hazardous.Registry, content, filepath string) ([]issue.Issue, error) {
+	mf, err := makefile.Parse(strings.NewReader(content), filepath)
+	if err != nil {
+		return nil, fmt.Errorf("%w %s: %w", errUnparsed, filepath, err)
 	}
 
-	return issues
+	return registry.CheckMakefile(filepath, mf)
 }
@@ -7,6 +7,7 @@ import (
 	"path/filepath"
 	"testing"
 
+	"github.com/hiteshrepo/hazardous/pkg/hazardous"
 	"github.com/hiteshrepo/hazardous/pkg/issue"
 	"github.com/rogpeppe/go-internal/gotooltest"
 	"github.com/rogpeppe/go-internal/testscript"
@@ -14,12 +15,18 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
+const (
+	rmRFMessage = "rm -rf deletes files recursively without asking for confirmation"
+	rmRFFix     = `guard the target path, e.g. rm -rf "${DIR:?}/build", or drop -f to be prompted`
+)
+
 func TestScanShellScript(t *testing.T) {
 	tests := []struct {
 		name     string
 		content  string
 		filepath string
 		want     []issue.Issue
+		wantErr  bool
 	}{
 		{
 			name: "single hazardous command",
@@ -31,7 +38,13 @@ rm -rf /path/to/dir`,
 					Filepath: "test.sh",
 					Line:     2,
 					Col:      1,
+					EndLine:  2,
+					EndCol:   20,
 					Command:  "rm -rf",
+					RuleID:   "rm-rf",
+					Severity: issue.SeverityWarning,
+					Message:  rmRFMessage,
+					Fix:      rmRFFix,
 				},
 			},
 		},
@@ -47,13 +60,25 @@ rm -rf /path2`,
 					Filepath: "test.sh",
 					Line:     2,
 					Col:      1,
+					EndLine:  2,
+					EndCol:   14,
 					Command:  "rm -rf",
+					RuleID:   "rm-rf",
+					Severity: issue.SeverityWarning,
+					Message:  rmRFMessage,
+					Fix:      rmRFFix,
 				},
 				{
 					Filepath: "test.sh",
 					Line:     4,
 					Col:      1,
+					EndLine:  4,
+					EndCol:   14,
 					Command:  "rm -rf",
+					RuleID:   "rm-rf",
+					Severity: issue.SeverityWarning,
+					Message:  rmRFMessage,
+					Fix:      rmRFFix,
 				},
 			},
 		},
@@ -81,13 +106,46 @@ fi`,
 					Filepath: "test.sh",
 					Line:     3,
 					Col:      5,
+					EndLine:  3,
+					EndCol:   20,
 					Command:  "rm -rf",
+					RuleID:   "rm-rf",
+					Severity: issue.SeverityInfo,
+					Message:  "rm -rf deletes the temporary path /tmp/dir",
+					Fix:      rmRFFix,
 				},
 				{
 					Filepath: "test.sh",
 					Line:     6,
 					Col:      5,
+					EndLine:  6,
+					EndCol:   19,
 					Command:  "rm -rf",
+					RuleID:   "rm-rf",
+					Severity: issue.SeverityInfo,
+					Message:  "rm -rf deletes ./build inside the repository",
+					Fix:      rmRFFix,
+				},
+			},
+		},
+		{
+			name: "destructive command on an unassigned variable",
+			content: `#!/bin/bash
+OUT_DIR := ""
+rm $OUT_DIR/*`,
+			filepath: "test.sh",
+			want: []issue.Issue{
+				{
+					Filepath: "test.sh",
+					Line:     3,
+					Col:      4,
+					EndLine:  3,
+					EndCol:   14,
+					Command:  "rm",
+					RuleID:   "empty-var-path",
+					Severity: issue.SeverityError,
+					Message:  `$OUT_DIR is never assigned, so rm may be given "/*"`,
+					Fix:      `use "${OUT_DIR:?}" so the command aborts when the variable is empty`,
 				},
 			},
 		},
@@ -99,6 +157,7 @@ if [ "$1" == "--clean" ] then # missing semicolon
 fi`,
 			filepath: "test.sh",
 			want:     nil,
+			wantErr:  true,
 		},
 		{
 			name:     "empty script",
@@ -118,7 +177,13 @@ fi`,
 					Filepath: "test.sh",
 					Line:     3,
 					Col:      4,
+					EndLine:  3,
+					EndCol:   16,
 					Command:  "rm -rf",
+					RuleID:   "rm-rf",
+					Severity: issue.SeverityWarning,
+					Message:  rmRFMessage,
+					Fix:      rmRFFix,
 				},
 			},
 		},
@@ -126,7 +191,12 @@ fi`,
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := scanShellScript(tt.content, tt.filepath)
+			got, err := scanShellScript(hazardous.DefaultRegistry, tt.content, tt.filepath)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
 			assert.Equal(t, got, tt.want, "scanShellScript() = %v, want %v", got, tt.want)
 		})
 	}
@@ -138,6 +208,7 @@ func TestScanMakefile(t *testing.T) {
 		content  string
 		filepath string
 		want     []issue.Issue
+		wantErr  bool
 	}{
 		{
 			name: "simple rm -rf command",
@@ -149,7 +220,13 @@ func TestScanMakefile(t *testing.T) {
 					Filepath: "Makefile",
 					Line:     2,
 					Col:      2,
+					EndLine:  2,
+					EndCol:   15,
 					Command:  "rm -rf",
+					RuleID:   "rm-rf",
+					Severity: issue.SeverityInfo,
+					Message:  "rm -rf deletes build/ inside the repository",
+					Fix:      rmRFFix,
 				},
 			},
 		},
@@ -165,13 +242,25 @@ func TestScanMakefile(t *testing.T) {
 					Filepath: "Makefile",
 					Line:     2,
 					Col:      2,
+					EndLine:  2,
+					EndCol:   15,
 					Command:  "rm -rf",
+					RuleID:   "rm-rf",
+					Severity: issue.SeverityInfo,
+					Message:  "rm -rf deletes build/ inside the repository",
+					Fix:      rmRFFix,
 				},
 				{
 					Filepath: "Makefile",
 					Line:     3,
 					Col:      2,
-					Command:  "rm -fr",
+					EndLine:  3,
+					EndCol:   14,
+					Command:  "rm -rf",
+					RuleID:   "rm-rf",
+					Severity: issue.SeverityInfo,
+					Message:  "rm -rf deletes temp/ inside the repository",
+					Fix:      rmRFFix,
 				},
 			},
 		},
@@ -187,13 +276,25 @@ clean:
 					Filepath: "Makefile",
 					Line:     3,
 					Col:      2,
+					EndLine:  3,
+					EndCol:   21,
 					Command:  "rm -rf",
+					RuleID:   "rm-rf",
+					Severity: issue.SeverityInfo,
+					Message:  "rm -rf deletes $(BUILD_DIR) inside the repository",
+					Fix:      rmRFFix,
 				},
 				{
 					Filepath: "Makefile",
 					Line:     4,
 					Col:      2,
+					EndLine:  4,
+					EndCol:   21,
 					Command:  "rm -rf",
+					RuleID:   "rm-rf",
+					Severity: issue.SeverityInfo,
+					Message:  "rm -rf deletes ${BUILD_DIR} inside the repository",
+					Fix:      rmRFFix,
 				},
 			},
 		},
@@ -218,16 +319,126 @@ clean:
 					Filepath: "Makefile",
 					Line:     3,
 					Col:      2,
+					EndLine:  3,
+					EndCol:   15,
 					Command:  "rm -rf",
+					RuleID:   "rm-rf",
+					Severity: issue.SeverityInfo,
+					Message:  "rm -rf deletes build/ inside the repository",
+					Fix:      rmRFFix,
 				},
 				{
 					Filepath: "Makefile",
 					Line:     5,
 					Col:      2,
-					Command:  "rm -fr",
+					EndLine:  5,
+					EndCol:   14,
+					Command:  "rm -rf",
+					RuleID:   "rm-rf",
+					Severity: issue.SeverityInfo,
+					Message:  "rm -rf deletes temp/ inside the repository",
+					Fix:      rmRFFix,
 				},
 			},
 		},
+		{
+			name: "variable assignments and comments are not recipes",
+			content: `RM_ALL = rm -rf /
+# rm -rf /
+clean:
+	echo "nothing to do"`,
+			filepath: "Makefile",
+			want:     nil,
+		},
+		{
+			name: "recipe prefixes",
+			content: `clean:
+	@rm -rf build/
+	-rm -rf dist/
+	+@ rm -rf out/`,
+			filepath: "Makefile",
+			want: []issue.Issue{
+				{
+					Filepath: "Makefile",
+					Line:     2,
+					Col:      3,
+					EndLine:  2,
+					EndCol:   16,
+					Command:  "rm -rf",
+					RuleID:   "rm-rf",
+					Severity: issue.SeverityInfo,
+					Message:  "rm -rf deletes build/ inside the repository",
+					Fix:      rmRFFix,
+				},
+				{
+					Filepath: "Makefile",
+					Line:     3,
+					Col:      3,
+					EndLine:  3,
+					EndCol:   15,
+					Command:  "rm -rf",
+					RuleID:   "rm-rf",
+					Severity: issue.SeverityInfo,
+					Message:  "rm -rf deletes dist/ inside the repository",
+					Fix:      rmRFFix,
+				},
+				{
+					Filepath: "Makefile",
+					Line:     4,
+					Col:      5,
+					EndLine:  4,
+					EndCol:   16,
+					Command:  "rm -rf",
+					RuleID:   "rm-rf",
+					Severity: issue.SeverityInfo,
+					Message:  "rm -rf deletes out/ inside the repository",
+					Fix:      rmRFFix,
+				},
+			},
+		},
+		{
+			name: "backslash continuation and inline recipe",
+			content: `clean: ; rm -rf build/
+distclean:
+	rm \
+	  -rf dist/`,
+			filepath: "Makefile",
+			want: []issue.Issue{
+				{
+					Filepath: "Makefile",
+					Line:     1,
+					Col:      10,
+					EndLine:  1,
+					EndCol:   23,
+					Command:  "rm -rf",
+					RuleID:   "rm-rf",
+					Severity: issue.SeverityInfo,
+					Message:  "rm -rf deletes build/ inside the repository",
+					Fix:      rmRFFix,
+				},
+				{
+					Filepath: "Makefile",
+					Line:     3,
+					Col:      2,
+					EndLine:  4,
+					EndCol:   13,
+					Command:  "rm -rf",
+					RuleID:   "rm-rf",
+					Severity: issue.SeverityInfo,
+					Message:  "rm -rf deletes dist/ inside the repository",
+					Fix:      rmRFFix,
+				},
+			},
+		},
+		{
+			name: "unbalanced conditional",
+			content: `clean:
+	rm -rf build/
+endif`,
+			filepath: "Makefile",
+			want:     nil,
+			wantErr:  true,
+		},
 		{
 			name:     "empty Makefile",
 			content:  "",
@@ -246,19 +457,37 @@ clean:
 					Filepath: "Makefile",
 					Line:     2,
 					Col:      4,
+					EndLine:  2,
+					EndCol:   17,
 					Command:  "rm -rf",
+					RuleID:   "rm-rf",
+					Severity: issue.SeverityInfo,
+					Message:  "rm -rf deletes build/ inside the repository",
+					Fix:      rmRFFix,
 				},
 				{
 					Filepath: "Makefile",
 					Line:     3,
 					Col:      3,
+					EndLine:  3,
+					EndCol:   17,
 					Command:  "rm -rf",
+					RuleID:   "rm-rf",
+					Severity: issue.SeverityInfo,
+					Message:  "rm -rf deletes temp/ inside the repository",
+					Fix:      rmRFFix,
 				},
 				{
 					Filepath: "Makefile",
 					Line:     4,
 					Col:      2,
+					EndLine:  4,
+					EndCol:   14,
 					Command:  "rm -rf",
+					RuleID:   "rm-rf",
+					Severity: issue.SeverityInfo,
+					Message:  "rm -rf deletes data/ inside the repository",
+					Fix:      rmRFFix,
 				},
 			},
 		},
@@ -266,18 +495,61 @@ clean:
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := scanMakefile(tt.content, tt.filepath)
+			got, err := scanMakefile(hazardous.DefaultRegistry, tt.content, tt.filepath)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
 			assert.Equal(t, tt.want, got, "scanMakefile() = %v, want %v", got, tt.want)
 		})
 	}
 }
 
+func TestRunExitCodes(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+		return path
+	}
+
+	dangerous := write("dangerous.sh", "rm -rf /opt/app/build\n")
+	safe := write("safe.sh", "ls -l\n")
+	broken := write("broken.sh", "if true then\nfi\n")
+	makefile := write("Makefile", "clean:\n\trm -rf /opt/app/build/\n")
+
+	tests := []struct {
+		name string
+		args []string
+		want int
+	}{
+		{name: "no findings", args: []string{safe}, want: exitOK},
+		{name: "findings above threshold", args: []string{dangerous}, want: exitFindings},
+		{name: "findings below threshold", args: []string{"--fail-on=error", dangerous}, want: exitOK},
+		{name: "failing disabled", args: []string{"--fail-on=none", dangerous}, want: exitOK},
+		{name: "rule disabled", args: []string{"--disable-rules=rm-rf", dangerous}, want: exitOK},
+		{name: "rule enabled again in the next run", args: []string{dangerous}, want: exitFindings},
+		{name: "makefile findings", args: []string{makefile}, want: exitFindings},
+		{name: "parse error", args: []string{broken}, want: exitError},
+		{name: "missing file", args: []string{filepath.Join(dir, "missing.sh")}, want: exitError},
+		{name: "invalid severity", args: []string{"--fail-on=fatal", dangerous}, want: exitError},
+		{name: "invalid format", args: []string{"--format=xml", dangerous}, want: exitError},
+		{name: "unknown flag", args: []string{"--nope", dangerous}, want: exitError},
+		{name: "no path", args: nil, want: exitError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, run(tt.args))
+		})
+	}
+}
+
 func TestMain(m *testing.M) {
 	os.Exit(testscript.RunMain(m, map[string]func() int{
 		"hazardous": func() int {
-			main()
-
-			return 0
+			return run(os.Args[1:])
 		},
 	}))
 }
@@ -0,0 +1,187 @@
+package command
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Arg is a single argument of a command line. Arguments whose value is only
+// known at run time, such as "$FLAGS", are not Literal and are treated as
+// operands.
+type Arg struct {
+	Value   string
+	Literal bool
+}
+
+// Literals returns literal arguments for the given strings.
+func Literals(values ...string) []Arg {
+	args := make([]Arg, len(values))
+	for i, v := range values {
+		args[i] = Arg{Value: v, Literal: true}
+	}
+
+	return args
+}
+
+// Match is an option found on a command line.
+type Match struct {
+	Option *Option
+	// Value is the argument of the option, and HasValue tells whether it was
+	// given one.
+	Value    string
+	HasValue bool
+	// Index is the index of the argument the option was found in.
+	Index int
+}
+
+// Parsed is a command line split into options and operands.
+type Parsed struct {
+	Options []Match
+	// Operands lists the indexes of the operand arguments.
+	Operands []int
+	// Unknown lists the options the spec does not know, and known options
+	// spelled incorrectly, e.g. --force=yes for an option taking no argument.
+	Unknown []string
+}
+
+// Has reports whether the option with the given canonical name is set.
+func (p *Parsed) Has(name string) bool {
+	_, ok := p.Lookup(name)
+	return ok
+}
+
+// Lookup returns the last occurrence of the named option.
+func (p *Parsed) Lookup(name string) (Match, bool) {
+	for i := len(p.Options) - 1; i >= 0; i-- {
+		if p.Options[i].Option.Name == name {
+			return p.Options[i], true
+		}
+	}
+
+	return Match{}, false
+}
+
+// Parse parses the arguments of the named command, excluding the command name
+// itself. Commands without a spec have all their options reported as unknown.
+func Parse(name string, args []Arg) *Parsed {
+	spec, ok := Lookup(name)
+	if !ok {
+		spec = &Spec{Name: name, Options: commonOptions}
+	}
+
+	return spec.Parse(args)
+}
+
+// Parse splits args into options and operands the way getopt_long does:
+// short options may be bundled (-rf), long options may be abbreviated and
+// given their argument after an equals sign (--target-directory=out), "--"
+// ends the options and, unless the spec is POSIX, options may follow
+// operands. LongOnly specs take every option as a long one, by its full name
+// only, and boolean values for options without an argument, as Go's flag
+// package does (-auto-approve=true).
+func (s *Spec) Parse(args []Arg) *Parsed {
+	p := &Parsed{}
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		v := arg.Value
+
+		switch {
+		case !arg.Literal || len(v) < 2 || v[0] != '-':
+			p.Operands = append(p.Operands, i)
+			if s.POSIX {
+				return p.rest(args, i+1)
+			}
+
+		case v == "--":
+			return p.rest(args, i+1)
+
+		case strings.HasPrefix(v, "--"):
+			i = s.parseLong(p, args, i, v[2:])
+
+		case s.LongOnly:
+			i = s.parseLong(p, args, i, v[1:])
+
+		default:
+			i = s.parseShort(p, args, i)
+		}
+	}
+
+	return p
+}
+
+// rest adds the arguments from index i on as operands.
+func (p *Parsed) rest(args []Arg, i int) *Parsed {
+	for ; i < len(args); i++ {
+		p.Operands = append(p.Operands, i)
+	}
+
+	return p
+}
+
+// parseLong parses the long option in args[i], written as option without its
+// dashes.
+func (s *Spec) parseLong(p *Parsed, args []Arg, i int, option string) int {
+	arg := args[i].Value
+	name, value, hasValue := strings.Cut(option, "=")
+
+	opt := s.long(name)
+	switch {
+	case opt != nil && opt.Arg == NoArg && hasValue && s.LongOnly:
+		// Go's flag package takes a value for boolean flags, e.g.
+		// -auto-approve=true; a false one leaves the option unset
+		set, err := strconv.ParseBool(value)
+		switch {
+		case err != nil:
+			p.Unknown = append(p.Unknown, arg)
+		case set:
+			p.Options = append(p.Options, Match{Option: opt, Index: i})
+		}
+
+		return i
+	case opt == nil, opt.Arg == NoArg && hasValue:
+		p.Unknown = append(p.Unknown, arg)
+		return i
+	case opt.Arg == RequiredArg && !hasValue && i+1 < len(args):
+		value, hasValue = args[i+1].Value, true
+		p.Options = append(p.Options, Match{Option: opt, Value: value, HasValue: hasValue, Index: i})
+		return i + 1
+	}
+
+	p.Options = append(p.Options, Match{Option: opt, Value: value, HasValue: hasValue, Index: i})
+
+	return i
+}
+
+func (s *Spec) parseShort(p *Parsed, args []Arg, i int) int {
+	arg := args[i].Value
+
+	for j := 1; j < len(arg); j++ {
+		opt := s.short(arg[j])
+		if opt == nil {
+			p.Unknown = append(p.Unknown, "-"+arg[j:j+1])
+			continue
+		}
+
+		if opt.Arg == NoArg {
+			p.Options = append(p.Options, Match{Option: opt, Index: i})
+			continue
+		}
+
+		// the rest of the bundle, or the next argument, is the value
+		m := Match{Option: opt, Index: i}
+		switch {
+		case j+1 < len(arg):
+			m.Value, m.HasValue = arg[j+1:], true
+		case opt.Arg == RequiredArg && i+1 < len(args):
+			m.Value, m.HasValue = args[i+1].Value, true
+			i++
+		}
+
+		p.Options = append(p.Options, m)
+
+		return i
+	}
+
+	return i
+}
@@ -0,0 +1,171 @@
+package command
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name         string
+		command      string
+		args         []Arg
+		wantOptions  []string
+		wantOperands []int
+		wantUnknown  []string
+	}{
+		{
+			name:         "bundled short options",
+			command:      "rm",
+			args:         Literals("-Rf", "build"),
+			wantOptions:  []string{"recursive", "force"},
+			wantOperands: []int{1},
+		},
+		{
+			name:         "options after operands",
+			command:      "rm",
+			args:         Literals("build", "-r", "--force"),
+			wantOptions:  []string{"recursive", "force"},
+			wantOperands: []int{0},
+		},
+		{
+			name:         "end of options",
+			command:      "rm",
+			args:         Literals("-f", "--", "-r"),
+			wantOptions:  []string{"force"},
+			wantOperands: []int{2},
+		},
+		{
+			name:         "abbreviated long option",
+			command:      "rm",
+			args:         Literals("--rec", "build"),
+			wantOptions:  []string{"recursive"},
+			wantOperands: []int{1},
+		},
+		{
+			name:         "ambiguous long option",
+			command:      "rm",
+			args:         Literals("--ver", "build"),
+			wantOperands: []int{1},
+			wantUnknown:  []string{"--ver"},
+		},
+		{
+			name:         "value for an option without argument",
+			command:      "rm",
+			args:         Literals("--force=yes", "build"),
+			wantOperands: []int{1},
+			wantUnknown:  []string{"--force=yes"},
+		},
+		{
+			name:         "required argument in the next word",
+			command:      "cp",
+			args:         Literals("-t", "out", "a", "b"),
+			wantOptions:  []string{"target-directory"},
+			wantOperands: []int{2, 3},
+		},
+		{
+			name:         "required argument attached",
+			command:      "cp",
+			args:         Literals("-tout", "--suffix=.bak", "a"),
+			wantOptions:  []string{"target-directory", "suffix"},
+			wantOperands: []int{2},
+		},
+		{
+			name:         "optional argument is never taken from the next word",
+			command:      "rm",
+			args:         Literals("--interactive", "never"),
+			wantOptions:  []string{"interactive"},
+			wantOperands: []int{1},
+		},
+		{
+			name:         "non-literal argument is an operand",
+			command:      "rm",
+			args:         []Arg{{Value: "-rf"}, {Value: "build", Literal: true}},
+			wantOperands: []int{0, 1},
+		},
+		{
+			name:         "single dash is an operand",
+			command:      "rm",
+			args:         Literals("-"),
+			wantOperands: []int{0},
+		},
+		{
+			name:         "single dash long options",
+			command:      "terraform-apply",
+			args:         Literals("-auto-approve", "--target=module.db", "-var", "env=prod"),
+			wantOptions:  []string{"auto-approve", "target", "var"},
+			wantOperands: nil,
+		},
+		{
+			name:         "boolean values of single dash options",
+			command:      "terraform-apply",
+			args:         Literals("-auto-approve=true", "-destroy=false", "-json=maybe"),
+			wantOptions:  []string{"auto-approve"},
+			wantOperands: nil,
+			wantUnknown:  []string{"-json=maybe"},
+		},
+		{
+			name:         "single dash options are not abbreviated",
+			command:      "terraform-apply",
+			args:         Literals("-auto", "--auto-approve"),
+			wantOptions:  []string{"auto-approve"},
+			wantOperands: nil,
+			wantUnknown:  []string{"-auto"},
+		},
+		{
+			name:         "unknown command",
+			command:      "frobnicate",
+			args:         Literals("-x", "--help", "file"),
+			wantOptions:  []string{"help"},
+			wantOperands: []int{2},
+			wantUnknown:  []string{"-x"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Parse(tt.command, tt.args)
+
+			var names []string
+			for _, m := range got.Options {
+				names = append(names, m.Option.Name)
+			}
+
+			assert.Equal(t, tt.wantOptions, names)
+			assert.Equal(t, tt.wantOperands, got.Operands)
+			assert.Equal(t, tt.wantUnknown, got.Unknown)
+		})
+	}
+}
+
+func TestParseValue(t *testing.T) {
+	got := Parse("cp", Literals("--suffix", ".orig", "-S.bak", "a", "b"))
+
+	m, ok := got.Lookup("suffix")
+	require.True(t, ok)
+	assert.Equal(t, ".bak", m.Value)
+	assert.Equal(t, 2, m.Index)
+	assert.False(t, got.Has("recursive"))
+}
+
+func TestSpecs(t *testing.T) {
+	for _, name := range Names() {
+		spec, ok := Lookup(name)
+		require.True(t, ok)
+
+		seen := map[string]bool{}
+		for _, opt := range spec.Options {
+			for _, c := range opt.Short {
+				assert.False(t, seen["-"+string(c)], "%s: -%c is defined twice", name, c)
+				seen["-"+string(c)] = true
+			}
+
+			for _, long := range opt.Long {
+				assert.False(t, seen["--"+long], "%s: --%s is defined twice", name, long)
+				seen["--"+long] = true
+			}
+		}
+	}
+}
@@ -0,0 +1,144 @@
+// Package command describes the command-line interface of common commands
+// and parses their arguments the way getopt_long does, so that rules can ask
+// whether an option is set instead of matching flag strings.
+package command
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ArgKind tells whether an option takes an argument.
+type ArgKind string
+
+const (
+	NoArg       ArgKind = ""
+	RequiredArg ArgKind = "required"
+	// OptionalArg options only take an argument attached to them, as in
+	// `--backup=numbered` or `-n5`.
+	OptionalArg ArgKind = "optional"
+)
+
+// Option is a single option of a command.
+type Option struct {
+	// Name is the canonical name of the option, shared by all its spellings,
+	// e.g. "recursive" for -r, -R and --recursive.
+	Name string `json:"name"`
+	// Short lists the letters of the single-dash spellings.
+	Short string `json:"short,omitempty"`
+	// Long lists the double-dash spellings, without the dashes.
+	Long []string `json:"long,omitempty"`
+	Arg  ArgKind  `json:"arg,omitempty"`
+}
+
+// Spec is the command-line interface of a command.
+type Spec struct {
+	Name    string    `json:"-"`
+	Options []*Option `json:"options"`
+	// POSIX is set for commands that stop parsing options at the first
+	// operand, usually because the operands are another command line.
+	POSIX bool `json:"posix,omitempty"`
+	// LongOnly is set for commands parsing options the way Go's flag package
+	// does, e.g. terraform: long options may be given with a single dash, as
+	// in -auto-approve.
+	LongOnly bool `json:"longonly,omitempty"`
+	// Aliases lists other names of commands sharing the spec, e.g. sh for
+	// bash.
+	Aliases []string `json:"aliases,omitempty"`
+}
+
+// commonOptions are understood by every GNU command.
+var commonOptions = []*Option{
+	{Name: "help", Long: []string{"help"}},
+	{Name: "version", Long: []string{"version"}},
+}
+
+//go:embed specs.json
+var specsJSON []byte
+
+var specs = loadSpecs(specsJSON)
+
+func loadSpecs(data []byte) map[string]*Spec {
+	var loaded map[string]*Spec
+	if err := json.Unmarshal(data, &loaded); err != nil {
+		panic(fmt.Sprintf("loading command specs: %v", err))
+	}
+
+	for name, spec := range loaded {
+		spec.Name = name
+		spec.Options = append(spec.Options, commonOptions...)
+	}
+
+	for _, spec := range loaded {
+		for _, alias := range spec.Aliases {
+			loaded[alias] = spec
+		}
+	}
+
+	return loaded
+}
+
+// Lookup returns the spec of the named command.
+func Lookup(name string) (*Spec, bool) {
+	spec, ok := specs[name]
+	return spec, ok
+}
+
+// Names returns the commands with a spec, sorted.
+func Names() []string {
+	names := make([]string, 0, len(specs))
+	for name := range specs {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+func (s *Spec) short(c byte) *Option {
+	for _, opt := range s.Options {
+		if strings.IndexByte(opt.Short, c) >= 0 {
+			return opt
+		}
+	}
+
+	return nil
+}
+
+// long finds a long option by name or, unless the spec is LongOnly, by an
+// unambiguous prefix of its name, as getopt_long does. Go's flag package
+// takes no abbreviations.
+func (s *Spec) long(name string) *Option {
+	var match *Option
+
+	for _, opt := range s.Options {
+		for _, long := range opt.Long {
+			if long == name {
+				return opt
+			}
+		}
+	}
+
+	if s.LongOnly {
+		return nil
+	}
+
+	for _, opt := range s.Options {
+		for _, long := range opt.Long {
+			if !strings.HasPrefix(long, name) || len(name) == 0 {
+				continue
+			}
+
+			if match != nil && match.Name != opt.Name {
+				return nil
+			}
+			match = opt
+		}
+	}
+
+	return match
+}
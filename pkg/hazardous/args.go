@@ -0,0 +1,103 @@
+package hazardous
+
+import (
+	"strings"
+
+	"github.com/hiteshrepo/hazardous/pkg/command"
+	"mvdan.cc/sh/syntax"
+)
+
+// parseCall parses the arguments of c with the spec of its command.
+func parseCall(c *call) *command.Parsed {
+	return command.Parse(c.name, literalArgs(c.args))
+}
+
+// literalArgs converts words to command arguments.
+func literalArgs(words []*syntax.Word) []command.Arg {
+	args := make([]command.Arg, 0, len(words))
+
+	for _, word := range words {
+		value, ok := literalWord(word)
+		args = append(args, command.Arg{Value: value, Literal: ok})
+	}
+
+	return args
+}
+
+// operandWords returns the words of the operands of c.
+func operandWords(c *call, parsed *command.Parsed) []*syntax.Word {
+	words := make([]*syntax.Word, 0, len(parsed.Operands))
+
+	for _, i := range parsed.Operands {
+		words = append(words, c.args[i])
+	}
+
+	return words
+}
+
+// literalWord returns the value of a word made only of literal text, quoted
+// or not, and false if it contains expansions.
+func literalWord(word *syntax.Word) (string, bool) {
+	var sb strings.Builder
+
+	for _, part := range flattenParts(word.Parts) {
+		switch p := part.(type) {
+		case *syntax.Lit:
+			sb.WriteString(p.Value)
+		case *syntax.SglQuoted:
+			sb.WriteString(p.Value)
+		default:
+			return "", false
+		}
+	}
+
+	return sb.String(), true
+}
+
+// constantWord returns the value of a word made of literal text and variables
+// holding constants, and false if it depends on values only known at run
+// time.
+func (ctx *Context) constantWord(word *syntax.Word) (string, bool) {
+	var sb strings.Builder
+
+	for _, part := range flattenParts(word.Parts) {
+		switch p := part.(type) {
+		case *syntax.Lit:
+			sb.WriteString(p.Value)
+		case *syntax.SglQuoted:
+			sb.WriteString(p.Value)
+		case *syntax.ParamExp:
+			value, ok := ctx.constant(p)
+			if !ok {
+				return "", false
+			}
+			sb.WriteString(value)
+		default:
+			return "", false
+		}
+	}
+
+	return sb.String(), true
+}
+
+// subcommand returns the call of the subcommand of c, e.g. push in git push,
+// named after both so that its arguments are parsed with their own spec, e.g.
+// git-push. args are the arguments of c, parsed with a spec that stops at the
+// first operand.
+func (c *call) subcommand(args *command.Parsed) (*call, bool) {
+	if len(args.Operands) == 0 {
+		return nil, false
+	}
+
+	i := args.Operands[0]
+
+	name, ok := literalWord(c.args[i])
+	if !ok {
+		return nil, false
+	}
+
+	sub := *c
+	sub.name, sub.word, sub.args = c.name+"-"+name, c.args[i], c.args[i+1:]
+
+	return &sub, true
+}
@@ -0,0 +1,353 @@
+package hazardous
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/hiteshrepo/hazardous/pkg/command"
+	"github.com/hiteshrepo/hazardous/pkg/issue"
+	"mvdan.cc/sh/syntax"
+)
+
+// call is a command invocation as the shell runs it, with its name
+// normalized so that rules can match on it: /bin/rm, \rm, "r""m", command rm
+// and $RM with RM=rm are all calls to rm. Commands run by wrappers such as
+// sudo, xargs or find -exec are calls of their own.
+type call struct {
+	expr *syntax.CallExpr
+	name string
+	// word is the word naming the command, e.g. ./install.sh.
+	word *syntax.Word
+	// args are the arguments following the name, including those coming from
+	// a variable holding a command line such as $(RM) in a Makefile.
+	args []*syntax.Word
+
+	// privileged names the wrapper running the command as another user,
+	// e.g. "sudo", and runAs that user.
+	privileged string
+	runAs      string
+	// input is set when the wrapper appends operands read at run time, as
+	// xargs does.
+	input bool
+	// placeholder is replaced by the wrapper with operands read at run time,
+	// e.g. {} in find -exec.
+	placeholder string
+}
+
+// commandPrefixes run the command given as their first operand.
+var commandPrefixes = map[string]bool{"builtin": true, "command": true, "exec": true}
+
+// wrapper describes a command that runs the command line given as its
+// operands.
+type wrapper struct {
+	// skip reports whether the leading operand at index i is an argument of
+	// the wrapper rather than the command, e.g. the duration of timeout.
+	skip func(i int, arg string) bool
+	// stops lists the options with which the wrapper does not run the
+	// command, e.g. sudo --list.
+	stops []string
+	// privileged wrappers run the command as another user, root by default.
+	privileged bool
+}
+
+var wrappers = map[string]wrapper{
+	"doas":    {privileged: true, stops: []string{"clear-persist", "config"}},
+	"env":     {skip: isAssignment},
+	"nice":    {},
+	"nohup":   {},
+	"sudo":    {privileged: true, skip: isAssignment, stops: []string{"edit", "list", "remove-timestamp", "reset-timestamp", "validate"}},
+	"timeout": {skip: func(i int, _ string) bool { return i == 0 }},
+	"xargs":   {},
+}
+
+// findExec lists the actions of find that run a command.
+var findExec = map[string]bool{"-exec": true, "-execdir": true, "-ok": true, "-okdir": true}
+
+// maxWrappers bounds the nesting of wrappers.
+const maxWrappers = 8
+
+func isAssignment(_ int, arg string) bool {
+	return strings.Contains(arg, "=") && !strings.HasPrefix(arg, "=")
+}
+
+// calls normalizes the commands run by cmd: the command itself and any
+// command it wraps. Calls whose name is only known at run time, or that do
+// not run a command, as in `command -v rm`, are left out.
+func (ctx *Context) calls(cmd *syntax.CallExpr) []*call {
+	if len(cmd.Args) == 0 {
+		return nil
+	}
+
+	c := &call{expr: cmd}
+	if ctx.script != nil {
+		c.privileged, c.runAs = ctx.script.via.privileged, ctx.script.via.runAs
+	}
+
+	return ctx.unwrap(c, cmd.Args, 0)
+}
+
+// lastCall returns the command run by st, unwrapped from sudo and the like.
+func (ctx *Context) lastCall(st *syntax.Stmt) *call {
+	cmd, ok := st.Cmd.(*syntax.CallExpr)
+	if !ok {
+		return nil
+	}
+
+	calls := ctx.calls(cmd)
+	if len(calls) == 0 {
+		return nil
+	}
+
+	return calls[len(calls)-1]
+}
+
+// unwrap resolves the command line words run by outer.
+func (ctx *Context) unwrap(outer *call, words []*syntax.Word, depth int) []*call {
+	if depth > maxWrappers {
+		return nil
+	}
+
+	name, extra := ctx.commandName(words[0])
+	if len(name) == 0 {
+		return nil
+	}
+
+	c := *outer
+	c.name, c.word, c.args = name, words[0], append(extra, words[1:]...)
+
+	if name == "find" {
+		return append([]*call{&c}, ctx.unwrapFind(&c, depth)...)
+	}
+
+	w, isWrapper := wrappers[name]
+	if !isWrapper && !commandPrefixes[name] {
+		return []*call{&c}
+	}
+
+	args := command.Parse(name, literalArgs(c.args))
+	if args.Has("describe") || args.Has("verbose-describe") {
+		return nil
+	}
+
+	for _, opt := range w.stops {
+		if args.Has(opt) {
+			return []*call{&c}
+		}
+	}
+
+	operands := args.Operands
+	for w.skip != nil && len(operands) > 0 {
+		value, _ := literalWord(c.args[operands[0]])
+		if !w.skip(len(args.Operands)-len(operands), value) {
+			break
+		}
+		operands = operands[1:]
+	}
+
+	inner := c
+	switch {
+	case args.Has("split-string"):
+		m, _ := args.Lookup("split-string")
+		words = splitWords(c.args[m.Index], m.Value)
+	case len(operands) > 0:
+		words = c.args[operands[0]:]
+	default:
+		return []*call{&c}
+	}
+
+	if w.privileged {
+		inner.privileged, inner.runAs = name, "root"
+		if m, ok := args.Lookup("user"); ok {
+			inner.runAs = m.Value
+		}
+	}
+
+	if name == "xargs" {
+		inner.input = true
+		if m, ok := args.Lookup("replace-str"); ok {
+			inner.input, inner.placeholder = false, m.Value
+		} else if m, ok := args.Lookup("replace"); ok {
+			inner.input, inner.placeholder = false, "{}"
+			if m.HasValue {
+				inner.placeholder = m.Value
+			}
+		}
+	}
+
+	if len(words) == 0 {
+		return []*call{&c}
+	}
+
+	if commandPrefixes[name] {
+		return ctx.unwrap(&inner, words, depth+1)
+	}
+
+	return append([]*call{&c}, ctx.unwrap(&inner, words, depth+1)...)
+}
+
+// unwrapFind resolves the commands run by the -exec actions of find, which
+// end at a ";" or "+" argument and replace {} with the files found.
+func (ctx *Context) unwrapFind(c *call, depth int) []*call {
+	var calls []*call
+
+	for i := 0; i < len(c.args); i++ {
+		if value, _ := literalWord(c.args[i]); !findExec[value] {
+			continue
+		}
+
+		start := i + 1
+		for i++; i < len(c.args); i++ {
+			if value, _ := literalWord(c.args[i]); unescape(value, "") == ";" || value == "+" {
+				break
+			}
+		}
+
+		if start < i {
+			inner := *c
+			inner.placeholder = "{}"
+			calls = append(calls, ctx.unwrap(&inner, c.args[start:i], depth+1)...)
+		}
+	}
+
+	return calls
+}
+
+// splitWords splits the value of an option holding a command line, e.g.
+// env -S, into words positioned at the word the option was given in.
+func splitWords(word *syntax.Word, value string) []*syntax.Word {
+	var words []*syntax.Word
+
+	for _, f := range strings.Fields(value) {
+		words = append(words, &syntax.Word{Parts: []syntax.WordPart{
+			&syntax.Lit{ValuePos: word.Pos(), ValueEnd: word.End(), Value: f},
+		}})
+	}
+
+	return words
+}
+
+// elevate raises the severity of a finding on a command run with sudo or
+// doas, which reaches files the user running the script cannot.
+func (c *call) elevate(found *issue.Issue) {
+	if len(c.privileged) == 0 {
+		return
+	}
+
+	if found.Severity < issue.SeverityError {
+		found.Severity++
+	}
+
+	found.Command = c.privileged + " " + found.Command
+	found.Message += fmt.Sprintf(", running as %s through %s", c.runAs, c.privileged)
+}
+
+// isPlaceholder reports whether word stands for operands given at run time.
+func (c *call) isPlaceholder(word *syntax.Word) bool {
+	value, ok := literalWord(word)
+	return ok && len(c.placeholder) > 0 && strings.Contains(value, c.placeholder)
+}
+
+// commandName resolves the word naming a command to the base name of the
+// command. An unquoted variable holding a command line, e.g. RM = rm -f,
+// also yields the words following the name.
+func (ctx *Context) commandName(word *syntax.Word) (string, []*syntax.Word) {
+	if len(word.Parts) == 1 {
+		if p, ok := word.Parts[0].(*syntax.ParamExp); ok {
+			value, _ := ctx.constant(p)
+			fields := strings.Fields(value)
+			if len(fields) == 0 {
+				return "", nil
+			}
+
+			return path.Base(fields[0]), splitWords(word, strings.Join(fields[1:], " "))
+		}
+	}
+
+	var sb strings.Builder
+
+	for _, part := range word.Parts {
+		switch p := part.(type) {
+		case *syntax.Lit:
+			sb.WriteString(unescape(p.Value, ""))
+		case *syntax.SglQuoted:
+			sb.WriteString(p.Value)
+		case *syntax.DblQuoted:
+			for _, part := range p.Parts {
+				switch p := part.(type) {
+				case *syntax.Lit:
+					sb.WriteString(unescape(p.Value, "$`\"\\\n"))
+				case *syntax.ParamExp:
+					value, ok := ctx.constant(p)
+					if !ok {
+						return "", nil
+					}
+					sb.WriteString(value)
+				default:
+					return "", nil
+				}
+			}
+		case *syntax.ParamExp:
+			value, ok := ctx.constant(p)
+			if !ok {
+				return "", nil
+			}
+			sb.WriteString(value)
+		default:
+			return "", nil
+		}
+	}
+
+	name := sb.String()
+	if strings.Contains(name, "/") {
+		name = path.Base(name)
+	}
+
+	return name, nil
+}
+
+// constant returns the value of a plain expansion of a variable holding a
+// constant.
+func (ctx *Context) constant(p *syntax.ParamExp) (string, bool) {
+	if !plainParam(p) {
+		return "", false
+	}
+
+	b, ok := ctx.vars.lookup(p)
+	if !ok || len(b.value) == 0 {
+		return "", false
+	}
+
+	return b.value, true
+}
+
+// plainParam reports whether p expands a variable as it is, as $DIR or ${DIR}
+// do, rather than a default, length, slice or substitution of it.
+func plainParam(p *syntax.ParamExp) bool {
+	return p.Exp == nil && !p.Length && !p.Excl && p.Index == nil && p.Slice == nil && p.Repl == nil
+}
+
+// unescape removes the backslashes quoting a character in a literal. Inside
+// double quotes, only the characters in special are escaped; an empty special
+// stands for any character, as outside quotes. A backslash before a newline
+// joins the lines.
+func unescape(s, special string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+
+	var sb strings.Builder
+
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) && (special == "" || strings.IndexByte(special, s[i+1]) >= 0) {
+			i++
+			if s[i] == '\n' {
+				continue
+			}
+		}
+
+		sb.WriteByte(s[i])
+	}
+
+	return sb.String()
+}
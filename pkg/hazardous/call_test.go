@@ -0,0 +1,202 @@
+package hazardous
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hiteshrepo/hazardous/pkg/issue"
+	"github.com/hiteshrepo/hazardous/pkg/makefile"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"mvdan.cc/sh/syntax"
+)
+
+func TestResolveCall(t *testing.T) {
+	tests := []struct {
+		name     string
+		script   string
+		wantName string
+		wantArgs []string
+	}{
+		{name: "plain", script: "rm -rf /", wantName: "rm", wantArgs: []string{"-rf", "/"}},
+		{name: "absolute path", script: "/bin/rm -rf /", wantName: "rm", wantArgs: []string{"-rf", "/"}},
+		{name: "relative path", script: "./bin/rm -rf /", wantName: "rm", wantArgs: []string{"-rf", "/"}},
+		{name: "escaped alias", script: `\rm -rf /`, wantName: "rm", wantArgs: []string{"-rf", "/"}},
+		{name: "concatenated quotes", script: `"r""m" -rf /`, wantName: "rm", wantArgs: []string{"-rf", "/"}},
+		{name: "mixed quotes", script: `r'm' -rf /`, wantName: "rm", wantArgs: []string{"-rf", "/"}},
+		{name: "escape in double quotes", script: `"\rm" -rf /`, wantName: `\rm`, wantArgs: []string{"-rf", "/"}},
+		{name: "command prefix", script: "command rm -rf /", wantName: "rm", wantArgs: []string{"-rf", "/"}},
+		{name: "command with options", script: "command -p -- rm -rf /", wantName: "rm", wantArgs: []string{"-rf", "/"}},
+		{name: "builtin prefix", script: "builtin rm -rf /", wantName: "rm", wantArgs: []string{"-rf", "/"}},
+		{name: "exec prefix", script: "exec -a cleanup rm -rf /", wantName: "rm", wantArgs: []string{"-rf", "/"}},
+		{name: "nested prefixes", script: "exec command /bin/rm -rf /", wantName: "rm", wantArgs: []string{"-rf", "/"}},
+		{name: "command lookup", script: "command -v rm"},
+		{name: "exec without a command", script: "exec", wantName: "exec"},
+		{name: "constant variable", script: "RM=rm\n$RM -rf /", wantName: "rm", wantArgs: []string{"-rf", "/"}},
+		{name: "quoted constant variable", script: "RM=/bin/rm\n\"$RM\" -rf /", wantName: "rm", wantArgs: []string{"-rf", "/"}},
+		{name: "variable holding a command line", script: "RM='rm -f'\n$RM -r /", wantName: "rm", wantArgs: []string{"-f", "-r", "/"}},
+		{name: "unknown variable", script: "$RM -rf /"},
+		{name: "command substitution", script: "$(which rm) -rf /"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := parseScript(t, tt.script)
+
+			ctx := &Context{Filepath: "test.sh", vars: trackVariables(file)}
+			cmd := file.Stmts[len(file.Stmts)-1].Cmd.(*syntax.CallExpr)
+
+			calls := ctx.calls(cmd)
+			if len(tt.wantName) == 0 {
+				assert.Empty(t, calls)
+				return
+			}
+
+			require.Len(t, calls, 1)
+			c := calls[0]
+			assert.Equal(t, tt.wantName, c.name)
+
+			var args []string
+			for _, w := range c.args {
+				args = append(args, ctx.wordText(w))
+			}
+			assert.Equal(t, tt.wantArgs, args)
+		})
+	}
+}
+
+func TestResolveCallMakefile(t *testing.T) {
+	mf, err := makefile.Parse(strings.NewReader(`clean:
+	$(RM) -r /
+`), "Makefile")
+	require.NoError(t, err)
+
+	issues, err := NewRegistry(rmRule).CheckMakefile("Makefile", mf)
+	require.NoError(t, err)
+
+	require.Len(t, issues, 1)
+	assert.Equal(t, "rm -rf", issues[0].Command)
+	assert.Equal(t, "rm -rf deletes the entire filesystem (/)", issues[0].Message)
+}
+
+func TestWrappedCalls(t *testing.T) {
+	tests := []struct {
+		name         string
+		script       string
+		wantCommand  string
+		wantSeverity issue.Severity
+		wantMessage  string
+	}{
+		{
+			name:         "sudo",
+			script:       "sudo rm -rf /opt/app",
+			wantCommand:  "sudo rm -rf",
+			wantSeverity: issue.SeverityError,
+			wantMessage:  "rm -rf deletes files recursively without asking for confirmation, running as root through sudo",
+		},
+		{
+			name:         "sudo as another user",
+			script:       "sudo -u deploy -H rm -rf build",
+			wantCommand:  "sudo rm -rf",
+			wantSeverity: issue.SeverityWarning,
+			wantMessage:  "rm -rf deletes build inside the repository, running as deploy through sudo",
+		},
+		{
+			name:         "sudo with environment",
+			script:       "sudo DEBUG=1 /bin/rm -r ~/",
+			wantCommand:  "sudo rm -r",
+			wantSeverity: issue.SeverityError,
+			wantMessage:  "rm -r deletes the home directory (~/), running as root through sudo",
+		},
+		{
+			name:         "doas",
+			script:       "doas rm -rf /",
+			wantCommand:  "doas rm -rf",
+			wantSeverity: issue.SeverityError,
+			wantMessage:  "rm -rf deletes the entire filesystem (/), running as root through doas",
+		},
+		{
+			name:         "env",
+			script:       "env -i PATH=/bin rm -rf build",
+			wantCommand:  "rm -rf",
+			wantSeverity: issue.SeverityInfo,
+			wantMessage:  "rm -rf deletes build inside the repository",
+		},
+		{
+			name:         "env split string",
+			script:       "env -S 'rm -rf /'",
+			wantCommand:  "rm -rf",
+			wantSeverity: issue.SeverityError,
+			wantMessage:  "rm -rf deletes the entire filesystem (/)",
+		},
+		{
+			name:         "timeout",
+			script:       "timeout -s KILL 30 rm -rf /",
+			wantCommand:  "rm -rf",
+			wantSeverity: issue.SeverityError,
+			wantMessage:  "rm -rf deletes the entire filesystem (/)",
+		},
+		{
+			name:         "nested wrappers",
+			script:       "nice -n 10 nohup sudo rm -rf ~",
+			wantCommand:  "sudo rm -rf",
+			wantSeverity: issue.SeverityError,
+			wantMessage:  "rm -rf deletes the home directory (~), running as root through sudo",
+		},
+		{
+			name:         "find -exec",
+			script:       "find / -name '*.o' -exec rm -rf {} +",
+			wantCommand:  "rm -rf",
+			wantSeverity: issue.SeverityWarning,
+			wantMessage:  "rm -rf deletes files recursively without asking for confirmation",
+		},
+		{
+			name:         "second find -exec",
+			script:       `find . -type d -exec echo {} \; -execdir rm -r {} \;`,
+			wantCommand:  "rm -r",
+			wantSeverity: issue.SeverityWarning,
+			wantMessage:  "rm -r deletes files recursively",
+		},
+		{
+			name:         "xargs",
+			script:       "ls | xargs -0 rm -rf build",
+			wantCommand:  "rm -rf",
+			wantSeverity: issue.SeverityWarning,
+			wantMessage:  "rm -rf deletes files recursively without asking for confirmation",
+		},
+		{
+			name:         "xargs with a fixed target",
+			script:       "ls | xargs rm -rf /",
+			wantCommand:  "rm -rf",
+			wantSeverity: issue.SeverityError,
+			wantMessage:  "rm -rf deletes the entire filesystem (/)",
+		},
+		{
+			name:         "xargs replace string",
+			script:       "ls | xargs -I% rm -rf build/%",
+			wantCommand:  "rm -rf",
+			wantSeverity: issue.SeverityWarning,
+			wantMessage:  "rm -rf deletes files recursively without asking for confirmation",
+		},
+		{name: "sudo list", script: "sudo -l rm -rf /"},
+		{name: "timeout without a command", script: "timeout 30"},
+		{name: "wrapped rm -f", script: "sudo env rm -f file"},
+	}
+
+	r := NewRegistry(rmRule)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issues := checkScript(t, r, tt.script)
+			if len(tt.wantCommand) == 0 {
+				assert.Empty(t, issues)
+				return
+			}
+
+			require.Len(t, issues, 1)
+			assert.Equal(t, tt.wantCommand, issues[0].Command)
+			assert.Equal(t, tt.wantSeverity, issues[0].Severity)
+			assert.Equal(t, tt.wantMessage, issues[0].Message)
+		})
+	}
+}
@@ -0,0 +1,469 @@
+package hazardous
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/hiteshrepo/hazardous/pkg/command"
+	"github.com/hiteshrepo/hazardous/pkg/issue"
+)
+
+// namespaceTypes are the names kubectl accepts for namespaces.
+var namespaceTypes = []string{"ns", "namespace", "namespaces"}
+
+var awsRule = &commandRule{
+	id:          "aws-delete",
+	description: "aws CLI call deleting cloud resources in bulk or for good",
+	severity:    issue.SeverityWarning,
+	label:       "aws",
+	message:     "aws deletes cloud resources",
+	commands:    []string{"aws"},
+	classify:    classifyAws,
+}
+
+var gsutilRule = &commandRule{
+	id:          "gsutil-delete",
+	description: "gsutil call deleting Cloud Storage objects in bulk",
+	severity:    issue.SeverityWarning,
+	label:       "gsutil",
+	message:     "gsutil deletes objects",
+	commands:    []string{"gsutil"},
+	classify:    classifyGsutil,
+}
+
+var azRule = &commandRule{
+	id:          "az-delete",
+	description: "az CLI call deleting resource groups or blobs in bulk",
+	severity:    issue.SeverityWarning,
+	label:       "az",
+	message:     "az deletes cloud resources",
+	commands:    []string{"az"},
+	classify:    classifyAz,
+}
+
+var terraformRule = &commandRule{
+	id:          "terraform-destroy",
+	description: "terraform destroying infrastructure or applying changes without review",
+	severity:    issue.SeverityWarning,
+	label:       "terraform destroy",
+	message:     "terraform destroy deletes the infrastructure it manages",
+	fix:         "review a saved plan with terraform plan -out, then apply that plan file",
+	commands:    []string{"terraform"},
+	classify:    classifyTerraform,
+}
+
+var kubectlRule = &commandRule{
+	id:          "kubectl-delete",
+	description: "kubectl deleting namespaces or every resource of a kind",
+	severity:    issue.SeverityError,
+	label:       "kubectl delete",
+	message:     "kubectl delete deletes resources in bulk",
+	fix:         "delete resources by name or label selector, and preview with --dry-run=client",
+	commands:    []string{"kubectl"},
+	classify:    classifyKubectl,
+}
+
+var helmRule = &commandRule{
+	id:          "helm-uninstall",
+	description: "helm uninstalling a release and the resources it created",
+	severity:    issue.SeverityWarning,
+	label:       "helm uninstall",
+	message:     "helm uninstall deletes a release and the resources it created",
+	fix:         "preview with --dry-run, and keep the release history with --keep-history",
+	commands:    []string{"helm"},
+	classify:    classifyHelm,
+}
+
+// nested returns the call of the subcommand depth levels below c, e.g. s3 rm
+// two levels below aws, along with the names leading to it.
+func (c *call) nested(depth int) (*call, []string, bool) {
+	names := make([]string, 0, depth)
+
+	for range depth {
+		sub, ok := c.subcommand(parseCall(c))
+		if !ok {
+			return nil, nil, false
+		}
+
+		names = append(names, strings.TrimPrefix(sub.name, c.name+"-"))
+		c = sub
+	}
+
+	return c, names, true
+}
+
+// nameList joins names for a message, e.g. "a, b and c".
+func nameList(names []string) string {
+	if len(names) == 1 {
+		return names[0]
+	}
+
+	return fmt.Sprintf("%s and %s", strings.Join(names[:len(names)-1], ", "), names[len(names)-1])
+}
+
+// operandTexts returns the operands of c as written.
+func (ctx *Context) operandTexts(c *call, args *command.Parsed) []string {
+	var texts []string
+
+	for _, word := range operandWords(c, args) {
+		texts = append(texts, ctx.wordText(word))
+	}
+
+	return texts
+}
+
+// optionText returns the argument of the named option as written, e.g.
+// $STACK, or an empty string when the option is not set.
+func (ctx *Context) optionText(c *call, args *command.Parsed, name string) string {
+	m, ok := args.Lookup(name)
+	switch {
+	case !ok || !m.HasValue:
+		return ""
+	case len(m.Value) == 0 && m.Index+1 < len(c.args):
+		// expansions are only taken as the argument of an option when they
+		// follow it in their own word
+		return ctx.wordText(c.args[m.Index+1])
+	}
+
+	return m.Value
+}
+
+// named describes a resource of the given kind by name, e.g. "the stack web",
+// falling back to the kind alone when the name is missing.
+func named(kind, name string) string {
+	if len(name) == 0 {
+		return "a " + kind
+	}
+
+	return fmt.Sprintf("the %s %s", kind, name)
+}
+
+// classifyAws keeps the aws calls deleting data or resources for good:
+// recursive S3 deletions, bucket removals, S3 syncs with --delete, EC2
+// terminations, RDS deletions and CloudFormation stack deletions.
+func classifyAws(ctx *Context, c *call, _ *command.Parsed, found *issue.Issue) bool {
+	sub, names, ok := c.nested(2)
+	if !ok {
+		return false
+	}
+
+	args := parseCall(sub)
+	if args.Has("dryrun") || args.Has("dry-run") {
+		return false
+	}
+
+	found.Command = "aws " + strings.Join(names, " ")
+	operands := ctx.operandTexts(sub, args)
+
+	switch sub.name {
+	case "aws-s3-rm":
+		if !args.Has("recursive") || len(operands) == 0 {
+			return false
+		}
+
+		found.Command += " --recursive"
+		found.Message = fmt.Sprintf("%s deletes every object below %s", found.Command, operands[0])
+		found.Fix = "enable bucket versioning, and preview the deletion with --dryrun"
+		found.Severity = issue.SeverityError
+
+	case "aws-s3-rb":
+		if !args.Has("force") || len(operands) == 0 {
+			return false
+		}
+
+		found.Command += " --force"
+		found.Message = fmt.Sprintf("%s deletes the bucket %s along with every object in it", found.Command, operands[0])
+		found.Fix = "empty the bucket on purpose first, and keep versioning or backups enabled"
+		found.Severity = issue.SeverityError
+
+	case "aws-s3-sync":
+		if !args.Has("delete") || len(operands) < 2 {
+			return false
+		}
+
+		found.Command += " --delete"
+		found.Message = fmt.Sprintf("%s deletes the objects of %s missing from %s", found.Command, operands[1], operands[0])
+		found.Fix = "preview the sync with --dryrun, and double-check the order of source and destination"
+
+	case "aws-ec2-terminate-instances":
+		ids := slices.DeleteFunc(append([]string{ctx.optionText(sub, args, "instance-ids")}, operands...),
+			func(id string) bool { return len(id) == 0 })
+
+		target := "instances"
+		if len(ids) > 0 {
+			target = nameList(ids)
+		}
+
+		found.Message = fmt.Sprintf("%s terminates %s, deleting their instance store and root volumes", found.Command, target)
+		found.Fix = "stop instances instead, and turn on termination protection for the ones to keep"
+
+	case "aws-rds-delete-db-instance", "aws-rds-delete-db-cluster":
+		kind, option := "database instance", "db-instance-identifier"
+		if sub.name == "aws-rds-delete-db-cluster" {
+			kind, option = "database cluster", "db-cluster-identifier"
+		}
+
+		snapshot := ""
+		if args.Has("skip-final-snapshot") {
+			found.Command += " --skip-final-snapshot"
+			found.Severity = issue.SeverityError
+			snapshot = " without a final snapshot"
+		}
+
+		found.Message = fmt.Sprintf("%s deletes %s%s", found.Command, named(kind, ctx.optionText(sub, args, option)), snapshot)
+		found.Fix = "take a final snapshot and turn on deletion protection"
+
+	case "aws-cloudformation-delete-stack":
+		found.Message = fmt.Sprintf("%s deletes %s and every resource it created",
+			found.Command, named("stack", ctx.optionText(sub, args, "stack-name")))
+		found.Fix = "turn on termination protection, and retain stateful resources with a DeletionPolicy"
+
+	default:
+		return false
+	}
+
+	return true
+}
+
+// classifyGsutil keeps recursive gsutil rm calls, and gsutil rsync -d, which
+// deletes the destination objects missing from the source.
+func classifyGsutil(ctx *Context, c *call, args *command.Parsed, found *issue.Issue) bool {
+	sub, ok := c.subcommand(args)
+	if !ok {
+		return false
+	}
+
+	subArgs := parseCall(sub)
+	operands := ctx.operandTexts(sub, subArgs)
+
+	switch {
+	case sub.name == "gsutil-rm" && subArgs.Has("recursive") && len(operands) > 0:
+		found.Command = "gsutil rm -r"
+		found.Message = fmt.Sprintf("gsutil rm -r deletes every object below %s", nameList(operands))
+		found.Fix = "enable object versioning or soft delete on the bucket, and list what matches with gsutil ls first"
+		found.Severity = issue.SeverityError
+
+		if subArgs.Has("all-versions") {
+			found.Command = "gsutil rm -r -a"
+			found.Message = strings.Replace(found.Message, "rm -r", "rm -r -a", 1) + ", including their previous versions"
+		}
+
+	case sub.name == "gsutil-rsync" && subArgs.Has("delete") && !subArgs.Has("dry-run") && len(operands) > 1:
+		found.Command = "gsutil rsync -d"
+		found.Message = fmt.Sprintf("gsutil rsync -d deletes the objects of %s missing from %s", operands[1], operands[0])
+		found.Fix = "preview the sync with -n, and double-check the order of source and destination"
+
+	default:
+		return false
+	}
+
+	return true
+}
+
+// classifyAz keeps az group delete, which deletes every resource of a
+// resource group, and az storage blob delete-batch.
+func classifyAz(ctx *Context, c *call, _ *command.Parsed, found *issue.Issue) bool {
+	sub, names, ok := c.nested(2)
+	if ok && sub.name == "az-storage-blob" {
+		sub, names, ok = c.nested(3)
+	}
+
+	if !ok {
+		return false
+	}
+
+	args := parseCall(sub)
+	found.Command = "az " + strings.Join(names, " ")
+
+	switch sub.name {
+	case "az-group-delete":
+		confirm := ""
+		if args.Has("yes") {
+			found.Command += " --yes"
+			found.Severity = issue.SeverityError
+			confirm = " without asking for confirmation"
+		}
+
+		found.Message = fmt.Sprintf("%s deletes %s and every resource in it%s",
+			found.Command, named("resource group", ctx.optionText(sub, args, "name")), confirm)
+		found.Fix = "put a delete lock on resource groups to keep, and confirm deletions interactively"
+
+	case "az-storage-blob-delete-batch":
+		if args.Has("dryrun") {
+			return false
+		}
+
+		found.Message = fmt.Sprintf("%s deletes every blob in %s",
+			found.Command, named("container", ctx.optionText(sub, args, "source")))
+		if pattern := ctx.optionText(sub, args, "pattern"); len(pattern) > 0 {
+			found.Message += " matching " + pattern
+		}
+
+		found.Fix = "preview the deletion with --dryrun, and turn on soft delete for blobs"
+
+	default:
+		return false
+	}
+
+	return true
+}
+
+// classifyTerraform keeps terraform destroy and apply -destroy, which are
+// errors when they skip the confirmation with -auto-approve, and apply
+// -auto-approve without a saved plan, which applies changes nobody reviewed.
+func classifyTerraform(ctx *Context, c *call, args *command.Parsed, found *issue.Issue) bool {
+	sub, ok := c.subcommand(args)
+	if !ok || (sub.name != "terraform-destroy" && sub.name != "terraform-apply") {
+		return false
+	}
+
+	subArgs := parseCall(sub)
+	approved := subArgs.Has("auto-approve")
+
+	switch {
+	case sub.name == "terraform-destroy" || subArgs.Has("destroy"):
+		found.Command = "terraform destroy"
+		if sub.name == "terraform-apply" {
+			found.Command = "terraform apply -destroy"
+		}
+
+		confirm := ""
+		if approved {
+			found.Command += " -auto-approve"
+			found.Severity = issue.SeverityError
+			confirm = " without asking for confirmation"
+		}
+
+		target := "every resource managed by the configuration"
+		if targets := targetOptions(subArgs); len(targets) > 0 {
+			target = "the resources " + nameList(targets)
+		}
+
+		found.Message = fmt.Sprintf("%s deletes %s%s", found.Command, target, confirm)
+
+	case approved && len(subArgs.Operands) == 0 && !subArgs.Has("refresh-only"):
+		found.Command = "terraform apply -auto-approve"
+		found.Message = "terraform apply -auto-approve applies changes, including deletions and replacements, without review"
+
+	default:
+		return false
+	}
+
+	return true
+}
+
+// targetOptions returns the resources given to terraform with -target.
+func targetOptions(args *command.Parsed) []string {
+	var targets []string
+
+	for _, m := range args.Options {
+		if m.Option.Name == "target" && len(m.Value) > 0 {
+			targets = append(targets, m.Value)
+		}
+	}
+
+	return targets
+}
+
+// classifyKubectl keeps kubectl delete calls removing namespaces, which takes
+// everything in them along, or every resource of a kind with --all or
+// --all-namespaces.
+func classifyKubectl(ctx *Context, c *call, args *command.Parsed, found *issue.Issue) bool {
+	sub, ok := c.subcommand(args)
+	if !ok || sub.name != "kubectl-delete" {
+		return false
+	}
+
+	subArgs := parseCall(sub)
+	if m, ok := subArgs.Lookup("dry-run"); ok && m.Value != "none" {
+		return false
+	}
+
+	words := operandWords(sub, subArgs)
+	if len(words) == 0 {
+		return false
+	}
+
+	kinds, _ := literalWord(words[0])
+	names := ctx.operandTexts(sub, subArgs)[1:]
+
+	var namespaces bool
+
+	for _, kind := range strings.Split(kinds, ",") {
+		kind, name, ok := strings.Cut(kind, "/")
+		if slices.Contains(namespaceTypes, kind) {
+			namespaces = true
+
+			if ok {
+				names = append(names, name)
+			}
+		}
+	}
+
+	scope := "the current namespace"
+	for _, ns := range []string{ctx.optionText(sub, subArgs, "namespace"), ctx.optionText(c, args, "namespace")} {
+		if len(ns) > 0 {
+			scope = "the namespace " + ns
+			break
+		}
+	}
+
+	switch {
+	case namespaces && subArgs.Has("all"):
+		found.Command = "kubectl delete namespace --all"
+		found.Message = "kubectl delete namespace --all deletes every namespace and every resource in them"
+
+	case namespaces:
+		target := "the namespaces it is given and every resource in them"
+		switch len(names) {
+		case 0:
+		case 1:
+			target = fmt.Sprintf("the namespace %s and every resource in it", names[0])
+		default:
+			target = fmt.Sprintf("the namespaces %s and every resource in them", nameList(names))
+		}
+
+		found.Command = "kubectl delete namespace"
+		found.Message = "kubectl delete namespace deletes " + target
+
+	case subArgs.Has("all-namespaces"):
+		found.Command = "kubectl delete -A"
+		found.Message = fmt.Sprintf("kubectl delete -A deletes %s in every namespace", ctx.wordText(words[0]))
+
+	case subArgs.Has("all"):
+		found.Command = "kubectl delete --all"
+		found.Message = fmt.Sprintf("kubectl delete --all deletes all %s in %s", ctx.wordText(words[0]), scope)
+
+	default:
+		return false
+	}
+
+	return true
+}
+
+// classifyHelm keeps helm uninstall, which deletes the resources of a
+// release, including the persistent volume claims holding its data.
+func classifyHelm(ctx *Context, c *call, args *command.Parsed, found *issue.Issue) bool {
+	sub, ok := c.subcommand(args)
+	if !ok || !slices.Contains([]string{"helm-uninstall", "helm-delete", "helm-del", "helm-un"}, sub.name) {
+		return false
+	}
+
+	subArgs := parseCall(sub)
+	if subArgs.Has("dry-run") {
+		return false
+	}
+
+	releases := ctx.operandTexts(sub, subArgs)
+	if len(releases) == 0 {
+		return false
+	}
+
+	found.Message = fmt.Sprintf("helm uninstall deletes the release %s and the resources it created", releases[0])
+	if len(releases) > 1 {
+		found.Message = fmt.Sprintf("helm uninstall deletes the releases %s and the resources they created", nameList(releases))
+	}
+
+	return true
+}
@@ -0,0 +1,225 @@
+package hazardous
+
+import (
+	"testing"
+
+	"github.com/hiteshrepo/hazardous/pkg/issue"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCloudRules(t *testing.T) {
+	tests := []struct {
+		name         string
+		script       string
+		wantRule     string
+		wantCommand  string
+		wantMessage  string
+		wantSeverity issue.Severity
+	}{
+		{
+			name:         "recursive s3 deletion",
+			script:       "aws --profile prod s3 rm s3://backups/db --recursive",
+			wantRule:     "aws-delete",
+			wantCommand:  "aws s3 rm --recursive",
+			wantMessage:  "aws s3 rm --recursive deletes every object below s3://backups/db",
+			wantSeverity: issue.SeverityError,
+		},
+		{
+			name:         "forced bucket removal",
+			script:       `aws s3 rb "s3://$BUCKET" --force`,
+			wantRule:     "aws-delete",
+			wantCommand:  "aws s3 rb --force",
+			wantMessage:  "aws s3 rb --force deletes the bucket s3://$BUCKET along with every object in it",
+			wantSeverity: issue.SeverityError,
+		},
+		{
+			name:         "s3 sync deleting the destination",
+			script:       "aws s3 sync ./site s3://www --delete",
+			wantRule:     "aws-delete",
+			wantCommand:  "aws s3 sync --delete",
+			wantMessage:  "aws s3 sync --delete deletes the objects of s3://www missing from ./site",
+			wantSeverity: issue.SeverityWarning,
+		},
+		{
+			name:         "instances terminated",
+			script:       "aws ec2 terminate-instances --instance-ids i-1 i-2",
+			wantRule:     "aws-delete",
+			wantCommand:  "aws ec2 terminate-instances",
+			wantMessage:  "aws ec2 terminate-instances terminates i-1 and i-2, deleting their instance store and root volumes",
+			wantSeverity: issue.SeverityWarning,
+		},
+		{
+			name:         "database deleted without a snapshot",
+			script:       "aws rds delete-db-instance --db-instance-identifier orders --skip-final-snapshot",
+			wantRule:     "aws-delete",
+			wantCommand:  "aws rds delete-db-instance --skip-final-snapshot",
+			wantMessage:  "aws rds delete-db-instance --skip-final-snapshot deletes the database instance orders without a final snapshot",
+			wantSeverity: issue.SeverityError,
+		},
+		{
+			name:         "stack deleted",
+			script:       `aws cloudformation delete-stack --stack-name "$STACK"`,
+			wantRule:     "aws-delete",
+			wantCommand:  "aws cloudformation delete-stack",
+			wantMessage:  "aws cloudformation delete-stack deletes the stack $STACK and every resource it created",
+			wantSeverity: issue.SeverityWarning,
+		},
+		{
+			name:         "recursive gsutil deletion",
+			script:       "gsutil -m rm -r gs://logs/2024",
+			wantRule:     "gsutil-delete",
+			wantCommand:  "gsutil rm -r",
+			wantMessage:  "gsutil rm -r deletes every object below gs://logs/2024",
+			wantSeverity: issue.SeverityError,
+		},
+		{
+			name:         "gsutil rsync deleting the destination",
+			script:       "gsutil rsync -r -d ./build gs://www",
+			wantRule:     "gsutil-delete",
+			wantCommand:  "gsutil rsync -d",
+			wantMessage:  "gsutil rsync -d deletes the objects of gs://www missing from ./build",
+			wantSeverity: issue.SeverityWarning,
+		},
+		{
+			name:         "resource group deleted without confirmation",
+			script:       "az group delete -n staging --yes --no-wait",
+			wantRule:     "az-delete",
+			wantCommand:  "az group delete --yes",
+			wantMessage:  "az group delete --yes deletes the resource group staging and every resource in it without asking for confirmation",
+			wantSeverity: issue.SeverityError,
+		},
+		{
+			name:         "blobs deleted in bulk",
+			script:       "az storage blob delete-batch --source uploads --pattern '*.png'",
+			wantRule:     "az-delete",
+			wantCommand:  "az storage blob delete-batch",
+			wantMessage:  "az storage blob delete-batch deletes every blob in the container uploads matching *.png",
+			wantSeverity: issue.SeverityWarning,
+		},
+		{
+			name:         "terraform destroy without confirmation",
+			script:       "terraform -chdir=infra destroy -auto-approve",
+			wantRule:     "terraform-destroy",
+			wantCommand:  "terraform destroy -auto-approve",
+			wantMessage:  "terraform destroy -auto-approve deletes every resource managed by the configuration without asking for confirmation",
+			wantSeverity: issue.SeverityError,
+		},
+		{
+			name:         "terraform destroy approved with a value",
+			script:       "terraform destroy -auto-approve=true",
+			wantRule:     "terraform-destroy",
+			wantCommand:  "terraform destroy -auto-approve",
+			wantMessage:  "terraform destroy -auto-approve deletes every resource managed by the configuration without asking for confirmation",
+			wantSeverity: issue.SeverityError,
+		},
+		{
+			name:         "targeted terraform apply -destroy",
+			script:       "terraform apply -destroy -target=module.db",
+			wantRule:     "terraform-destroy",
+			wantCommand:  "terraform apply -destroy",
+			wantMessage:  "terraform apply -destroy deletes the resources module.db",
+			wantSeverity: issue.SeverityWarning,
+		},
+		{
+			name:         "terraform apply without a plan",
+			script:       "terraform apply -auto-approve -var env=prod",
+			wantRule:     "terraform-destroy",
+			wantCommand:  "terraform apply -auto-approve",
+			wantMessage:  "terraform apply -auto-approve applies changes, including deletions and replacements, without review",
+			wantSeverity: issue.SeverityWarning,
+		},
+		{
+			name:         "terraform apply approved with a value",
+			script:       "terraform apply -auto-approve=true",
+			wantRule:     "terraform-destroy",
+			wantCommand:  "terraform apply -auto-approve",
+			wantMessage:  "terraform apply -auto-approve applies changes, including deletions and replacements, without review",
+			wantSeverity: issue.SeverityWarning,
+		},
+		{
+			name:         "namespace deleted",
+			script:       "kubectl delete ns staging",
+			wantRule:     "kubectl-delete",
+			wantCommand:  "kubectl delete namespace",
+			wantMessage:  "kubectl delete namespace deletes the namespace staging and every resource in it",
+			wantSeverity: issue.SeverityError,
+		},
+		{
+			name:         "every pod of a namespace",
+			script:       "kubectl -n prod delete pods --all",
+			wantRule:     "kubectl-delete",
+			wantCommand:  "kubectl delete --all",
+			wantMessage:  "kubectl delete --all deletes all pods in the namespace prod",
+			wantSeverity: issue.SeverityError,
+		},
+		{
+			name:         "every namespace",
+			script:       "kubectl delete deployments,services -l app=web --all-namespaces",
+			wantRule:     "kubectl-delete",
+			wantCommand:  "kubectl delete -A",
+			wantMessage:  "kubectl delete -A deletes deployments,services in every namespace",
+			wantSeverity: issue.SeverityError,
+		},
+		{
+			name:         "helm release uninstalled",
+			script:       "helm uninstall postgres -n data",
+			wantRule:     "helm-uninstall",
+			wantCommand:  "helm uninstall",
+			wantMessage:  "helm uninstall deletes the release postgres and the resources it created",
+			wantSeverity: issue.SeverityWarning,
+		},
+		{
+			name:   "single s3 object",
+			script: "aws s3 rm s3://bucket/key",
+		},
+		{
+			name:   "s3 dry run",
+			script: "aws s3 rm s3://bucket --recursive --dryrun",
+		},
+		{
+			name:   "terraform apply of a saved plan",
+			script: "terraform apply -auto-approve tfplan",
+		},
+		{
+			name:   "terraform apply asking for approval",
+			script: "terraform apply -auto-approve=false",
+		},
+		{
+			name:   "terraform plan -destroy",
+			script: "terraform plan -destroy -out=tfplan",
+		},
+		{
+			name:   "single pod",
+			script: "kubectl delete pod web-0",
+		},
+		{
+			name:   "kubectl dry run",
+			script: "kubectl delete ns staging --dry-run=client",
+		},
+		{
+			name:   "helm dry run",
+			script: "helm uninstall web --dry-run",
+		},
+		{
+			name:   "listing",
+			script: "aws s3 ls s3://bucket --recursive",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issues := checkScript(t, NewRegistry(awsRule, gsutilRule, azRule, terraformRule, kubectlRule, helmRule), tt.script)
+			if len(tt.wantCommand) == 0 {
+				assert.Empty(t, issues)
+				return
+			}
+
+			require.Len(t, issues, 1)
+			assert.Equal(t, tt.wantRule, issues[0].RuleID)
+			assert.Equal(t, tt.wantCommand, issues[0].Command)
+			assert.Equal(t, tt.wantMessage, issues[0].Message)
+			assert.Equal(t, tt.wantSeverity, issues[0].Severity)
+		})
+	}
+}
@@ -0,0 +1,373 @@
+package hazardous
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/hiteshrepo/hazardous/pkg/command"
+	"github.com/hiteshrepo/hazardous/pkg/issue"
+)
+
+// containerRuntimes share the command line of docker.
+var containerRuntimes = []string{"docker", "podman", "nerdctl"}
+
+// containerGroups are the docker subcommands grouping others, e.g. container
+// in docker container run.
+var containerGroups = []string{"container", "system", "volume"}
+
+// runtimeSockets are the sockets controlling a container runtime. A container
+// that can reach one can start privileged containers on the host.
+var runtimeSockets = []string{"docker.sock", "podman.sock", "containerd.sock", "crio.sock"}
+
+// hostNamespaces are the options sharing a namespace of the host, and what the
+// container gets access to through them.
+var hostNamespaces = []struct{ option, access string }{
+	{"pid", "the processes of the host"},
+	{"network", "the network stack of the host"},
+	{"ipc", "the shared memory of the host"},
+	{"uts", "the hostname of the host"},
+	{"userns", "the users of the host"},
+}
+
+var containerEscapeRule = &commandRule{
+	id:          "container-privileged",
+	description: "container run with privileges or namespaces of the host",
+	severity:    issue.SeverityWarning,
+	label:       "docker run",
+	message:     "docker run lets the container escape to the host",
+	fix:         "drop --privileged and host namespaces, and add only the capabilities or devices the container needs",
+	commands:    containerRuntimes,
+	classify:    classifyContainerEscape,
+}
+
+var containerMountRule = &commandRule{
+	id:          "container-host-mount",
+	description: "container mounting the host filesystem or the container runtime socket",
+	severity:    issue.SeverityWarning,
+	label:       "docker run -v",
+	message:     "docker run mounts a host path into the container",
+	fix:         "mount only the directories the container needs, read-only where possible with :ro",
+	commands:    containerRuntimes,
+	classify:    classifyContainerMount,
+}
+
+var containerPruneRule = &commandRule{
+	id:          "container-prune",
+	description: "bulk deletion of images, containers and volumes with prune",
+	severity:    issue.SeverityWarning,
+	label:       "docker system prune",
+	message:     "docker system prune deletes unused images, containers and volumes",
+	fix:         "prune with --filter, e.g. until=24h or a label, and leave volumes out",
+	commands:    containerRuntimes,
+	classify:    classifyContainerPrune,
+}
+
+// containerSubcommand returns the call of the subcommand of a container
+// runtime, named after docker's so that podman and nerdctl share its specs,
+// e.g. docker-run for podman container run. It also returns the command line
+// leading to it, e.g. "podman run".
+func containerSubcommand(c *call, args *command.Parsed) (*call, string, bool) {
+	sub, ok := c.subcommand(args)
+	if !ok {
+		return nil, "", false
+	}
+
+	names := []string{strings.TrimPrefix(sub.name, c.name+"-")}
+	if slices.Contains(containerGroups, names[0]) {
+		group := *sub
+		group.name = "docker-" + names[0]
+
+		if sub, ok = group.subcommand(parseCall(&group)); !ok {
+			return nil, "", false
+		}
+
+		names = append(names, strings.TrimPrefix(sub.name, group.name+"-"))
+	}
+
+	line := c.name + " " + strings.Join(names, " ")
+	if names[0] == "container" {
+		// docker container run is docker run
+		names = names[1:]
+	}
+
+	sub.name = "docker-" + strings.Join(names, "-")
+
+	return sub, line, true
+}
+
+// containerRun returns the call of a container runtime starting a container,
+// along with its parsed arguments.
+func containerRun(c *call, args *command.Parsed) (*call, *command.Parsed, string, bool) {
+	sub, line, ok := containerSubcommand(c, args)
+	if !ok || (sub.name != "docker-run" && sub.name != "docker-create") {
+		return nil, nil, "", false
+	}
+
+	return sub, parseCall(sub), line, true
+}
+
+// classifyContainerEscape keeps containers started with --privileged, with
+// namespaces of the host or with capabilities and security profiles that let
+// them take over the host.
+func classifyContainerEscape(ctx *Context, c *call, args *command.Parsed, found *issue.Issue) bool {
+	_, runArgs, line, ok := containerRun(c, args)
+	if !ok {
+		return false
+	}
+
+	var (
+		flags  []string
+		access []string
+	)
+
+	if runArgs.Has("privileged") {
+		flags = append(flags, "--privileged")
+		access = append(access, "every capability and device of the host")
+		found.Severity = issue.SeverityError
+	}
+
+	for _, ns := range hostNamespaces {
+		if m, ok := runArgs.Lookup(ns.option); ok && m.Value == "host" {
+			flags = append(flags, fmt.Sprintf("--%s=host", ns.option))
+			access = append(access, ns.access)
+		}
+	}
+
+	for _, m := range runArgs.Options {
+		switch {
+		case m.Option.Name == "cap-add" && slices.Contains([]string{"ALL", "SYS_ADMIN", "CAP_SYS_ADMIN"}, strings.ToUpper(m.Value)):
+			flags = append(flags, "--cap-add="+m.Value)
+			access = append(access, "administrative control over the host kernel")
+		case m.Option.Name == "security-opt" && strings.HasSuffix(m.Value, "=unconfined"):
+			flags = append(flags, "--security-opt="+m.Value)
+			access = append(access, "system calls the runtime otherwise blocks")
+		}
+	}
+
+	if len(flags) == 0 {
+		return false
+	}
+
+	found.Command = line + " " + strings.Join(flags, " ")
+	found.Message = fmt.Sprintf("%s gives the container %s", found.Command, nameList(access))
+
+	return true
+}
+
+// hostMount is a host path mounted into a container.
+type hostMount struct {
+	// flag is the option as written, e.g. -v /:/host.
+	flag     string
+	source   string
+	readOnly bool
+}
+
+// parseVolume parses the argument of -v, e.g. /var/run/docker.sock:/sock:ro.
+// Named volumes, which are not host paths, are skipped.
+func parseVolume(spec string) (hostMount, bool) {
+	fields := strings.Split(spec, ":")
+	if len(fields) < 2 || !isHostPath(fields[0]) {
+		return hostMount{}, false
+	}
+
+	m := hostMount{source: fields[0]}
+	if len(fields) > 2 {
+		m.readOnly = slices.Contains(strings.Split(fields[2], ","), "ro")
+	}
+
+	return m, true
+}
+
+// parseMount parses the argument of --mount, e.g.
+// type=bind,source=/,target=/host. Only bind mounts use host paths.
+func parseMount(spec string) (hostMount, bool) {
+	var (
+		m    hostMount
+		bind bool
+	)
+
+	for _, field := range strings.Split(spec, ",") {
+		key, value, _ := strings.Cut(field, "=")
+
+		switch key {
+		case "type":
+			bind = value == "bind"
+		case "source", "src":
+			m.source = value
+		case "readonly", "ro":
+			m.readOnly = value == "" || value == "true" || value == "1"
+		}
+	}
+
+	return m, bind && isHostPath(m.source)
+}
+
+// mountSpec returns the argument of the mount option m of c with the
+// variables in it resolved the way rm targets are, e.g. "$HOME":/root as
+// ~:/root, along with the argument as written.
+func (ctx *Context) mountSpec(c *call, m command.Match) (string, string) {
+	arg, _ := literalWord(c.args[m.Index])
+	if len(m.Value) > 0 || strings.Contains(arg, "=") || m.Index+1 >= len(c.args) {
+		return m.Value, m.Value
+	}
+
+	// the argument is the next word, which has expansions
+	word := c.args[m.Index+1]
+
+	spec, ok := ctx.wordPath(word)
+	if !ok {
+		return "", ctx.wordText(word)
+	}
+
+	return spec, ctx.wordText(word)
+}
+
+// isHostPath reports whether the source of a mount is a host path rather than
+// the name of a volume.
+func isHostPath(source string) bool {
+	return strings.HasPrefix(source, "/") || strings.HasPrefix(source, ".") || strings.HasPrefix(source, "~")
+}
+
+// classify grades the mount m, and describes what the container gets access
+// to through it. It returns false for mounts of no concern.
+func (m hostMount) classify(protected []string) (issue.Severity, string, bool) {
+	if slices.Contains(runtimeSockets, path.Base(m.source)) {
+		return issue.SeverityError, fmt.Sprintf("control of the container runtime through %s, and so root on the host", m.source), true
+	}
+
+	var (
+		severity issue.Severity
+		desc     string
+	)
+
+	switch class := classifyPath(m.source, protected); class {
+	case pathRoot:
+		severity, desc = issue.SeverityError, "the entire host filesystem (/)"
+	case pathSystem, pathHome, pathProtected:
+		severity, desc = issue.SeverityWarning, class.describe(m.source)
+	default:
+		switch class := classifyFile(m.source, protected); class {
+		case fileCritical, fileSystem, fileProtected:
+			severity, desc = issue.SeverityWarning, class.describe(m.source)
+		default:
+			return 0, "", false
+		}
+	}
+
+	if m.readOnly {
+		if severity < issue.SeverityError {
+			return 0, "", false
+		}
+
+		// reading the host filesystem still exposes its keys and passwords
+		return issue.SeverityWarning, "read access to " + desc, true
+	}
+
+	return severity, "write access to " + desc, true
+}
+
+// classifyContainerMount keeps containers mounting the root of the host,
+// system directories and files, or the socket of the container runtime. The
+// most severe mount is reported.
+func classifyContainerMount(ctx *Context, c *call, args *command.Parsed, found *issue.Issue) bool {
+	run, runArgs, line, ok := containerRun(c, args)
+	if !ok {
+		return false
+	}
+
+	var (
+		worst   hostMount
+		access  string
+		matched bool
+	)
+
+	for _, opt := range runArgs.Options {
+		var (
+			m  hostMount
+			ok bool
+		)
+
+		spec, text := ctx.mountSpec(run, opt)
+
+		switch opt.Option.Name {
+		case "volume":
+			m, ok = parseVolume(spec)
+			m.flag = "-v " + text
+		case "mount":
+			m, ok = parseMount(spec)
+			m.flag = "--mount " + text
+		}
+
+		if !ok {
+			continue
+		}
+
+		severity, desc, ok := m.classify(ctx.protected)
+		if ok && (!matched || severity > found.Severity) {
+			worst, access, matched = m, desc, true
+			found.Severity = severity
+		}
+	}
+
+	if !matched {
+		return false
+	}
+
+	found.Command = line + " " + worst.flag
+	found.Message = fmt.Sprintf("%s gives the container %s", found.Command, access)
+
+	return true
+}
+
+// classifyContainerPrune keeps prune calls deleting every unused image or
+// volume. Volumes hold data, so deleting them is an error.
+func classifyContainerPrune(ctx *Context, c *call, args *command.Parsed, found *issue.Issue) bool {
+	sub, line, ok := containerSubcommand(c, args)
+	if !ok {
+		return false
+	}
+
+	pruneArgs := parseCall(sub)
+
+	switch sub.name {
+	case "docker-system-prune":
+		deleted := []string{"stopped containers"}
+
+		if pruneArgs.Has("all") {
+			line += " -a"
+			deleted = append(deleted, "every image not used by a container")
+		}
+
+		if pruneArgs.Has("volumes") {
+			line += " --volumes"
+			deleted = append(deleted, "every unused volume along with its data")
+			found.Severity = issue.SeverityError
+		}
+
+		if len(deleted) == 1 {
+			// only dangling images, stopped containers and unused networks
+			return false
+		}
+
+		found.Message = fmt.Sprintf("%s deletes %s", line, nameList(deleted))
+
+	case "docker-volume-prune":
+		what := "every unused anonymous volume along with its data"
+		if pruneArgs.Has("all") {
+			line += " -a"
+			what = "every unused volume along with its data, including named ones"
+			found.Severity = issue.SeverityError
+		}
+
+		found.Message = fmt.Sprintf("%s deletes %s", line, what)
+
+	default:
+		return false
+	}
+
+	found.Command = line
+
+	return true
+}
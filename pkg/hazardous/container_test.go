@@ -0,0 +1,169 @@
+package hazardous
+
+import (
+	"testing"
+
+	"github.com/hiteshrepo/hazardous/pkg/issue"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContainerRules(t *testing.T) {
+	tests := []struct {
+		name         string
+		script       string
+		wantRule     string
+		wantCommand  string
+		wantMessage  string
+		wantSeverity issue.Severity
+	}{
+		{
+			name:         "privileged container",
+			script:       "docker run --rm --privileged alpine sh",
+			wantRule:     "container-privileged",
+			wantCommand:  "docker run --privileged",
+			wantMessage:  "docker run --privileged gives the container every capability and device of the host",
+			wantSeverity: issue.SeverityError,
+		},
+		{
+			name:         "host namespaces",
+			script:       "podman run --pid=host --net host -it debug",
+			wantRule:     "container-privileged",
+			wantCommand:  "podman run --pid=host --network=host",
+			wantMessage:  "podman run --pid=host --network=host gives the container the processes of the host and the network stack of the host",
+			wantSeverity: issue.SeverityWarning,
+		},
+		{
+			name:         "added capability",
+			script:       "docker container create --cap-add SYS_ADMIN fuse",
+			wantRule:     "container-privileged",
+			wantCommand:  "docker container create --cap-add=SYS_ADMIN",
+			wantMessage:  "docker container create --cap-add=SYS_ADMIN gives the container administrative control over the host kernel",
+			wantSeverity: issue.SeverityWarning,
+		},
+		{
+			name:         "host filesystem mounted",
+			script:       "docker run -v /:/host alpine chroot /host",
+			wantRule:     "container-host-mount",
+			wantCommand:  "docker run -v /:/host",
+			wantMessage:  "docker run -v /:/host gives the container write access to the entire host filesystem (/)",
+			wantSeverity: issue.SeverityError,
+		},
+		{
+			name:         "docker socket mounted",
+			script:       "nerdctl run -d -v data:/data -v /var/run/docker.sock:/var/run/docker.sock:ro agent",
+			wantRule:     "container-host-mount",
+			wantCommand:  "nerdctl run -v /var/run/docker.sock:/var/run/docker.sock:ro",
+			wantMessage:  "nerdctl run -v /var/run/docker.sock:/var/run/docker.sock:ro gives the container control of the container runtime through /var/run/docker.sock, and so root on the host",
+			wantSeverity: issue.SeverityError,
+		},
+		{
+			name:         "system directory bind mount",
+			script:       "docker run --mount type=bind,source=/etc,target=/etc nginx",
+			wantRule:     "container-host-mount",
+			wantCommand:  "docker run --mount type=bind,source=/etc,target=/etc",
+			wantMessage:  "docker run --mount type=bind,source=/etc,target=/etc gives the container write access to the system directory /etc",
+			wantSeverity: issue.SeverityWarning,
+		},
+		{
+			name:         "home directory mounted",
+			script:       `docker run -v "$HOME":/root img`,
+			wantRule:     "container-host-mount",
+			wantCommand:  "docker run -v $HOME:/root",
+			wantMessage:  "docker run -v $HOME:/root gives the container write access to the home directory (~)",
+			wantSeverity: issue.SeverityWarning,
+		},
+		{
+			name:         "docker socket held in a variable",
+			script:       "SOCK=/var/run/docker.sock; docker run -v $SOCK:$SOCK img",
+			wantRule:     "container-host-mount",
+			wantCommand:  "docker run -v $SOCK:$SOCK",
+			wantMessage:  "docker run -v $SOCK:$SOCK gives the container control of the container runtime through /var/run/docker.sock, and so root on the host",
+			wantSeverity: issue.SeverityError,
+		},
+		{
+			name:         "bind mount source held in a variable",
+			script:       "SRC=/etc; docker run --mount type=bind,source=$SRC,target=/etc nginx",
+			wantRule:     "container-host-mount",
+			wantCommand:  "docker run --mount type=bind,source=$SRC,target=/etc",
+			wantMessage:  "docker run --mount type=bind,source=$SRC,target=/etc gives the container write access to the system directory /etc",
+			wantSeverity: issue.SeverityWarning,
+		},
+		{
+			name:         "host filesystem mounted read-only",
+			script:       "docker run -v /:/rootfs:ro cadvisor",
+			wantRule:     "container-host-mount",
+			wantCommand:  "docker run -v /:/rootfs:ro",
+			wantMessage:  "docker run -v /:/rootfs:ro gives the container read access to the entire host filesystem (/)",
+			wantSeverity: issue.SeverityWarning,
+		},
+		{
+			name:         "system prune with volumes",
+			script:       "docker system prune -af --volumes",
+			wantRule:     "container-prune",
+			wantCommand:  "docker system prune -a --volumes",
+			wantMessage:  "docker system prune -a --volumes deletes stopped containers, every image not used by a container and every unused volume along with its data",
+			wantSeverity: issue.SeverityError,
+		},
+		{
+			name:         "every image",
+			script:       "podman system prune --all --force",
+			wantRule:     "container-prune",
+			wantCommand:  "podman system prune -a",
+			wantMessage:  "podman system prune -a deletes stopped containers and every image not used by a container",
+			wantSeverity: issue.SeverityWarning,
+		},
+		{
+			name:         "volume prune",
+			script:       "docker volume prune -f",
+			wantRule:     "container-prune",
+			wantCommand:  "docker volume prune",
+			wantMessage:  "docker volume prune deletes every unused anonymous volume along with its data",
+			wantSeverity: issue.SeverityWarning,
+		},
+		{
+			name:   "plain run",
+			script: "docker run --rm -v \"$PWD\":/src -w /src golang go test ./...",
+		},
+		{
+			name:   "project directory mounted",
+			script: "docker run -v ./data:/data -p 8080:80 nginx",
+		},
+		{
+			name:   "system directory mounted read-only",
+			script: "docker run -v /etc/localtime:/etc/localtime:ro app",
+		},
+		{
+			name:   "mount only known at run time",
+			script: `docker run -v "$DATA":/data app`,
+		},
+		{
+			name:   "named volume",
+			script: "docker run -v pgdata:/var/lib/postgresql/data postgres",
+		},
+		{
+			name:   "options of the containerized command",
+			script: "docker run alpine ls --privileged",
+		},
+		{
+			name:   "plain system prune",
+			script: "docker system prune -f",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issues := checkScript(t, NewRegistry(containerEscapeRule, containerMountRule, containerPruneRule), tt.script)
+			if len(tt.wantCommand) == 0 {
+				assert.Empty(t, issues)
+				return
+			}
+
+			require.Len(t, issues, 1)
+			assert.Equal(t, tt.wantRule, issues[0].RuleID)
+			assert.Equal(t, tt.wantCommand, issues[0].Command)
+			assert.Equal(t, tt.wantMessage, issues[0].Message)
+			assert.Equal(t, tt.wantSeverity, issues[0].Severity)
+		})
+	}
+}
@@ -0,0 +1,704 @@
+package hazardous
+
+import (
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/hiteshrepo/hazardous/pkg/issue"
+	"mvdan.cc/sh/syntax"
+)
+
+// maxPayload bounds the size of a decoded payload.
+const maxPayload = 1 << 20
+
+// decodeFunc replays a decoding step on constant data.
+type decodeFunc func(data []byte) ([]byte, error)
+
+// payload is data decoded at run time and run as code, such as the output of
+// `echo ... | base64 -d` piped into bash.
+type payload struct {
+	// steps name the decoding steps in order, e.g. "base64 -d".
+	steps []string
+	// start is the index of the pipeline stage the data comes from, -1 when
+	// it is read from the standard input of the script.
+	start int
+	// node is where the encoded data is written, or the command producing it.
+	node syntax.Node
+	// code is the decoded code, set when the data is constant and every step
+	// could be replayed.
+	code    string
+	decoded bool
+}
+
+// command returns the last decoding command, e.g. "base64 -d" or "printf".
+func (p *payload) command() string {
+	return strings.TrimSuffix(p.steps[len(p.steps)-1], " escapes")
+}
+
+var decodeExecRule = &decodeExecutionRule{}
+
+// decodeExecutionRule flags encoded payloads decoded at run time and run by
+// an interpreter, which hides the commands from review. Payloads written as
+// constants are decoded and checked by the other rules as embedded code.
+type decodeExecutionRule struct{}
+
+func (r *decodeExecutionRule) ID() string { return "decode-exec" }
+
+func (r *decodeExecutionRule) Description() string {
+	return "encoded payload decoded and run by an interpreter"
+}
+
+func (r *decodeExecutionRule) Severity() issue.Severity { return issue.SeverityWarning }
+
+func (r *decodeExecutionRule) Check(ctx *Context, node syntax.Node) []issue.Issue {
+	switch n := node.(type) {
+	case *syntax.BinaryCmd:
+		p, c, ok := ctx.pipePayload(n)
+		if !ok {
+			return nil
+		}
+
+		found := r.report(ctx, n, p, c)
+		found.Command = fmt.Sprintf("%s | %s", p.command(), found.Command)
+
+		return []issue.Issue{found}
+
+	case *syntax.CallExpr:
+		var issues []issue.Issue
+
+		for _, c := range ctx.calls(n) {
+			p, _, label, ok := ctx.substPayload(c)
+			if !ok {
+				continue
+			}
+
+			found := r.report(ctx, n, p, c)
+			found.Command = fmt.Sprintf(label, found.Command, p.command())
+			issues = append(issues, found)
+		}
+
+		return issues
+	}
+
+	return nil
+}
+
+// report builds the finding for payload p run by c.
+func (r *decodeExecutionRule) report(ctx *Context, node syntax.Node, p *payload, c *call) issue.Issue {
+	name := c.name
+	if len(c.privileged) > 0 {
+		name = c.privileged + " " + name
+	}
+
+	msg := fmt.Sprintf("%s runs a payload decoded with %s", c.name, strings.Join(p.steps, " | "))
+
+	found := ctx.newIssue(r, node, name, msg)
+	found.Fix = "run the decoded commands directly so that they can be reviewed"
+
+	if p.decoded {
+		found.Message += fmt.Sprintf(", which decodes to %q", summary(p.code))
+	} else {
+		found.Severity = issue.SeverityError
+		found.Message += ", which cannot be inspected before it runs"
+	}
+
+	c.elevate(&found)
+	found.Command = name
+
+	return found
+}
+
+// pipePayload returns the payload that the pipeline cmd decodes and feeds to
+// an interpreter. As a | b | c nests as a | (b | c), the payload is reported
+// on the pipe following the command it comes from, or on the whole pipeline
+// when it is read from the standard input of the script.
+func (ctx *Context) pipePayload(cmd *syntax.BinaryCmd) (*payload, *call, bool) {
+	if cmd.Op != syntax.Pipe && cmd.Op != syntax.PipeAll {
+		return nil, nil, false
+	}
+
+	left := pipeStages(cmd.X)
+	stages := append(left, pipeStages(cmd.Y)...)
+
+	for k := len(left); k < len(stages); k++ {
+		c := ctx.scriptReader(stages[k])
+		if c == nil {
+			continue
+		}
+
+		p, ok := ctx.payloadOf(stages[:k])
+		if !ok {
+			return nil, nil, false
+		}
+
+		// data read from standard input is reported on the outermost pipe,
+		// as a nested one reads it from the stage before
+		if p.start < 0 && ctx.vars.isPiped(cmd) || p.start >= 0 && p.start != len(left)-1 {
+			return nil, nil, false
+		}
+
+		return p, c, true
+	}
+
+	return nil, nil, false
+}
+
+// substPayload returns the payload c runs from a command substitution, as in
+// `eval "$(echo ... | base64 -d)"`, or a process substitution, as in
+// `bash <(base64 -d <<< ...)`, along with where it runs and the format of the
+// label of a finding.
+func (ctx *Context) substPayload(c *call) (*payload, runKind, string, bool) {
+	var (
+		word  *syntax.Word
+		runs  runKind
+		label string
+		// proc is set when c runs the file given as word, which only a
+		// process substitution makes hold the payload
+		proc bool
+	)
+
+	switch args := parseCall(c); {
+	case c.name == "eval":
+		if len(c.args) == 1 {
+			word, runs, label = c.args[0], runsInline, `%s "$(%s)"`
+		}
+	case len(args.Operands) == 0:
+	case shells[c.name] && args.Has("command"):
+		word, runs, label = c.args[args.Operands[0]], runsInSubshell, `%s -c "$(%s)"`
+	case shells[c.name]:
+		word, runs, label, proc = c.args[args.Operands[0]], runsInSubshell, "%s <(%s)", true
+	case c.name == "source" || c.name == ".":
+		word, runs, label, proc = c.args[args.Operands[0]], runsInline, "%s <(%s)", true
+	}
+
+	if word == nil {
+		return nil, 0, "", false
+	}
+
+	parts := flattenParts(word.Parts)
+	if len(parts) != 1 {
+		return nil, 0, "", false
+	}
+
+	var stmts []*syntax.Stmt
+
+	switch p := parts[0].(type) {
+	case *syntax.CmdSubst:
+		if !proc {
+			stmts = p.Stmts
+		}
+	case *syntax.ProcSubst:
+		if proc && p.Op == syntax.CmdIn {
+			stmts = p.Stmts
+		}
+	}
+
+	if len(stmts) != 1 {
+		return nil, 0, "", false
+	}
+
+	p, ok := ctx.payloadOf(pipeStages(stmts[0]))
+	if !ok {
+		return nil, 0, "", false
+	}
+
+	return p, runs, label, true
+}
+
+// payloadOf returns the payload written by a pipeline: decoding commands at
+// its end, fed by the command before them or by their own input, or a
+// printf or echo -e writing escape sequences.
+func (ctx *Context) payloadOf(stages []*syntax.Stmt) (*payload, bool) {
+	var (
+		p     = &payload{}
+		funcs []decodeFunc
+		i     = len(stages)
+	)
+
+	for ; i > 0; i-- {
+		c := ctx.lastCall(stages[i-1])
+		if c == nil {
+			break
+		}
+
+		step, fn, ok := decoder(c)
+		if !ok {
+			break
+		}
+
+		p.steps = append([]string{step}, p.steps...)
+		funcs = append([]decodeFunc{fn}, funcs...)
+	}
+
+	var (
+		data     string
+		constant bool
+	)
+
+	if i > 0 {
+		var step string
+		p.start = i - 1
+		data, p.node, constant, step = ctx.output(stages[i-1])
+
+		if len(step) > 0 {
+			p.steps = append([]string{step}, p.steps...)
+		}
+	} else if len(p.steps) > 0 {
+		var ok bool
+		if data, p.node, constant, ok = ctx.input(stages[0]); !ok {
+			// the data is read from the standard input of the script
+			p.start, p.node = -1, stages[0]
+		}
+	}
+
+	if len(p.steps) == 0 {
+		return nil, false
+	}
+
+	if !constant {
+		return p, true
+	}
+
+	out := []byte(data)
+	for _, fn := range funcs {
+		if fn == nil {
+			return p, true
+		}
+
+		var err error
+		if out, err = fn(out); err != nil || len(out) > maxPayload {
+			return p, true
+		}
+	}
+
+	if utf8.Valid(out) && !bytes.ContainsRune(out, 0) {
+		p.code, p.decoded = string(out), true
+	}
+
+	return p, true
+}
+
+// decoder returns the decoding step run by c and how to replay it, nil when
+// it cannot be replayed. openssl and xxd take single-dash long options, which
+// are matched as written.
+func decoder(c *call) (string, decodeFunc, bool) {
+	switch c.name {
+	case "base64":
+		return "base64 -d", decodeBase64, parseCall(c).Has("decode")
+	case "base32":
+		return "base32 -d", decodeBase32, parseCall(c).Has("decode")
+	case "gunzip", "zcat":
+		return "gunzip", decodeGzip, true
+	case "gzip":
+		return "gunzip", decodeGzip, parseCall(c).Has("decompress")
+	case "bunzip2", "bzcat":
+		return "bunzip2", decodeBzip2, true
+	case "bzip2":
+		return "bunzip2", decodeBzip2, parseCall(c).Has("decompress")
+	case "rev":
+		return "rev", decodeRev, len(c.args) == 0
+	}
+
+	args := make(map[string]bool, len(c.args))
+	for _, word := range c.args {
+		if value, ok := literalWord(word); ok {
+			args[value] = true
+		}
+	}
+
+	switch c.name {
+	case "xxd":
+		switch {
+		case !args["-r"] && !args["-revert"] && !args["-rp"] && !args["-pr"]:
+			return "", nil, false
+		case args["-p"] || args["-ps"] || args["-plain"] || args["-postscript"] || args["-rp"] || args["-pr"]:
+			return "xxd -r -p", decodeHex, true
+		default:
+			return "xxd -r", nil, true
+		}
+	case "openssl":
+		if args["-d"] && (args["base64"] || args["enc"] && (args["-base64"] || args["-a"])) {
+			return "openssl base64 -d", decodeBase64, true
+		}
+	}
+
+	return "", nil, false
+}
+
+// output returns what st writes to its standard output when it is made of
+// constants, including variables holding them: the arguments of echo or
+// printf, or the input of cat. step names
+// the decoding when escape sequences are expanded, as printf does.
+func (ctx *Context) output(st *syntax.Stmt) (data string, node syntax.Node, constant bool, step string) {
+	c := ctx.lastCall(st)
+	if c == nil {
+		return "", st, false, ""
+	}
+
+	values := make([]string, len(c.args))
+	constant = true
+
+	for i, word := range c.args {
+		var ok bool
+		if values[i], ok = ctx.constantWord(word); !ok {
+			constant = false
+		}
+	}
+
+	if len(c.args) > 0 {
+		node = c.args[len(c.args)-1]
+	} else {
+		node = st
+	}
+
+	switch c.name {
+	case "echo":
+		i, newline, escapes := 0, true, false
+		for ; i < len(values) && isEchoOption(values[i]); i++ {
+			newline = newline && !strings.Contains(values[i], "n")
+			escapes = strings.Contains(values[i], "e") || escapes && !strings.Contains(values[i], "E")
+		}
+
+		data = strings.Join(values[i:], " ")
+		if escapes {
+			var encoded bool
+			if data, encoded = expandEscapes(data, false); encoded {
+				step = "echo -e escapes"
+			}
+		}
+
+		if newline {
+			data += "\n"
+		}
+
+		return data, node, constant, step
+
+	case "printf":
+		if len(values) > 0 && values[0] == "--" {
+			values = values[1:]
+		}
+
+		if len(values) == 0 || strings.HasPrefix(values[0], "-") {
+			return "", node, false, ""
+		}
+
+		data, encoded, ok := printfOutput(values[0], values[1:])
+		if encoded {
+			step = "printf escapes"
+		}
+
+		return data, node, constant && ok, step
+
+	case "cat":
+		if len(parseCall(c).Operands) == 0 {
+			data, node, constant, _ = ctx.input(st)
+			return data, node, constant, ""
+		}
+	}
+
+	return "", node, false, ""
+}
+
+// input returns the standard input of st when it comes from a here-document,
+// a here-string, a file or an operand of the command, and whether it is a
+// constant. An empty here-document is placed at its delimiter.
+func (ctx *Context) input(st *syntax.Stmt) (data string, node syntax.Node, constant, ok bool) {
+	for _, r := range st.Redirs {
+		switch r.Op {
+		case syntax.WordHdoc:
+			value, ok := literalWord(r.Word)
+			return value + "\n", r.Word, ok, true
+		case syntax.Hdoc, syntax.DashHdoc:
+			value, ok := heredocText(r)
+			if r.Hdoc == nil {
+				return value, r.Word, ok, true
+			}
+
+			return value, r.Hdoc, ok, true
+		case syntax.RdrIn:
+			return "", r.Word, false, true
+		}
+	}
+
+	if c := ctx.lastCall(st); c != nil && len(parseCall(c).Operands) > 0 {
+		return "", st, false, true
+	}
+
+	return "", nil, false, false
+}
+
+// heredocText returns the body of a here-document without expansions.
+func heredocText(r *syntax.Redirect) (string, bool) {
+	if r.Hdoc == nil {
+		return "", true
+	}
+
+	quoted := quotedDelimiter(r.Word)
+
+	var sb strings.Builder
+
+	for _, part := range r.Hdoc.Parts {
+		lit, ok := part.(*syntax.Lit)
+		if !ok {
+			return "", false
+		}
+
+		if quoted {
+			sb.WriteString(lit.Value)
+		} else {
+			sb.WriteString(unescape(lit.Value, "$`\\\n"))
+		}
+	}
+
+	text := sb.String()
+	if r.Op == syntax.DashHdoc {
+		lines := strings.Split(text, "\n")
+		for i, line := range lines {
+			lines[i] = strings.TrimLeft(line, "\t")
+		}
+		text = strings.Join(lines, "\n")
+	}
+
+	return text, true
+}
+
+// isEchoOption reports whether arg is an option of echo, e.g. -ne.
+func isEchoOption(arg string) bool {
+	return len(arg) > 1 && arg[0] == '-' && strings.Trim(arg[1:], "neE") == ""
+}
+
+// printfOutput returns what printf prints for a format and its arguments,
+// supporting the %s, %b and %% conversions, and whether it expanded hex or
+// octal escape sequences.
+func printfOutput(format string, args []string) (string, bool, bool) {
+	var (
+		sb      strings.Builder
+		encoded bool
+	)
+
+	for {
+		used := 0
+
+		for i := 0; i < len(format); i++ {
+			switch {
+			case format[i] == '\\':
+				text, n, enc := expandEscape(format[i:], true)
+				sb.WriteString(text)
+				encoded = encoded || enc
+				i += n - 1
+
+			case format[i] == '%' && i+1 < len(format):
+				i++
+
+				switch format[i] {
+				case '%':
+					sb.WriteByte('%')
+				case 's', 'b':
+					var arg string
+					if used < len(args) {
+						arg = args[used]
+					}
+					used++
+
+					if format[i] == 'b' {
+						var enc bool
+						arg, enc = expandEscapes(arg, false)
+						encoded = encoded || enc
+					}
+					sb.WriteString(arg)
+				default:
+					return "", encoded, false
+				}
+
+			default:
+				sb.WriteByte(format[i])
+			}
+		}
+
+		if used == 0 || used >= len(args) {
+			break
+		}
+
+		args = args[used:]
+	}
+
+	return sb.String(), encoded, true
+}
+
+// expandEscapes expands the backslash escape sequences of s, as echo -e and
+// printf %b do, and reports whether it held hex or octal escapes.
+func expandEscapes(s string, format bool) (string, bool) {
+	var (
+		sb      strings.Builder
+		encoded bool
+	)
+
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' {
+			sb.WriteByte(s[i])
+			continue
+		}
+
+		text, n, enc := expandEscape(s[i:], format)
+		sb.WriteString(text)
+		encoded = encoded || enc
+		i += n - 1
+	}
+
+	return sb.String(), encoded
+}
+
+// expandEscape expands the escape sequence at the start of s and returns the
+// number of bytes it spans. Octal escapes are \NNN in a printf format and
+// \0NNN elsewhere.
+func expandEscape(s string, format bool) (string, int, bool) {
+	if len(s) < 2 {
+		return s, len(s), false
+	}
+
+	if r := strings.IndexByte(`abefnrtv\`, s[1]); r >= 0 {
+		return string("\a\b\x1b\f\n\r\t\v\\"[r]), 2, false
+	}
+
+	digits := func(start, max int, valid string, base int) (string, int, bool) {
+		end := start
+		for end < len(s) && end-start < max && strings.IndexByte(valid, s[end]) >= 0 {
+			end++
+		}
+
+		if end == start {
+			return s[:2], 2, false
+		}
+
+		value, _ := strconv.ParseUint(s[start:end], base, 16)
+
+		return string([]byte{byte(value)}), end, true
+	}
+
+	switch {
+	case s[1] == 'x':
+		return digits(2, 2, "0123456789abcdefABCDEF", 16)
+	case s[1] == '0' && !format:
+		return digits(2, 3, "01234567", 8)
+	case s[1] >= '0' && s[1] <= '7' && format:
+		return digits(1, 3, "01234567", 8)
+	}
+
+	return s[:2], 2, false
+}
+
+// summary returns the first line of decoded code, shortened for a message.
+func summary(code string) string {
+	for _, line := range strings.Split(code, "\n") {
+		if line = strings.TrimSpace(line); len(line) == 0 {
+			continue
+		}
+
+		if r := []rune(line); len(r) > 60 {
+			return string(r[:57]) + "..."
+		}
+
+		return line
+	}
+
+	return ""
+}
+
+func decodeBase64(data []byte) ([]byte, error) {
+	s := strings.Join(strings.Fields(string(data)), "")
+	if out, err := base64.StdEncoding.DecodeString(s); err == nil {
+		return out, nil
+	}
+
+	return base64.RawStdEncoding.DecodeString(strings.TrimRight(s, "="))
+}
+
+func decodeBase32(data []byte) ([]byte, error) {
+	return base32.StdEncoding.DecodeString(strings.Join(strings.Fields(string(data)), ""))
+}
+
+func decodeHex(data []byte) ([]byte, error) {
+	return hex.DecodeString(strings.Join(strings.Fields(string(data)), ""))
+}
+
+func decodeGzip(data []byte) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	return io.ReadAll(io.LimitReader(r, maxPayload+1))
+}
+
+func decodeBzip2(data []byte) ([]byte, error) {
+	return io.ReadAll(io.LimitReader(bzip2.NewReader(bytes.NewReader(data)), maxPayload+1))
+}
+
+// decodeRev reverses every line, as rev does.
+func decodeRev(data []byte) ([]byte, error) {
+	lines := strings.Split(string(data), "\n")
+
+	for i, line := range lines {
+		r := []rune(line)
+		for a, b := 0, len(r)-1; a < b; a, b = a+1, b-1 {
+			r[a], r[b] = r[b], r[a]
+		}
+		lines[i] = string(r)
+	}
+
+	return []byte(strings.Join(lines, "\n")), nil
+}
+
+// decodedScripts parses the constant payloads decoded and run as shell code
+// by cmd, given as a command or process substitution.
+func (ctx *Context) decodedScripts(cmd *syntax.CallExpr) []*script {
+	var scripts []*script
+
+	for _, c := range ctx.calls(cmd) {
+		p, runs, _, ok := ctx.substPayload(c)
+		if !ok || !p.decoded {
+			continue
+		}
+
+		if sc, ok := ctx.decodedScript(p, runs, c); ok {
+			scripts = append(scripts, sc)
+		}
+	}
+
+	return scripts
+}
+
+// decodedPipeScript parses the constant payload decoded and piped into a
+// shell by cmd.
+func (ctx *Context) decodedPipeScript(cmd *syntax.BinaryCmd) (*script, bool) {
+	p, c, ok := ctx.pipePayload(cmd)
+	if !ok || !p.decoded {
+		return nil, false
+	}
+
+	runs, ok := readsShell(c)
+	if !ok {
+		return nil, false
+	}
+
+	return ctx.decodedScript(p, runs, c)
+}
+
+// decodedScript parses a decoded payload run by via. Positions in it map to
+// the encoded data. Payloads decoding to the code that decodes them are
+// bounded like other embedded code, see script.nests.
+func (ctx *Context) decodedScript(p *payload, runs runKind, via *call) (*script, bool) {
+	b := ctx.newCodeBuilder()
+	b.opaque(p.node.Pos(), p.code)
+
+	return b.parse(runs, via)
+}
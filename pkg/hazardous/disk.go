@@ -0,0 +1,388 @@
+package hazardous
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/hiteshrepo/hazardous/pkg/command"
+	"github.com/hiteshrepo/hazardous/pkg/issue"
+	"mvdan.cc/sh/syntax"
+)
+
+// formatCommands create a filesystem or swap area, destroying what the device
+// held before.
+var formatCommands = []string{
+	"mkfs", "mke2fs", "mkfs.ext2", "mkfs.ext3", "mkfs.ext4", "mkfs.xfs", "mkfs.btrfs",
+	"mkfs.vfat", "mkfs.fat", "mkfs.msdos", "mkdosfs", "mkfs.exfat", "mkfs.ntfs", "mkntfs",
+	"mkfs.f2fs", "mkswap",
+}
+
+// partedCommands are the parted commands that change the partition table.
+var partedCommands = []string{
+	"mklabel", "mktable", "mkpart", "mkpartfs", "rm", "resize", "resizepart", "rescue",
+	"move", "cp", "name", "set", "toggle", "disk_set", "disk_toggle",
+}
+
+// diskFix is the fix suggested for writes to a disk.
+const diskFix = "check the target with lsblk first and name the disk by a stable path such as /dev/disk/by-id/..."
+
+var ddRule = &commandRule{
+	id:          "dd-device",
+	description: "dd writing to a disk or other block device",
+	severity:    issue.SeverityWarning,
+	label:       "dd",
+	message:     "dd overwrites a device",
+	fix:         diskFix,
+	commands:    []string{"dd"},
+	classify:    classifyDd,
+}
+
+var formatRule = &commandRule{
+	id:          "disk-format",
+	description: "filesystem creation or signature wiping on a disk",
+	severity:    issue.SeverityError,
+	label:       "mkfs",
+	message:     "mkfs formats a device, destroying the data on it",
+	fix:         diskFix,
+	commands:    append([]string{"wipefs"}, formatCommands...),
+	classify:    classifyFormat,
+}
+
+var partitionRule = &commandRule{
+	id:          "disk-partition",
+	description: "partition table changes with fdisk, sfdisk or parted",
+	severity:    issue.SeverityError,
+	label:       "fdisk",
+	message:     "fdisk rewrites the partition table of a disk",
+	fix:         diskFix,
+	commands:    []string{"fdisk", "sfdisk", "parted"},
+	classify:    classifyPartition,
+}
+
+var shredRule = &commandRule{
+	id:          "shred",
+	description: "files or disks overwritten with shred",
+	severity:    issue.SeverityWarning,
+	label:       "shred",
+	message:     "shred overwrites files so that they cannot be recovered",
+	fix:         "delete files with rm, and check the target with lsblk before shredding a disk",
+	commands:    []string{"shred"},
+	classify:    classifyShred,
+}
+
+// deviceClass tells what a path written to by a disk tool refers to, from
+// least to most severe.
+type deviceClass int
+
+const (
+	// deviceNone is a regular file, e.g. a disk image, or a character device
+	// such as /dev/null.
+	deviceNone deviceClass = iota
+	// deviceOther is a device that is not a known disk, e.g. a loop device.
+	deviceOther
+	// deviceUnknown is a path only known at run time.
+	deviceUnknown
+	// deviceVariable is a path below /dev only known at run time, e.g.
+	// /dev/$DISK.
+	deviceVariable
+	// deviceDisk is a disk or a partition.
+	deviceDisk
+)
+
+// diskPrefixes are the names of disks and partitions below /dev on Linux, the
+// BSDs and macOS.
+var diskPrefixes = []string{
+	"sd", "hd", "vd", "xvd", "nvme", "mmcblk", "md", "dm-", "disk", "rdisk", "ada", "da",
+	"mapper/", "root",
+}
+
+// charDevices are the devices below /dev that do not store anything.
+var charDevices = []string{
+	"null", "zero", "full", "random", "urandom", "stdin", "stdout", "stderr", "tty",
+	"fd/", "shm/", "pts/",
+}
+
+// classifyDevice returns what the path p below /dev refers to.
+func classifyDevice(p string) deviceClass {
+	name, ok := strings.CutPrefix(path.Clean(p), "/dev/")
+	if !ok {
+		return deviceNone
+	}
+
+	for _, prefix := range charDevices {
+		if strings.HasPrefix(name, prefix) {
+			return deviceNone
+		}
+	}
+
+	for _, prefix := range diskPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return deviceDisk
+		}
+	}
+
+	return deviceOther
+}
+
+// severity returns the severity of overwriting a device of class c.
+func (c deviceClass) severity() issue.Severity {
+	switch c {
+	case deviceDisk, deviceVariable:
+		return issue.SeverityError
+	case deviceOther, deviceUnknown:
+		return issue.SeverityWarning
+	default:
+		return issue.SeverityInfo
+	}
+}
+
+// describe names a device of class c written as arg, e.g. "the disk
+// /dev/sda".
+func (c deviceClass) describe(arg string) string {
+	switch c {
+	case deviceDisk:
+		return "the disk " + arg
+	case deviceOther:
+		return "the device " + arg
+	case deviceVariable:
+		return arg + ", a device only known at run time"
+	case deviceUnknown:
+		return arg + ", which may be a disk"
+	default:
+		return arg
+	}
+}
+
+// wordDevice classifies the path in word and returns it as written, along
+// with the constant it resolves to when they differ, e.g. "/dev/sda ($DISK)".
+func (ctx *Context) wordDevice(word *syntax.Word) (deviceClass, string) {
+	text := ctx.wordText(word)
+
+	p, ok := ctx.wordPath(word)
+	switch {
+	case ok && p != text:
+		return classifyDevice(p), fmt.Sprintf("%s (%s)", p, text)
+	case ok:
+		return classifyDevice(p), text
+	}
+
+	prefix := literalPrefix(word)
+	switch {
+	case len(prefix) == 0:
+		return deviceUnknown, text
+	case strings.HasPrefix(prefix, "/dev/"):
+		return deviceVariable, text
+	default:
+		return deviceNone, text
+	}
+}
+
+// classifyDevices returns the most severe class of the words along with its
+// description, and deviceUnknown when the wrapper appends operands at run
+// time.
+func (ctx *Context) classifyDevices(c *call, words []*syntax.Word) (deviceClass, string) {
+	worst, desc := deviceNone, ""
+	if c.input {
+		worst, desc = deviceUnknown, "paths read at run time, which may be disks"
+	}
+
+	for _, word := range words {
+		class, text := ctx.wordDevice(word)
+		if c.isPlaceholder(word) {
+			class = deviceUnknown
+		}
+
+		if class > worst || len(desc) == 0 {
+			worst, desc = class, class.describe(text)
+		}
+	}
+
+	return worst, desc
+}
+
+// literalPrefix returns the literal text a word starts with, e.g. /dev/ for
+// /dev/$DISK.
+func literalPrefix(word *syntax.Word) string {
+	var sb strings.Builder
+
+	for _, part := range flattenParts(word.Parts) {
+		switch p := part.(type) {
+		case *syntax.Lit:
+			sb.WriteString(p.Value)
+		case *syntax.SglQuoted:
+			sb.WriteString(p.Value)
+		default:
+			return sb.String()
+		}
+	}
+
+	return sb.String()
+}
+
+// toolSeverity returns the severity of formatting or partitioning a device of
+// class c. These tools are meant for disks, so a path only known at run time
+// is most likely one.
+func toolSeverity(c deviceClass) issue.Severity {
+	if c == deviceUnknown {
+		return issue.SeverityError
+	}
+
+	return c.severity()
+}
+
+// ddOperands splits the key=value operands of dd, e.g. of=/dev/sda, into the
+// key and a word holding the value.
+func ddOperands(c *call, args *command.Parsed) map[string]*syntax.Word {
+	operands := map[string]*syntax.Word{}
+
+	for _, word := range operandWords(c, args) {
+		lit, ok := word.Parts[0].(*syntax.Lit)
+		if !ok {
+			continue
+		}
+
+		key, value, ok := strings.Cut(lit.Value, "=")
+		if !ok {
+			continue
+		}
+
+		parts := word.Parts[1:]
+		if len(value) > 0 {
+			parts = append([]syntax.WordPart{
+				&syntax.Lit{ValuePos: lit.ValuePos, ValueEnd: lit.ValueEnd, Value: value},
+			}, parts...)
+		}
+
+		operands[key] = &syntax.Word{Parts: parts}
+	}
+
+	return operands
+}
+
+// classifyDd keeps dd calls writing to a device, or to a path only known at
+// run time.
+func classifyDd(ctx *Context, c *call, args *command.Parsed, found *issue.Issue) bool {
+	of, ok := ddOperands(c, args)["of"]
+	if !ok || len(of.Parts) == 0 {
+		return false
+	}
+
+	class, text := ctx.wordDevice(of)
+	if class == deviceNone {
+		return false
+	}
+
+	found.Command = "dd of=" + ctx.wordText(of)
+	found.Message = fmt.Sprintf("dd overwrites %s", class.describe(text))
+	found.Severity = class.severity()
+
+	return true
+}
+
+// classifyFormat keeps mkfs and wipefs calls on a device. Formatting a path
+// only known at run time is an error, since these tools are meant for disks.
+func classifyFormat(ctx *Context, c *call, args *command.Parsed, found *issue.Issue) bool {
+	verb := "formats"
+
+	switch {
+	case args.Has("no-act"):
+		return false
+	case c.name == "wipefs":
+		if !args.Has("all") && !args.Has("offset") {
+			// wipefs only lists the signatures it finds
+			return false
+		}
+
+		found.Command = "wipefs -a"
+		if !args.Has("all") {
+			found.Command = "wipefs -o"
+		}
+
+		verb = "erases the filesystem signatures of"
+	default:
+		found.Command = c.name
+	}
+
+	class, desc := ctx.classifyDevices(c, operandWords(c, args))
+	if class == deviceNone {
+		return false
+	}
+
+	found.Message = fmt.Sprintf("%s %s %s", found.Command, verb, desc)
+	found.Severity = toolSeverity(class)
+
+	return true
+}
+
+// classifyPartition keeps fdisk, sfdisk and parted calls that may change the
+// partition table of a disk. Without commands, parted reads them from its
+// input, as fdisk and sfdisk do.
+func classifyPartition(ctx *Context, c *call, args *command.Parsed, found *issue.Issue) bool {
+	if args.Has("list") || args.Has("list-details") || args.Has("dump") || args.Has("json") ||
+		args.Has("show-size") || args.Has("list-free") || args.Has("verify") || args.Has("no-act") {
+		return false
+	}
+
+	words := operandWords(c, args)
+	found.Command = c.name
+
+	if c.name == "parted" && len(words) > 1 {
+		// parted takes the disk first and the commands to run on it after
+		i := slices.IndexFunc(words[1:], func(word *syntax.Word) bool {
+			value, _ := literalWord(word)
+			return slices.Contains(partedCommands, value)
+		})
+		if i < 0 {
+			return false
+		}
+
+		name, _ := literalWord(words[i+1])
+		found.Command += " " + name
+		words = words[:1]
+	}
+
+	class, desc := ctx.classifyDevices(c, words)
+	if class == deviceNone {
+		return false
+	}
+
+	found.Message = fmt.Sprintf("%s rewrites the partition table of %s", found.Command, desc)
+	found.Severity = toolSeverity(class)
+
+	return true
+}
+
+// classifyShred grades shred calls by what they overwrite: disks as dd does,
+// files by their blast radius as rm does.
+func classifyShred(ctx *Context, c *call, args *command.Parsed, found *issue.Issue) bool {
+	verb := "overwrites"
+	if args.Has("remove") {
+		found.Command = "shred -u"
+		verb = "overwrites and deletes"
+	}
+
+	switch class, desc := ctx.classifyDevices(c, operandWords(c, args)); class {
+	case deviceDisk, deviceVariable, deviceOther:
+		found.Message = fmt.Sprintf("%s %s %s", found.Command, verb, desc)
+		found.Severity = class.severity()
+
+		return true
+	}
+
+	pclass, target := ctx.classifyTargets(c, args)
+	if target == nil {
+		return true
+	}
+
+	text := ctx.wordText(target)
+	if desc := pclass.describe(text); len(desc) > 0 {
+		text = desc
+	}
+
+	found.Message = fmt.Sprintf("%s %s %s", found.Command, verb, text)
+	found.Severity = pclass.severity()
+
+	return true
+}
@@ -0,0 +1,199 @@
+package hazardous
+
+import (
+	"testing"
+
+	"github.com/hiteshrepo/hazardous/pkg/issue"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiskRules(t *testing.T) {
+	tests := []struct {
+		name         string
+		script       string
+		wantRule     string
+		wantCommand  string
+		wantMessage  string
+		wantSeverity issue.Severity
+	}{
+		{
+			name:         "dd to a disk",
+			script:       "dd if=ubuntu.iso of=/dev/sdb bs=4M status=progress",
+			wantRule:     "dd-device",
+			wantCommand:  "dd of=/dev/sdb",
+			wantMessage:  "dd overwrites the disk /dev/sdb",
+			wantSeverity: issue.SeverityError,
+		},
+		{
+			name:         "dd to a disk from a constant",
+			script:       "DISK=/dev/nvme0n1\ndd if=/dev/zero of=$DISK count=1",
+			wantRule:     "dd-device",
+			wantCommand:  "dd of=$DISK",
+			wantMessage:  "dd overwrites the disk /dev/nvme0n1 ($DISK)",
+			wantSeverity: issue.SeverityError,
+		},
+		{
+			name:         "dd to a device named at run time",
+			script:       `dd if=/dev/urandom of="/dev/$1" bs=1M`,
+			wantRule:     "dd-device",
+			wantCommand:  "dd of=/dev/$1",
+			wantMessage:  "dd overwrites /dev/$1, a device only known at run time",
+			wantSeverity: issue.SeverityError,
+		},
+		{
+			name:         "dd to a path only known at run time",
+			script:       `dd if=boot.img of="$TARGET"`,
+			wantRule:     "dd-device",
+			wantCommand:  "dd of=$TARGET",
+			wantMessage:  "dd overwrites $TARGET, which may be a disk",
+			wantSeverity: issue.SeverityWarning,
+		},
+		{
+			name:         "dd to a loop device through sudo",
+			script:       "sudo dd if=rootfs.img of=/dev/loop0",
+			wantRule:     "dd-device",
+			wantCommand:  "sudo dd of=/dev/loop0",
+			wantMessage:  "dd overwrites the device /dev/loop0, running as root through sudo",
+			wantSeverity: issue.SeverityError,
+		},
+		{
+			name:         "mkfs",
+			script:       "mkfs.ext4 -F -L data /dev/nvme0n1p2",
+			wantRule:     "disk-format",
+			wantCommand:  "mkfs.ext4",
+			wantMessage:  "mkfs.ext4 formats the disk /dev/nvme0n1p2",
+			wantSeverity: issue.SeverityError,
+		},
+		{
+			name:         "mkfs on a variable",
+			script:       `mkfs -t xfs "$DEVICE"`,
+			wantRule:     "disk-format",
+			wantCommand:  "mkfs",
+			wantMessage:  "mkfs formats $DEVICE, which may be a disk",
+			wantSeverity: issue.SeverityError,
+		},
+		{
+			name:         "wipefs",
+			script:       "wipefs -a /dev/sda",
+			wantRule:     "disk-format",
+			wantCommand:  "wipefs -a",
+			wantMessage:  "wipefs -a erases the filesystem signatures of the disk /dev/sda",
+			wantSeverity: issue.SeverityError,
+		},
+		{
+			name:         "fdisk fed a script",
+			script:       "printf 'o\\nn\\np\\n\\n\\n\\nw\\n' | fdisk /dev/sdc",
+			wantRule:     "disk-partition",
+			wantCommand:  "fdisk",
+			wantMessage:  "fdisk rewrites the partition table of the disk /dev/sdc",
+			wantSeverity: issue.SeverityError,
+		},
+		{
+			name:         "parted mklabel",
+			script:       "parted -s /dev/sda mklabel gpt mkpart primary ext4 1MiB 100%",
+			wantRule:     "disk-partition",
+			wantCommand:  "parted mklabel",
+			wantMessage:  "parted mklabel rewrites the partition table of the disk /dev/sda",
+			wantSeverity: issue.SeverityError,
+		},
+		{
+			name:         "shred a disk",
+			script:       "shred -n 1 -z /dev/sdb",
+			wantRule:     "shred",
+			wantCommand:  "shred",
+			wantMessage:  "shred overwrites the disk /dev/sdb",
+			wantSeverity: issue.SeverityError,
+		},
+		{
+			name:         "shred and remove files",
+			script:       "shred -u ~/.ssh/id_rsa",
+			wantRule:     "shred",
+			wantCommand:  "shred -u",
+			wantMessage:  "shred -u overwrites and deletes ~/.ssh/id_rsa",
+			wantSeverity: issue.SeverityWarning,
+		},
+		{
+			name:         "shred a temporary file",
+			script:       "shred --remove /tmp/secret.key",
+			wantRule:     "shred",
+			wantCommand:  "shred -u",
+			wantMessage:  "shred -u overwrites and deletes the temporary path /tmp/secret.key",
+			wantSeverity: issue.SeverityInfo,
+		},
+		{
+			name:   "dd to an image",
+			script: "dd if=/dev/zero of=disk.img bs=1M count=64",
+		},
+		{
+			name:   "dd reading a disk",
+			script: "dd if=/dev/sda of=backup.img",
+		},
+		{
+			name:   "dd to standard output",
+			script: "dd if=/dev/urandom of=/dev/null count=10",
+		},
+		{
+			name:   "mkfs on an image",
+			script: "mkfs.ext4 -q rootfs.img 64M",
+		},
+		{
+			name:   "mkfs dry run",
+			script: "mke2fs -n /dev/sda1",
+		},
+		{
+			name:   "wipefs listing signatures",
+			script: "wipefs /dev/sda",
+		},
+		{
+			name:   "fdisk listing",
+			script: "fdisk -l /dev/sda",
+		},
+		{
+			name:   "parted print",
+			script: "parted -s /dev/sda unit MiB print",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issues := checkScript(t, NewRegistry(ddRule, formatRule, partitionRule, shredRule), tt.script)
+			if len(tt.wantCommand) == 0 {
+				assert.Empty(t, issues)
+				return
+			}
+
+			require.Len(t, issues, 1)
+			assert.Equal(t, tt.wantRule, issues[0].RuleID)
+			assert.Equal(t, tt.wantCommand, issues[0].Command)
+			assert.Equal(t, tt.wantMessage, issues[0].Message)
+			assert.Equal(t, tt.wantSeverity, issues[0].Severity)
+		})
+	}
+}
+
+func TestClassifyDevice(t *testing.T) {
+	tests := []struct {
+		path string
+		want deviceClass
+	}{
+		{path: "/dev/sda", want: deviceDisk},
+		{path: "/dev/sda1", want: deviceDisk},
+		{path: "/dev/nvme0n1p1", want: deviceDisk},
+		{path: "/dev/mmcblk0", want: deviceDisk},
+		{path: "/dev/mapper/vg-root", want: deviceDisk},
+		{path: "/dev/disk/by-id/ata-1", want: deviceDisk},
+		{path: "/dev/rdisk2", want: deviceDisk},
+		{path: "/dev/loop0", want: deviceOther},
+		{path: "/dev/null", want: deviceNone},
+		{path: "/dev/stdout", want: deviceNone},
+		{path: "/dev/shm/cache", want: deviceNone},
+		{path: "disk.img", want: deviceNone},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			assert.Equal(t, tt.want, classifyDevice(tt.path))
+		})
+	}
+}
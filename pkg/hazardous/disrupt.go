@@ -0,0 +1,510 @@
+package hazardous
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/hiteshrepo/hazardous/pkg/command"
+	"github.com/hiteshrepo/hazardous/pkg/issue"
+	"mvdan.cc/sh/syntax"
+)
+
+// hostService is a service or process the host cannot lose, and what
+// stopping it does.
+type hostService struct {
+	effect   string
+	severity issue.Severity
+}
+
+var (
+	remoteAccess = hostService{"cutting off remote access to the host", issue.SeverityError}
+	networking   = hostService{"taking the host off the network", issue.SeverityError}
+	containers   = hostService{"stopping every container on the host", issue.SeverityWarning}
+	firewall     = hostService{"turning the firewall of the host off", issue.SeverityWarning}
+	initProcess  = hostService{"which brings the whole host down", issue.SeverityError}
+	hostDaemon   = hostService{"breaking the services that depend on it", issue.SeverityWarning}
+)
+
+// criticalServices are the systemd units and process names of the services
+// a host depends on, by name without the .service suffix.
+var criticalServices = map[string]hostService{
+	"ssh": remoteAccess, "sshd": remoteAccess,
+	"network": networking, "networking": networking, "NetworkManager": networking,
+	"systemd-networkd": networking, "wpa_supplicant": networking,
+	"docker": containers, "dockerd": containers, "containerd": containers, "kubelet": containers, "crio": containers,
+	"firewalld": firewall, "ufw": firewall, "nftables": firewall, "iptables": firewall,
+	"systemd": initProcess, "init": initProcess,
+	"dbus": hostDaemon, "dbus-daemon": hostDaemon, "systemd-journald": hostDaemon,
+	"systemd-logind": hostDaemon, "systemd-resolved": hostDaemon, "cron": hostDaemon, "crond": hostDaemon,
+}
+
+// signalArg matches the signal given to kill, pkill or killall as an option,
+// e.g. -9, -KILL or -SIGKILL.
+var signalArg = regexp.MustCompile(`^-([0-9]+|[A-Z][A-Z0-9+-]*)$`)
+
+// matchAll lists the patterns matching the name of every process.
+var matchAll = []string{"", ".", ".*", ".+", "^", "$", "^.*$", "^.*", ".*$"}
+
+// hostActions describe what powering off, restarting or changing the mode of
+// the host does, by the systemctl verb doing it.
+var hostActions = map[string]string{
+	"poweroff":  "powers off the host, stopping every process running on it",
+	"reboot":    "restarts the host, stopping every process running on it",
+	"halt":      "halts the host, stopping every process running on it",
+	"rescue":    "switches the host to rescue mode, stopping every service and cutting off remote access",
+	"emergency": "switches the host to emergency mode, stopping every service and cutting off remote access",
+}
+
+// initLevels are the runlevels given to init and telinit that stop the host,
+// and the systemctl verb doing the same.
+var initLevels = map[string]string{"0": "poweroff", "6": "reboot", "1": "rescue", "s": "rescue", "S": "rescue"}
+
+// signalNames name the signals commonly given by number.
+var signalNames = map[string]string{
+	"1": "SIGHUP", "2": "SIGINT", "3": "SIGQUIT", "9": "SIGKILL", "15": "SIGTERM", "19": "SIGSTOP",
+}
+
+var shutdownRule = &commandRule{
+	id:          "system-shutdown",
+	description: "host shut down, rebooted or switched to rescue mode",
+	severity:    issue.SeverityError,
+	label:       "shutdown",
+	message:     "shutdown powers off the host",
+	fix:         "leave power management to the operator of the host, outside of scripts",
+	commands:    []string{"shutdown", "reboot", "poweroff", "halt", "init", "telinit", "systemctl"},
+	category:    issue.CategoryDisruption,
+	classify:    classifyShutdown,
+}
+
+var killRule = &commandRule{
+	id:          "kill-all",
+	description: "signal sent to every process, a process group or a critical process",
+	severity:    issue.SeverityWarning,
+	label:       "kill",
+	message:     "kill signals many processes at once",
+	fix:         "signal the processes started by the script by PID, e.g. from $!",
+	commands:    []string{"kill", "pkill", "killall", "killall5"},
+	category:    issue.CategoryDisruption,
+	classify:    classifyKill,
+}
+
+var firewallRule = &commandRule{
+	id:          "firewall-flush",
+	description: "firewall rules flushed or traffic dropped by default",
+	severity:    issue.SeverityWarning,
+	label:       "iptables -F",
+	message:     "iptables -F flushes firewall rules",
+	fix:         "change single rules with -A and -D, and save the rules with iptables-save before replacing them",
+	commands:    []string{"iptables", "ip6tables", "iptables-legacy", "iptables-nft", "ip6tables-legacy", "ip6tables-nft", "nft"},
+	category:    issue.CategoryDisruption,
+	classify:    classifyFirewall,
+}
+
+var swapoffRule = &commandRule{
+	id:          "swapoff",
+	description: "swap disabled with swapoff",
+	severity:    issue.SeverityWarning,
+	label:       "swapoff",
+	message:     "swapoff disables swap",
+	fix:         "check free memory before disabling swap, and leave swap to the configuration of the host",
+	commands:    []string{"swapoff"},
+	category:    issue.CategoryDisruption,
+	classify:    classifySwapoff,
+}
+
+var serviceRule = &commandRule{
+	id:          "service-stop",
+	description: "critical system service stopped, e.g. sshd or networking",
+	severity:    issue.SeverityWarning,
+	label:       "systemctl stop",
+	message:     "systemctl stop stops a critical service",
+	fix:         "restart services instead of stopping them, and never stop the ones giving access to the host",
+	commands:    []string{"systemctl", "service"},
+	category:    issue.CategoryDisruption,
+	classify:    classifyService,
+}
+
+// classifyShutdown keeps the commands powering off, halting or rebooting the
+// host, or switching it to rescue mode, which stops every service.
+func classifyShutdown(ctx *Context, c *call, args *command.Parsed, found *issue.Issue) bool {
+	operands := literalOperands(c, args)
+
+	var verb string
+
+	switch c.name {
+	case "shutdown":
+		if args.Has("cancel") || args.Has("show") || args.Has("warn-only") {
+			return false
+		}
+
+		switch {
+		case args.Has("reboot"):
+			found.Command, verb = "shutdown -r", "reboot"
+		case args.Has("halt"):
+			found.Command, verb = "shutdown -H", "halt"
+		default:
+			found.Command, verb = "shutdown", "poweroff"
+		}
+
+	case "reboot", "poweroff", "halt":
+		if args.Has("wtmp-only") {
+			return false
+		}
+
+		found.Command, verb = c.name, c.name
+
+	case "init", "telinit":
+		if len(operands) == 0 {
+			return false
+		}
+
+		found.Command, verb = c.name+" "+operands[0], initLevels[operands[0]]
+
+	case "systemctl":
+		if len(operands) == 0 {
+			return false
+		}
+
+		found.Command, verb = "systemctl "+operands[0], operands[0]
+
+		switch {
+		case verb == "isolate" && len(operands) > 1:
+			found.Command += " " + operands[1]
+			verb = strings.TrimSuffix(operands[1], ".target")
+		case verb == "soft-reboot" || verb == "kexec":
+			verb = "reboot"
+		}
+	}
+
+	action, ok := hostActions[verb]
+	if !ok {
+		return false
+	}
+
+	found.Message = fmt.Sprintf("%s %s", found.Command, action)
+
+	return true
+}
+
+// signalName returns the name of a signal given as a number or a name, e.g.
+// SIGKILL for 9 or KILL.
+func signalName(signal string) string {
+	if name, ok := signalNames[signal]; ok {
+		return name
+	}
+
+	return "SIG" + strings.TrimPrefix(strings.ToUpper(signal), "SIG")
+}
+
+// killArgs splits the arguments of kill into the signal option as written,
+// e.g. -9 or -s KILL, the name of the signal and the PIDs. It returns false
+// when kill lists signals instead.
+func (ctx *Context) killArgs(c *call) (flag, signal string, pids []*syntax.Word, ok bool) {
+	signal = "SIGTERM"
+
+	for i := 0; i < len(c.args); i++ {
+		value, literal := literalWord(c.args[i])
+
+		switch {
+		case !literal || !strings.HasPrefix(value, "-") || value == "-":
+			return flag, signal, c.args[i:], true
+		case value == "--":
+			return flag, signal, c.args[i+1:], true
+		case value == "-l" || value == "-L" || value == "--list" || value == "--table":
+			return "", "", nil, false
+		case len(flag) > 0:
+			// a negative PID once the signal is known
+			return flag, signal, c.args[i:], true
+		case (value == "-s" || value == "-n" || value == "--signal") && i+1 < len(c.args):
+			i++
+			flag = value + " " + ctx.wordText(c.args[i])
+			signal = signalName(ctx.wordText(c.args[i]))
+		default:
+			flag, signal = value, signalName(value[1:])
+		}
+	}
+
+	return flag, signal, nil, true
+}
+
+// withoutSignal returns c without the signal given as an option, e.g. the -9
+// of pkill -9 nginx, which would otherwise be parsed as bundled options. It
+// also returns the signal option and the name of the signal.
+func withoutSignal(c *call) (*call, string, string) {
+	for i, word := range c.args {
+		value, ok := literalWord(word)
+		if !ok || !strings.HasPrefix(value, "-") {
+			break
+		}
+
+		if signalArg.MatchString(value) {
+			stripped := *c
+			stripped.args = slices.Delete(slices.Clone(c.args), i, i+1)
+
+			return &stripped, value, signalName(value[1:])
+		}
+	}
+
+	return c, "", "SIGTERM"
+}
+
+// classifyKill keeps kill calls signalling every process, init or a process
+// group, and pkill and killall calls matching every process, every process
+// of a user or a critical service.
+func classifyKill(ctx *Context, c *call, args *command.Parsed, found *issue.Issue) bool {
+	const everyProcess = "every process the user can signal, including the shell running the script"
+
+	switch c.name {
+	case "killall5":
+		found.Command = "killall5"
+		found.Message = "killall5 signals every process but its own session and kernel threads"
+		found.Severity = issue.SeverityError
+
+		return true
+
+	case "kill":
+		flag, signal, pids, ok := ctx.killArgs(c)
+		if !ok || signal == "SIG0" {
+			return false
+		}
+
+		var target *syntax.Word
+
+		for _, word := range pids {
+			value, literal := literalWord(word)
+
+			switch {
+			case literal && value == "-1":
+				target, found.Severity = word, issue.SeverityError
+				found.Message = everyProcess
+			case literal && value == "1":
+				target, found.Severity = word, issue.SeverityError
+				found.Message = "init (PID 1), " + initProcess.effect
+			case strings.HasPrefix(literalPrefix(word), "-") && target == nil:
+				target = word
+				found.Message = "every process of the process group " + strings.TrimPrefix(ctx.wordText(word), "-")
+			}
+
+			if found.Severity == issue.SeverityError {
+				break
+			}
+		}
+
+		if target == nil {
+			return false
+		}
+
+		found.Command = strings.Join(slices.DeleteFunc([]string{"kill", flag, ctx.wordText(target)},
+			func(s string) bool { return len(s) == 0 }), " ")
+		found.Message = fmt.Sprintf("%s sends %s to %s", found.Command, signal, found.Message)
+
+		return true
+	}
+
+	// pkill and killall
+	stripped, flag, signal := withoutSignal(c)
+	args = parseCall(stripped)
+	if m, ok := args.Lookup("signal"); ok && len(m.Value) > 0 {
+		signal = signalName(m.Value)
+	}
+
+	if signal == "SIG0" {
+		// signal 0 only checks that the processes exist
+		return false
+	}
+
+	found.Command = c.name
+	if len(flag) > 0 {
+		found.Command += " " + flag
+	}
+
+	if args.Has("full") {
+		found.Command += " -f"
+	}
+
+	patterns := literalOperands(stripped, args)
+	regexps := c.name == "pkill" || args.Has("regexp")
+
+	for _, pattern := range patterns {
+		svc, critical := criticalServices[pattern]
+
+		switch {
+		case regexps && slices.Contains(matchAll, pattern):
+			found.Command += " " + pattern
+			found.Message = fmt.Sprintf("%s sends %s to %s", found.Command, signal, everyProcess)
+			found.Severity = issue.SeverityError
+
+			return true
+
+		case critical:
+			found.Command += " " + pattern
+			found.Message = fmt.Sprintf("%s sends %s to %s, %s", found.Command, signal, pattern, svc.effect)
+			found.Severity = svc.severity
+
+			return true
+		}
+	}
+
+	if len(operandWords(stripped, args)) > 0 {
+		return false
+	}
+
+	for _, name := range []string{"euid", "uid", "user"} {
+		if user := ctx.optionText(stripped, args, name); len(user) > 0 {
+			found.Command += " -u " + user
+			found.Message = fmt.Sprintf("%s sends %s to every process of the user %s", found.Command, signal, user)
+
+			return true
+		}
+	}
+
+	return false
+}
+
+// classifyFirewall keeps iptables flushing chains or dropping traffic by
+// default, and nft flushing the whole ruleset or a table.
+func classifyFirewall(ctx *Context, c *call, args *command.Parsed, found *issue.Issue) bool {
+	operands := literalOperands(c, args)
+
+	if c.name == "nft" {
+		switch {
+		case len(operands) >= 2 && operands[0] == "flush" && operands[1] == "ruleset":
+			found.Command = "nft flush ruleset"
+			found.Message = "nft flush ruleset deletes every firewall rule of the host, leaving it unprotected"
+			found.Severity = issue.SeverityError
+		case len(operands) >= 3 && (operands[0] == "flush" || operands[0] == "delete") && operands[1] == "table":
+			found.Command = "nft " + operands[0] + " table"
+			found.Message = fmt.Sprintf("%s %s deletes every rule of the table %s", found.Command,
+				strings.Join(operands[2:], " "), strings.Join(operands[2:], " "))
+		default:
+			return false
+		}
+
+		return true
+	}
+
+	table := ctx.optionText(c, args, "table")
+
+	found.Command = c.name
+	if len(table) > 0 {
+		found.Command += " -t " + table
+	} else {
+		table = "filter"
+	}
+
+	switch m, policy := args.Lookup("policy"); {
+	case args.Has("flush"):
+		found.Command += " -F"
+
+		if len(operands) == 0 {
+			found.Message = fmt.Sprintf("%s deletes every rule of every chain in the %s table, "+
+				"leaving the host unprotected or, under a DROP policy, unreachable", found.Command, table)
+			found.Severity = issue.SeverityError
+
+			return true
+		}
+
+		found.Message = fmt.Sprintf("%s %s deletes every rule of the chain %s in the %s table",
+			found.Command, operands[0], operands[0], table)
+
+	case policy && len(operands) > 0 && (strings.EqualFold(operands[0], "DROP") || strings.EqualFold(operands[0], "REJECT")):
+		found.Command += fmt.Sprintf(" -P %s %s", m.Value, operands[0])
+		found.Message = fmt.Sprintf("%s drops every packet of the chain %s that no rule accepts", found.Command, m.Value)
+
+		if m.Value == "INPUT" {
+			found.Message += ", which cuts off remote access unless a rule allows it"
+			found.Severity = issue.SeverityError
+		}
+
+	default:
+		return false
+	}
+
+	return true
+}
+
+// classifySwapoff keeps swapoff calls, which may leave the host without
+// enough memory for its processes.
+func classifySwapoff(ctx *Context, c *call, args *command.Parsed, found *issue.Issue) bool {
+	const effect = "so the kernel may kill processes when memory runs out"
+
+	operands := ctx.operandTexts(c, args)
+
+	switch {
+	case args.Has("all"):
+		found.Command = "swapoff -a"
+		found.Message = fmt.Sprintf("swapoff -a disables every swap area, %s", effect)
+	case len(operands) > 0:
+		found.Message = fmt.Sprintf("swapoff disables the swap area %s, %s", nameList(operands), effect)
+	default:
+		return false
+	}
+
+	return true
+}
+
+// classifyService keeps systemctl and service calls stopping a critical
+// service, e.g. sshd or networking.
+func classifyService(ctx *Context, c *call, args *command.Parsed, found *issue.Issue) bool {
+	operands := literalOperands(c, args)
+	if len(operands) < 2 || args.Has("user") {
+		return false
+	}
+
+	var (
+		units []string
+		verb  string
+	)
+
+	switch c.name {
+	case "service":
+		// service NAME stop
+		units, verb = operands[:1], operands[1]
+		found.Command = "service " + verb
+	default:
+		units, verb = operands[1:], operands[0]
+		found.Command = "systemctl " + verb
+	}
+
+	var action string
+
+	switch {
+	case verb == "stop":
+		action = "stops"
+	case verb == "kill" && c.name == "systemctl":
+		action = "kills"
+	case (verb == "disable" || verb == "mask") && args.Has("now"):
+		found.Command += " --now"
+		action = verb + "s and stops"
+	default:
+		return false
+	}
+
+	var (
+		worst hostService
+		unit  string
+	)
+
+	for _, u := range units {
+		name := strings.TrimSuffix(strings.TrimSuffix(u, ".service"), ".socket")
+		if svc, ok := criticalServices[name]; ok && (len(unit) == 0 || svc.severity > worst.severity) {
+			worst, unit = svc, name
+		}
+	}
+
+	if len(unit) == 0 {
+		return false
+	}
+
+	line := fmt.Sprintf("systemctl %s %s", verb, unit)
+	if c.name == "service" {
+		line = fmt.Sprintf("service %s %s", unit, verb)
+	}
+
+	found.Message = fmt.Sprintf("%s %s %s, %s", line, action, unit, worst.effect)
+	found.Severity = worst.severity
+
+	return true
+}
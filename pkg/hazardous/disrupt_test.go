@@ -0,0 +1,234 @@
+package hazardous
+
+import (
+	"testing"
+
+	"github.com/hiteshrepo/hazardous/pkg/issue"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDisruptionRules(t *testing.T) {
+	tests := []struct {
+		name         string
+		script       string
+		wantRule     string
+		wantCommand  string
+		wantMessage  string
+		wantSeverity issue.Severity
+	}{
+		{
+			name:         "reboot",
+			script:       "reboot",
+			wantRule:     "system-shutdown",
+			wantCommand:  "reboot",
+			wantMessage:  "reboot restarts the host, stopping every process running on it",
+			wantSeverity: issue.SeverityError,
+		},
+		{
+			name:         "scheduled shutdown",
+			script:       "shutdown -h +5 'maintenance'",
+			wantRule:     "system-shutdown",
+			wantCommand:  "shutdown",
+			wantMessage:  "shutdown powers off the host, stopping every process running on it",
+			wantSeverity: issue.SeverityError,
+		},
+		{
+			name:         "rescue target",
+			script:       "systemctl isolate rescue.target",
+			wantRule:     "system-shutdown",
+			wantCommand:  "systemctl isolate rescue.target",
+			wantMessage:  "systemctl isolate rescue.target switches the host to rescue mode, stopping every service and cutting off remote access",
+			wantSeverity: issue.SeverityError,
+		},
+		{
+			name:         "runlevel 6",
+			script:       "init 6",
+			wantRule:     "system-shutdown",
+			wantCommand:  "init 6",
+			wantMessage:  "init 6 restarts the host, stopping every process running on it",
+			wantSeverity: issue.SeverityError,
+		},
+		{
+			name:         "kill every process",
+			script:       "kill -9 -1",
+			wantRule:     "kill-all",
+			wantCommand:  "kill -9 -1",
+			wantMessage:  "kill -9 -1 sends SIGKILL to every process the user can signal, including the shell running the script",
+			wantSeverity: issue.SeverityError,
+		},
+		{
+			name:         "kill init",
+			script:       "sudo kill -s KILL 1",
+			wantRule:     "kill-all",
+			wantCommand:  "sudo kill -s KILL 1",
+			wantMessage:  "kill -s KILL 1 sends SIGKILL to init (PID 1), which brings the whole host down, running as root through sudo",
+			wantSeverity: issue.SeverityError,
+		},
+		{
+			name:         "kill a process group",
+			script:       `kill -- "-$PGID"`,
+			wantRule:     "kill-all",
+			wantCommand:  "kill -$PGID",
+			wantMessage:  "kill -$PGID sends SIGTERM to every process of the process group $PGID",
+			wantSeverity: issue.SeverityWarning,
+		},
+		{
+			name:         "pkill matching everything",
+			script:       "pkill -9 -f .",
+			wantRule:     "kill-all",
+			wantCommand:  "pkill -9 -f .",
+			wantMessage:  "pkill -9 -f . sends SIGKILL to every process the user can signal, including the shell running the script",
+			wantSeverity: issue.SeverityError,
+		},
+		{
+			name:         "killall of a critical process",
+			script:       "killall -HUP sshd",
+			wantRule:     "kill-all",
+			wantCommand:  "killall -HUP sshd",
+			wantMessage:  "killall -HUP sshd sends SIGHUP to sshd, cutting off remote access to the host",
+			wantSeverity: issue.SeverityError,
+		},
+		{
+			name:         "every process of a user",
+			script:       "pkill -u ci",
+			wantRule:     "kill-all",
+			wantCommand:  "pkill -u ci",
+			wantMessage:  "pkill -u ci sends SIGTERM to every process of the user ci",
+			wantSeverity: issue.SeverityWarning,
+		},
+		{
+			name:         "killall5",
+			script:       "killall5 -9",
+			wantRule:     "kill-all",
+			wantCommand:  "killall5",
+			wantMessage:  "killall5 signals every process but its own session and kernel threads",
+			wantSeverity: issue.SeverityError,
+		},
+		{
+			name:         "flush of every chain",
+			script:       "iptables -F",
+			wantRule:     "firewall-flush",
+			wantCommand:  "iptables -F",
+			wantMessage:  "iptables -F deletes every rule of every chain in the filter table, leaving the host unprotected or, under a DROP policy, unreachable",
+			wantSeverity: issue.SeverityError,
+		},
+		{
+			name:         "flush of one chain",
+			script:       "ip6tables -t nat -F POSTROUTING",
+			wantRule:     "firewall-flush",
+			wantCommand:  "ip6tables -t nat -F",
+			wantMessage:  "ip6tables -t nat -F POSTROUTING deletes every rule of the chain POSTROUTING in the nat table",
+			wantSeverity: issue.SeverityWarning,
+		},
+		{
+			name:         "input dropped by default",
+			script:       "iptables -P INPUT DROP",
+			wantRule:     "firewall-flush",
+			wantCommand:  "iptables -P INPUT DROP",
+			wantMessage:  "iptables -P INPUT DROP drops every packet of the chain INPUT that no rule accepts, which cuts off remote access unless a rule allows it",
+			wantSeverity: issue.SeverityError,
+		},
+		{
+			name:         "nft ruleset flushed",
+			script:       "nft flush ruleset",
+			wantRule:     "firewall-flush",
+			wantCommand:  "nft flush ruleset",
+			wantMessage:  "nft flush ruleset deletes every firewall rule of the host, leaving it unprotected",
+			wantSeverity: issue.SeverityError,
+		},
+		{
+			name:         "swap disabled",
+			script:       "swapoff -a",
+			wantRule:     "swapoff",
+			wantCommand:  "swapoff -a",
+			wantMessage:  "swapoff -a disables every swap area, so the kernel may kill processes when memory runs out",
+			wantSeverity: issue.SeverityWarning,
+		},
+		{
+			name:         "ssh stopped",
+			script:       "systemctl stop nginx sshd.service",
+			wantRule:     "service-stop",
+			wantCommand:  "systemctl stop",
+			wantMessage:  "systemctl stop sshd stops sshd, cutting off remote access to the host",
+			wantSeverity: issue.SeverityError,
+		},
+		{
+			name:         "docker disabled now",
+			script:       "systemctl disable --now docker",
+			wantRule:     "service-stop",
+			wantCommand:  "systemctl disable --now",
+			wantMessage:  "systemctl disable docker disables and stops docker, stopping every container on the host",
+			wantSeverity: issue.SeverityWarning,
+		},
+		{
+			name:         "networking stopped with service",
+			script:       "service networking stop",
+			wantRule:     "service-stop",
+			wantCommand:  "service stop",
+			wantMessage:  "service networking stop stops networking, taking the host off the network",
+			wantSeverity: issue.SeverityError,
+		},
+		{
+			name:   "cancelled shutdown",
+			script: "shutdown -c",
+		},
+		{
+			name:   "kill of a child",
+			script: `kill -9 "$pid"`,
+		},
+		{
+			name:   "signal listing",
+			script: "kill -l",
+		},
+		{
+			name:   "pkill checking a process exists",
+			script: "pkill -0 sshd",
+		},
+		{
+			name:   "killall checking a process exists",
+			script: "killall -s 0 sshd",
+		},
+		{
+			name:   "pkill of an application",
+			script: "pkill -f 'node server.js'",
+		},
+		{
+			name:   "listing firewall rules",
+			script: "iptables -L -n",
+		},
+		{
+			name:   "appending a rule",
+			script: "iptables -A INPUT -p tcp -j ACCEPT",
+		},
+		{
+			name:   "restart of ssh",
+			script: "systemctl restart sshd",
+		},
+		{
+			name:   "stop of an application",
+			script: "systemctl stop myapp",
+		},
+		{
+			name:   "user unit",
+			script: "systemctl --user stop docker",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issues := checkScript(t, NewRegistry(shutdownRule, killRule, firewallRule, swapoffRule, serviceRule), tt.script)
+			if len(tt.wantCommand) == 0 {
+				assert.Empty(t, issues)
+				return
+			}
+
+			require.Len(t, issues, 1)
+			assert.Equal(t, tt.wantRule, issues[0].RuleID)
+			assert.Equal(t, tt.wantCommand, issues[0].Command)
+			assert.Equal(t, tt.wantMessage, issues[0].Message)
+			assert.Equal(t, tt.wantSeverity, issues[0].Severity)
+			assert.Equal(t, issue.CategoryDisruption, issues[0].Category)
+		})
+	}
+}
@@ -0,0 +1,361 @@
+package hazardous
+
+import (
+	"sort"
+	"strings"
+
+	"mvdan.cc/sh/syntax"
+)
+
+// runKind tells where embedded code runs relative to the enclosing script,
+// which decides the variables it sees.
+type runKind int
+
+const (
+	// runsInline code runs in the current shell, as with eval.
+	runsInline runKind = iota
+	// runsLater code runs in the current shell at some later point, as with
+	// trap.
+	runsLater
+	// runsInSubshell code runs in a new shell started from the current one,
+	// as with bash -c.
+	runsInSubshell
+	// runsDetached code runs in a shell that does not share the variables
+	// of the current one, as with ssh or a generated script.
+	runsDetached
+)
+
+// script is shell code embedded in the arguments of a command, such as the
+// command string of bash -c, parsed on its own.
+type script struct {
+	file *syntax.File
+	code string
+	runs runKind
+	// via is the call running the code, and parent the embedded code it was
+	// found in, nil for the scanned source itself.
+	via    *call
+	parent *script
+
+	segments []segment
+	// links maps the placeholders standing for expansions performed by the
+	// enclosing shell, e.g. $DIR in bash -c "rm -rf $DIR", to those
+	// expansions.
+	links map[*syntax.ParamExp]*syntax.ParamExp
+}
+
+// segment maps a run of the embedded code, starting at offset, back to the
+// source text it was taken from. The byte at offset comes from text[start],
+// and text itself starts at pos in the enclosing source.
+type segment struct {
+	offset uint
+	pos    syntax.Pos
+	text   string
+	start  int
+}
+
+// maxEmbedding bounds the nesting of embedded code, which a variable holding
+// code that runs itself, as in X='eval "$X"'; eval "$X", makes endless.
+const maxEmbedding = 8
+
+// nests reports whether sc can be embedded in parent: the nesting stays
+// within maxEmbedding, and sc is not the code of parent or of the code
+// parent is embedded in, which would run itself again.
+func (sc *script) nests(parent *script) bool {
+	depth := 0
+
+	for p := parent; p != nil; p = p.parent {
+		if depth++; depth >= maxEmbedding || p.code == sc.code {
+			return false
+		}
+	}
+
+	return true
+}
+
+// shells run the command string given with -c.
+var shells = map[string]bool{
+	"ash": true, "bash": true, "dash": true, "ksh": true, "mksh": true, "sh": true, "zsh": true,
+}
+
+// embed parses the shell code embedded in the arguments of the commands run
+// by cmd. Arguments that are not constant strings, or not valid shell, are
+// skipped.
+func (ctx *Context) embed(cmd *syntax.CallExpr) []*script {
+	var scripts []*script
+
+	for _, c := range ctx.calls(cmd) {
+		words, runs, ok := embeddedWords(c)
+		if !ok {
+			continue
+		}
+
+		b := ctx.newCodeBuilder()
+		if !b.words(words) {
+			continue
+		}
+
+		if sc, ok := b.parse(runs, c); ok {
+			scripts = append(scripts, sc)
+		}
+	}
+
+	return scripts
+}
+
+// embeddedWords returns the arguments of c holding shell code, which the
+// command joins with spaces, and where the code runs.
+func embeddedWords(c *call) ([]*syntax.Word, runKind, bool) {
+	switch {
+	case shells[c.name]:
+		args := parseCall(c)
+		if !args.Has("command") || len(args.Operands) == 0 {
+			return nil, 0, false
+		}
+
+		return c.args[args.Operands[0] : args.Operands[0]+1], runsInSubshell, true
+
+	case c.name == "eval":
+		words := c.args
+		if len(words) > 0 && words[0].Lit() == "--" {
+			words = words[1:]
+		}
+
+		return words, runsInline, len(words) > 0
+
+	case c.name == "trap":
+		args := parseCall(c)
+		if args.Has("list") || args.Has("print") || len(args.Operands) < 2 {
+			return nil, 0, false
+		}
+
+		action := c.args[args.Operands[0]]
+		if value, _ := literalWord(action); value == "-" {
+			return nil, 0, false
+		}
+
+		return []*syntax.Word{action}, runsLater, true
+
+	case c.name == "ssh":
+		args := parseCall(c)
+		if len(args.Operands) < 2 {
+			return nil, 0, false
+		}
+
+		return c.args[args.Operands[1]:], runsDetached, true
+	}
+
+	return nil, 0, false
+}
+
+// codeBuilder rebuilds the shell code the command sees from the words of its
+// arguments, the way the enclosing shell expands them: quotes are removed,
+// variables holding constants are substituted, and other expansions are kept
+// as placeholders linked to the original expansion.
+type codeBuilder struct {
+	ctx      *Context
+	code     strings.Builder
+	segments []segment
+	params   map[uint]*syntax.ParamExp
+}
+
+func (ctx *Context) newCodeBuilder() *codeBuilder {
+	return &codeBuilder{ctx: ctx, params: make(map[uint]*syntax.ParamExp)}
+}
+
+// parse parses the code built so far, run by via.
+func (b *codeBuilder) parse(runs runKind, via *call) (*script, bool) {
+	if b.code.Len() == 0 {
+		return nil, false
+	}
+
+	file, err := syntax.NewParser().Parse(strings.NewReader(b.code.String()), "")
+	if err != nil {
+		return nil, false
+	}
+
+	sc := &script{
+		file:     file,
+		code:     b.code.String(),
+		runs:     runs,
+		via:      via,
+		segments: b.segments,
+		links:    make(map[*syntax.ParamExp]*syntax.ParamExp),
+	}
+
+	syntax.Walk(file, func(node syntax.Node) bool {
+		if pe, ok := node.(*syntax.ParamExp); ok {
+			if outer, ok := b.params[pe.Pos().Offset()]; ok {
+				sc.links[pe] = outer
+			}
+		}
+
+		return true
+	})
+
+	return sc, true
+}
+
+func (b *codeBuilder) words(words []*syntax.Word) bool {
+	for i, word := range words {
+		if i > 0 {
+			b.code.WriteByte(' ')
+		}
+
+		if !b.word(word) {
+			return false
+		}
+	}
+
+	return b.code.Len() > 0
+}
+
+func (b *codeBuilder) word(word *syntax.Word) bool {
+	for _, part := range word.Parts {
+		switch p := part.(type) {
+		case *syntax.Lit:
+			b.text(p.Pos(), p.Value, 0, func(byte) bool { return true })
+		case *syntax.SglQuoted:
+			if p.Dollar {
+				return false
+			}
+			b.text(p.Pos(), "'"+p.Value, 1, nil)
+		case *syntax.DblQuoted:
+			for _, part := range p.Parts {
+				switch p := part.(type) {
+				case *syntax.Lit:
+					b.text(p.Pos(), p.Value, 0, func(c byte) bool { return strings.IndexByte("$`\"\\\n", c) >= 0 })
+				case *syntax.ParamExp:
+					if !b.param(p) {
+						return false
+					}
+				default:
+					return false
+				}
+			}
+		case *syntax.ParamExp:
+			if !b.param(p) {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
+// heredoc writes the body of a here-document. Unless its delimiter is
+// quoted, the body is expanded like a double-quoted string. An empty body
+// is nil and writes nothing.
+func (b *codeBuilder) heredoc(body *syntax.Word, quoted bool) bool {
+	if body == nil {
+		return true
+	}
+
+	for _, part := range body.Parts {
+		switch p := part.(type) {
+		case *syntax.Lit:
+			if quoted {
+				b.text(p.Pos(), p.Value, 0, nil)
+			} else {
+				b.text(p.Pos(), p.Value, 0, func(c byte) bool { return strings.IndexByte("$`\\\n", c) >= 0 })
+			}
+		case *syntax.ParamExp:
+			if !b.param(p) {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
+// text writes text[start:], removing the backslashes before the characters
+// escaped reports true for. A nil escaped leaves backslashes alone.
+func (b *codeBuilder) text(pos syntax.Pos, text string, start int, escaped func(byte) bool) {
+	b.segment(pos, text, start)
+
+	for i := start; i < len(text); i++ {
+		if text[i] == '\\' && escaped != nil && i+1 < len(text) && escaped(text[i+1]) {
+			i++
+			if text[i] != '\n' {
+				b.segment(pos, text, i)
+				b.code.WriteByte(text[i])
+			}
+			b.segment(pos, text, i+1)
+			continue
+		}
+
+		b.code.WriteByte(text[i])
+	}
+}
+
+// param writes the value of a variable holding a constant, or a placeholder
+// for any other expansion.
+func (b *codeBuilder) param(p *syntax.ParamExp) bool {
+	b.segment(p.Pos(), "", 0)
+
+	if value, ok := b.ctx.constant(p); ok {
+		b.code.WriteString(value)
+		return true
+	}
+
+	if p.Param == nil {
+		return false
+	}
+
+	b.params[uint(b.code.Len())] = p
+	b.code.WriteString("${" + p.Param.Value + "}")
+
+	return true
+}
+
+// opaque writes code produced at run time from the data at pos, such as a
+// decoded payload, all of which maps back to pos.
+func (b *codeBuilder) opaque(pos syntax.Pos, code string) {
+	b.segment(pos, "", 0)
+	b.code.WriteString(code)
+}
+
+func (b *codeBuilder) segment(pos syntax.Pos, text string, start int) {
+	b.segments = append(b.segments, segment{offset: uint(b.code.Len()), pos: pos, text: text, start: start})
+}
+
+// positionIn maps a position in the embedded code sc, or in the scanned
+// source when sc is nil, to the scanned file.
+func (ctx *Context) positionIn(sc *script, pos syntax.Pos) (uint, uint) {
+	if sc == nil {
+		if ctx.MapPosition != nil {
+			return ctx.MapPosition(pos)
+		}
+
+		return pos.Line(), pos.Col()
+	}
+
+	offset := pos.Offset()
+
+	i := sort.Search(len(sc.segments), func(i int) bool { return sc.segments[i].offset > offset }) - 1
+	if i < 0 {
+		i = 0
+	}
+
+	seg := sc.segments[i]
+	line, col := ctx.positionIn(sc.parent, seg.pos)
+
+	end := seg.start + int(offset-seg.offset)
+	if end > len(seg.text) {
+		end = len(seg.text)
+	}
+
+	for j := 0; j < end; j++ {
+		if seg.text[j] == '\n' {
+			line, col = line+1, 1
+		} else {
+			col++
+		}
+	}
+
+	return line, col
+}
@@ -0,0 +1,216 @@
+package hazardous
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hiteshrepo/hazardous/pkg/issue"
+	"github.com/hiteshrepo/hazardous/pkg/makefile"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmbeddedCode(t *testing.T) {
+	tests := []struct {
+		name        string
+		script      string
+		wantRule    string
+		wantCommand string
+		wantMessage string
+		wantLine    uint
+		wantCol     uint
+	}{
+		{
+			name:        "sh -c",
+			script:      "sh -c 'rm -rf /'",
+			wantRule:    "rm-rf",
+			wantCommand: "rm -rf",
+			wantMessage: "rm -rf deletes the entire filesystem (/)",
+			wantLine:    1,
+			wantCol:     8,
+		},
+		{
+			name:        "bundled -c",
+			script:      `bash -ec "cd /; rm -rf *"`,
+			wantRule:    "rm-rf",
+			wantCommand: "rm -rf",
+			wantMessage: "rm -rf deletes everything in the current directory (*)",
+			wantLine:    1,
+			wantCol:     17,
+		},
+		{
+			name:        "expansion by the enclosing shell",
+			script:      `bash -c "rm -rf $DIR/*"`,
+			wantRule:    "empty-var-path",
+			wantCommand: "rm",
+			wantMessage: `$DIR is never assigned, so rm may be given "/*"`,
+			wantLine:    1,
+			wantCol:     17,
+		},
+		{
+			name:        "constant expanded by the enclosing shell",
+			script:      "DIR=/\nbash -c \"rm -rf $DIR\"",
+			wantRule:    "rm-rf",
+			wantCommand: "rm -rf",
+			wantMessage: "rm -rf deletes the entire filesystem (/)",
+			wantLine:    2,
+			wantCol:     10,
+		},
+		{
+			name:        "variable not exported to the new shell",
+			script:      "DIR=/opt\nbash -c 'rm -rf $DIR'",
+			wantRule:    "rm-rf",
+			wantCommand: "rm -rf",
+			wantMessage: "rm -rf deletes files recursively without asking for confirmation",
+			wantLine:    2,
+			wantCol:     10,
+		},
+		{
+			name:        "unexported variable may be empty in the new shell",
+			script:      "DIR=/opt\nbash -c 'rm -rf \"$DIR\"/*'",
+			wantRule:    "empty-var-path",
+			wantCommand: "rm",
+			wantMessage: `$DIR may be empty: not exported to the new shell (line 1), so rm may be given "/*"`,
+			wantLine:    2,
+			wantCol:     17,
+		},
+		{
+			name:        "exported variable",
+			script:      "export DIR=/opt\nbash -c 'rm -rf $DIR'",
+			wantRule:    "rm-rf",
+			wantCommand: "rm -rf",
+			wantMessage: "rm -rf deletes the system directory $DIR",
+			wantLine:    2,
+			wantCol:     10,
+		},
+		{
+			name:        "variable exported after its assignment",
+			script:      "DIR=/opt\ndeclare -x DIR\nsh -c 'rm -rf $DIR'",
+			wantRule:    "rm-rf",
+			wantCommand: "rm -rf",
+			wantMessage: "rm -rf deletes the system directory $DIR",
+			wantLine:    3,
+			wantCol:     8,
+		},
+		{
+			name:        "set -a exports assignments",
+			script:      "set -a\nDIR=/opt\nsh -c 'rm -rf $DIR'",
+			wantRule:    "rm-rf",
+			wantCommand: "rm -rf",
+			wantMessage: "rm -rf deletes the system directory $DIR",
+			wantLine:    3,
+			wantCol:     8,
+		},
+		{
+			name:        "eval of a variable",
+			script:      "CLEANUP='rm -rf /etc'\neval \"$CLEANUP\"",
+			wantRule:    "rm-rf",
+			wantCommand: "rm -rf",
+			wantMessage: "rm -rf deletes the system directory /etc",
+			wantLine:    2,
+			wantCol:     7,
+		},
+		{
+			name:        "eval of several words",
+			script:      `eval rm -rf '~/'`,
+			wantRule:    "rm-rf",
+			wantCommand: "rm -rf",
+			wantMessage: "rm -rf deletes the home directory (~/)",
+			wantLine:    1,
+			wantCol:     6,
+		},
+		{
+			name:        "trap sees later assignments",
+			script:      "trap 'rm -rf \"$WORK\"/*' EXIT\nWORK=$(mktemp -d)",
+			wantRule:    "empty-var-path",
+			wantCommand: "rm",
+			wantMessage: `$WORK may be empty: assigned from command output (line 2), so rm may be given "/*"`,
+			wantLine:    1,
+			wantCol:     14,
+		},
+		{
+			name:        "ssh",
+			script:      `ssh -p 2222 deploy@host "rm -rf /srv"`,
+			wantRule:    "rm-rf",
+			wantCommand: "rm -rf",
+			wantMessage: "rm -rf deletes the system directory /srv",
+			wantLine:    1,
+			wantCol:     26,
+		},
+		{
+			name:        "privileged shell",
+			script:      "sudo sh -c 'rm -rf /opt/app'",
+			wantRule:    "rm-rf",
+			wantCommand: "sudo rm -rf",
+			wantMessage: "rm -rf deletes files recursively without asking for confirmation, running as root through sudo",
+			wantLine:    1,
+			wantCol:     13,
+		},
+		{
+			name:        "multi-line command string",
+			script:      "bash -c '\n  set -e\n  rm -rf /\n'",
+			wantRule:    "rm-rf",
+			wantCommand: "rm -rf",
+			wantMessage: "rm -rf deletes the entire filesystem (/)",
+			wantLine:    3,
+			wantCol:     3,
+		},
+		{
+			name:        "nested",
+			script:      `sh -c "bash -c 'rm -rf /'"`,
+			wantRule:    "rm-rf",
+			wantCommand: "rm -rf",
+			wantMessage: "rm -rf deletes the entire filesystem (/)",
+			wantLine:    1,
+			wantCol:     17,
+		},
+		{name: "trap reset", script: "trap - EXIT"},
+		{name: "script file", script: "bash cleanup.sh -rf /"},
+		{name: "ssh without a command", script: "ssh host"},
+		{name: "not shell code", script: `bash -c "rm -rf (("`},
+		{name: "eval running itself", script: `X='eval "$X"'; eval "$X"`},
+		{name: "bash -c running itself", script: `export Y='bash -c "$Y"'; bash -c "$Y"`},
+		{name: "trap setting itself", script: `T='trap "$T" EXIT'; trap "$T" EXIT`},
+		{name: "eval growing itself", script: `X='eval "$X$X"'; eval "$X"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var issues []issue.Issue
+			for _, found := range checkScript(t, DefaultRegistry, tt.script) {
+				if len(tt.wantRule) == 0 || found.RuleID == tt.wantRule {
+					issues = append(issues, found)
+				}
+			}
+
+			if len(tt.wantRule) == 0 {
+				assert.Empty(t, issues)
+				return
+			}
+
+			require.Len(t, issues, 1)
+			assert.Equal(t, tt.wantRule, issues[0].RuleID)
+			assert.Equal(t, tt.wantCommand, issues[0].Command)
+			assert.Equal(t, tt.wantMessage, issues[0].Message)
+			assert.Equal(t, tt.wantLine, issues[0].Line)
+			assert.Equal(t, tt.wantCol, issues[0].Col)
+		})
+	}
+}
+
+func TestEmbeddedCodeMakefile(t *testing.T) {
+	mf, err := makefile.Parse(strings.NewReader(`OUT_DIR := ""
+clean:
+	bash -c "rm -rf $(OUT_DIR)/*"
+`), "Makefile")
+	require.NoError(t, err)
+
+	issues, err := NewRegistry(emptyVarRule).CheckMakefile("Makefile", mf)
+	require.NoError(t, err)
+
+	require.Len(t, issues, 1)
+	assert.Equal(t, `$(OUT_DIR) is empty: assigned an empty value (line 1), so rm may be given "/*"`, issues[0].Message)
+	assert.Equal(t, issue.SeverityError, issues[0].Severity)
+	assert.Equal(t, uint(3), issues[0].Line)
+	assert.Equal(t, uint(18), issues[0].Col)
+}
@@ -0,0 +1,234 @@
+package hazardous
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/hiteshrepo/hazardous/pkg/issue"
+	"mvdan.cc/sh/syntax"
+)
+
+// destructiveCommands are the commands whose path arguments must not lose a
+// component to an empty expansion: `rm -rf "$DIR/"` removes / when DIR is
+// empty.
+var destructiveCommands = map[string]bool{
+	"rm":       true,
+	"rmdir":    true,
+	"unlink":   true,
+	"shred":    true,
+	"truncate": true,
+	"mv":       true,
+	"chmod":    true,
+	"chown":    true,
+	"chgrp":    true,
+}
+
+var emptyVarRule = &emptyVariableRule{}
+
+// emptyVariableRule flags arguments of destructive commands built from a
+// variable or command substitution that may expand to an empty string, based
+// on the variable analysis of the file.
+type emptyVariableRule struct{}
+
+func (r *emptyVariableRule) ID() string { return "empty-var-path" }
+
+func (r *emptyVariableRule) Description() string {
+	return "destructive command on a path built from a variable that may be empty"
+}
+
+func (r *emptyVariableRule) Severity() issue.Severity { return issue.SeverityWarning }
+
+func (r *emptyVariableRule) Check(ctx *Context, node syntax.Node) []issue.Issue {
+	cmd, ok := node.(*syntax.CallExpr)
+	if !ok || ctx.vars == nil {
+		return nil
+	}
+
+	var issues []issue.Issue
+
+	for _, c := range ctx.calls(cmd) {
+		if !destructiveCommands[c.name] {
+			continue
+		}
+
+		for _, word := range operandWords(c, parseCall(c)) {
+			found, ok := r.checkWord(ctx, c.name, word)
+			if ok {
+				c.elevate(&found)
+				issues = append(issues, found)
+			}
+		}
+	}
+
+	return issues
+}
+
+func (r *emptyVariableRule) checkWord(ctx *Context, command string, word *syntax.Word) (issue.Issue, bool) {
+	for _, e := range ctx.emptyExpansions(word) {
+		if len(e.expanded) == 0 {
+			// the whole argument disappears, which is harmless
+			continue
+		}
+
+		if !widens(e.expanded, ctx.protected) {
+			// what is left stays where the argument pointed, as with
+			// $NAME.log
+			continue
+		}
+
+		found := ctx.newIssue(r, word, command, fmt.Sprintf("%s, so %s may be given %q", e.desc, command, e.expanded))
+		found.Severity = e.severity
+		found.Fix = e.fix
+
+		return found, true
+	}
+
+	return issue.Issue{}, false
+}
+
+// widens reports whether an argument left as p by an empty expansion reaches
+// further than it was meant to: the root, an absolute path, the whole current
+// directory, or a path that is more severe to delete than an unresolved one,
+// as when "$DIR/bin" becomes /bin.
+func widens(p string, protected []string) bool {
+	if path.IsAbs(p) {
+		return true
+	}
+
+	class := classifyPath(p, protected)
+
+	return class == pathCurrent || class.severity() > pathUnknown.severity()
+}
+
+// emptyExpansion is an expansion in a word that may be empty.
+type emptyExpansion struct {
+	// desc explains why, e.g. "$DIR is unset".
+	desc     string
+	fix      string
+	severity issue.Severity
+	// expanded is the word with the expansion empty.
+	expanded string
+}
+
+// emptyExpansions finds the variables and command substitutions in word
+// that may expand to an empty string, in order, based on the variable
+// analysis of the file.
+func (ctx *Context) emptyExpansions(word *syntax.Word) []emptyExpansion {
+	var found []emptyExpansion
+
+	parts := flattenParts(word.Parts)
+
+	for _, part := range parts {
+		e := emptyExpansion{severity: issue.SeverityWarning}
+
+		switch p := part.(type) {
+		case *syntax.ParamExp:
+			b, ok := ctx.vars.lookup(p)
+			if !ok || !b.mayBeEmpty() {
+				continue
+			}
+
+			e.desc = fmt.Sprintf("%s %s", ctx.expansion(p), ctx.describe(b))
+			e.fix = fmt.Sprintf(`use "${%s:?}" so the command aborts when the variable is empty`, p.Param.Value)
+			if len(b.fix) > 0 {
+				e.fix = b.fix
+			}
+
+			if b.state != varMaybeEmpty {
+				e.severity = issue.SeverityError
+			}
+
+		case *syntax.CmdSubst:
+			e.desc = "the command substitution may produce no output"
+			e.fix = "store the output in a variable and check that it is not empty first"
+
+		default:
+			continue
+		}
+
+		e.expanded = ctx.renderParts(parts, part)
+		found = append(found, e)
+	}
+
+	return found
+}
+
+// describe explains why an expansion with binding b may be empty.
+func (ctx *Context) describe(b binding) string {
+	var desc string
+
+	switch b.state {
+	case varUnset:
+		if !b.pos.valid() && b.line == 0 {
+			return "is never assigned"
+		}
+		desc = "is unset"
+	case varEmpty:
+		desc = "is empty: " + b.how
+	default:
+		desc = "may be empty: " + b.how
+	}
+
+	switch {
+	case b.pos.valid():
+		line, _ := ctx.positionIn(b.pos.script, b.pos.pos)
+		desc += fmt.Sprintf(" (line %d)", line)
+	case b.line > 0:
+		desc += fmt.Sprintf(" (line %d)", b.line)
+	}
+
+	return desc
+}
+
+// expansion returns how a parameter expansion is written in the scanned file.
+func (ctx *Context) expansion(pe *syntax.ParamExp) string {
+	if b, ok := ctx.vars.lookup(pe); ok && len(b.expr) > 0 {
+		return b.expr
+	}
+
+	return "$" + pe.Param.Value
+}
+
+// flattenParts returns the parts of a word with double quotes removed.
+func flattenParts(parts []syntax.WordPart) []syntax.WordPart {
+	var flat []syntax.WordPart
+
+	for _, part := range parts {
+		if dq, ok := part.(*syntax.DblQuoted); ok {
+			flat = append(flat, flattenParts(dq.Parts)...)
+			continue
+		}
+
+		flat = append(flat, part)
+	}
+
+	return flat
+}
+
+// renderParts renders flattened word parts with empty expanding to nothing,
+// showing what the word becomes when that expansion is empty.
+func (ctx *Context) renderParts(parts []syntax.WordPart, empty syntax.WordPart) string {
+	var sb strings.Builder
+
+	for _, part := range parts {
+		if part == empty {
+			continue
+		}
+
+		switch p := part.(type) {
+		case *syntax.Lit:
+			sb.WriteString(p.Value)
+		case *syntax.SglQuoted:
+			sb.WriteString(p.Value)
+		case *syntax.ParamExp:
+			sb.WriteString(ctx.expansion(p))
+		case *syntax.CmdSubst:
+			sb.WriteString("$(...)")
+		default:
+			sb.WriteString("...")
+		}
+	}
+
+	return sb.String()
+}
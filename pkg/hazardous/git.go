@@ -0,0 +1,321 @@
+package hazardous
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/hiteshrepo/hazardous/pkg/command"
+	"github.com/hiteshrepo/hazardous/pkg/issue"
+)
+
+// sharedBranches are the branches everybody works from, whose history must
+// never be rewritten.
+var sharedBranches = []string{"main", "master", "trunk", "develop", "production", "prod", "stable"}
+
+var gitRule = &commandRule{
+	id:          "git-history",
+	description: "git command destroying commits or uncommitted work",
+	severity:    issue.SeverityWarning,
+	label:       "git",
+	message:     "git destroys commits or uncommitted work",
+	commands:    []string{"git"},
+	classify:    classifyGit,
+}
+
+// classifyGit keeps the git subcommands that lose history or work: forced
+// pushes, git reset --hard, git clean -f, git branch -D and git
+// filter-branch.
+func classifyGit(ctx *Context, c *call, args *command.Parsed, found *issue.Issue) bool {
+	sub, ok := c.subcommand(args)
+	if !ok {
+		return false
+	}
+
+	subArgs := parseCall(sub)
+
+	switch sub.name {
+	case "git-push":
+		return classifyPush(sub, subArgs, found)
+	case "git-reset":
+		return classifyReset(sub, subArgs, found)
+	case "git-clean":
+		return classifyClean(subArgs, found)
+	case "git-branch":
+		return classifyBranch(sub, subArgs, found)
+	case "git-filter-branch":
+		return classifyFilterBranch(sub, subArgs, found)
+	default:
+		return false
+	}
+}
+
+// refspec is a single ref given to git push, e.g. +HEAD:main.
+type refspec struct {
+	// branch is the remote branch updated, without refs/heads/.
+	branch string
+	force  bool
+	delete bool
+}
+
+// parseRefspec parses a literal refspec given to git push.
+func parseRefspec(spec string) refspec {
+	var r refspec
+
+	spec, r.force = strings.CutPrefix(spec, "+")
+
+	src, dst, ok := strings.Cut(spec, ":")
+	if !ok {
+		dst = src
+	}
+
+	r.branch = strings.TrimPrefix(dst, "refs/heads/")
+	r.delete = ok && len(src) == 0
+
+	return r
+}
+
+// isSharedBranch reports whether branch is one everybody works from, e.g.
+// main or release/1.2.
+func isSharedBranch(branch string) bool {
+	return slices.Contains(sharedBranches, branch) || strings.HasPrefix(branch, "release")
+}
+
+// classifyPush keeps pushes that force-update or delete remote branches. The
+// severity depends on whether a shared branch is forced or deleted. A branch
+// is forced by --force or a +refspec, while --force-with-lease only rewrites
+// history the pusher has seen.
+func classifyPush(c *call, args *command.Parsed, found *issue.Issue) bool {
+	if args.Has("dry-run") {
+		return false
+	}
+
+	var (
+		remote   string
+		branches []string
+		deleted  []string
+		// plus is set when a refspec forces its branch
+		plus        bool
+		forced      = args.Has("force")
+		lease       = args.Has("force-with-lease")
+		shared      bool
+		sharedForce bool
+	)
+
+	for i, word := range operandWords(c, args) {
+		value, ok := literalWord(word)
+		if i == 0 {
+			remote = value
+			continue
+		}
+
+		if !ok {
+			continue
+		}
+
+		r := parseRefspec(value)
+
+		switch {
+		case r.delete || args.Has("delete"):
+			deleted = append(deleted, r.branch)
+			shared = shared || isSharedBranch(r.branch)
+		case forced || r.force:
+			plus = plus || r.force
+			branches = append(branches, r.branch)
+			shared = shared || isSharedBranch(r.branch)
+			sharedForce = sharedForce || isSharedBranch(r.branch)
+		case lease:
+			branches = append(branches, r.branch)
+			shared = shared || isSharedBranch(r.branch)
+		}
+	}
+
+	on := ""
+	if len(remote) > 0 {
+		on = " on " + remote
+	}
+
+	switch {
+	case args.Has("mirror"):
+		found.Command = "git push --mirror"
+		found.Message = fmt.Sprintf("git push --mirror overwrites and deletes every branch and tag%s", on)
+		found.Fix = "push the branches and tags to update by name"
+
+	case forced || plus || lease:
+		switch {
+		case forced:
+			found.Command = "git push --force"
+		case plus:
+			found.Command = "git push +refspec"
+		default:
+			found.Command = "git push --force-with-lease"
+		}
+
+		target := "the branches it pushes"
+		switch {
+		case args.Has("all"):
+			target, shared, sharedForce = "every branch", true, forced
+		case len(branches) > 0:
+			target = branchList(branches)
+		}
+
+		found.Message = fmt.Sprintf("%s rewrites the history of %s%s", found.Command, target, on)
+		found.Fix = "never force-push to shared branches, and use --force-with-lease elsewhere"
+
+		switch {
+		case sharedForce:
+			found.Severity = issue.SeverityError
+		case !shared && !forced && !plus:
+			found.Severity = issue.SeverityInfo
+		}
+
+	case len(deleted) > 0:
+		found.Command = "git push --delete"
+		found.Message = fmt.Sprintf("git push --delete deletes %s%s", branchList(deleted), on)
+		found.Fix = "delete remote branches by hand once they are merged"
+
+		if shared {
+			found.Severity = issue.SeverityError
+		}
+
+	default:
+		return false
+	}
+
+	return true
+}
+
+// branchList names the given branches, e.g. "branch main" or "branches main
+// and dev".
+func branchList(branches []string) string {
+	if len(branches) == 1 {
+		return "branch " + branches[0]
+	}
+
+	return "branches " + nameList(branches)
+}
+
+// classifyReset keeps git reset --hard, which discards uncommitted changes.
+func classifyReset(c *call, args *command.Parsed, found *issue.Issue) bool {
+	if !args.Has("hard") {
+		return false
+	}
+
+	found.Command = "git reset --hard"
+	found.Message = "git reset --hard discards uncommitted changes"
+	found.Fix = "stash or commit the changes first, or use git reset --keep"
+
+	if words := operandWords(c, args); len(words) > 0 {
+		if target, ok := literalWord(words[0]); ok && target != "HEAD" {
+			found.Message += " and moves the current branch to " + target
+		}
+	}
+
+	return true
+}
+
+// classifyClean keeps git clean runs that delete files, which need -f unless
+// clean.requireForce is turned off.
+func classifyClean(args *command.Parsed, found *issue.Issue) bool {
+	if !args.Has("force") || args.Has("dry-run") || args.Has("interactive") {
+		return false
+	}
+
+	flags, what := "-f", "untracked files"
+	if args.Has("only-ignored") {
+		flags, what = "-fX", "ignored files"
+	}
+
+	if args.Has("directories") {
+		flags, what = strings.Replace(flags, "f", "fd", 1), what+" and directories"
+	}
+
+	if args.Has("ignored") && !args.Has("only-ignored") {
+		flags, what = flags+"x", what+", including ignored ones such as .env files and caches"
+	}
+
+	found.Command = "git clean " + flags
+	found.Message = fmt.Sprintf("%s deletes %s", found.Command, what)
+	found.Fix = "preview what is deleted with git clean -n, and drop -x to keep ignored files"
+
+	return true
+}
+
+// classifyBranch keeps git branch -D, which deletes branches even when they
+// are not merged.
+func classifyBranch(c *call, args *command.Parsed, found *issue.Issue) bool {
+	if !args.Has("delete-force") && !(args.Has("delete") && args.Has("force")) {
+		return false
+	}
+
+	var branches []string
+
+	for _, word := range operandWords(c, args) {
+		if branch, ok := literalWord(word); ok {
+			branches = append(branches, branch)
+
+			if isSharedBranch(branch) {
+				found.Severity = issue.SeverityError
+			}
+		}
+	}
+
+	target := "branches"
+	if len(branches) > 0 {
+		target = branchList(branches)
+	}
+
+	found.Command = "git branch -D"
+	found.Message = fmt.Sprintf("git branch -D deletes %s even if not merged", target)
+	found.Fix = "use git branch -d, which refuses to delete unmerged branches"
+
+	return true
+}
+
+// classifyFilterBranch keeps git filter-branch, which rewrites every commit
+// of the branches it is given. Of a range such as main..HEAD, only the ref
+// after the dots is rewritten, and refs excluded with ^ are left alone.
+func classifyFilterBranch(c *call, args *command.Parsed, found *issue.Issue) bool {
+	var (
+		branches []string
+		current  bool
+		all      bool
+	)
+
+	for _, word := range operandWords(c, args) {
+		value, ok := literalWord(word)
+		if i := strings.LastIndex(value, ".."); i >= 0 {
+			value = strings.TrimPrefix(value[i+2:], ".")
+		}
+
+		switch {
+		case value == "--all":
+			all = true
+		case !ok || strings.HasPrefix(value, "-") || strings.HasPrefix(value, "^"):
+		case len(value) == 0 || value == "HEAD":
+			current = true
+		default:
+			branches = append(branches, value)
+		}
+	}
+
+	// rev-list options may also come before --, where they are unknown
+	all = all || slices.Contains(args.Unknown, "--all")
+
+	target := "the current branch"
+	switch {
+	case all:
+		target = "every branch and tag"
+		found.Severity = issue.SeverityError
+	case current && len(branches) > 0:
+		target = "the current branch and " + branchList(branches)
+	case len(branches) > 0:
+		target = branchList(branches)
+	}
+
+	found.Command = "git filter-branch"
+	found.Message = fmt.Sprintf("git filter-branch rewrites every commit of %s", target)
+	found.Fix = "rewrite history in a fresh clone, and coordinate with everyone using the repository"
+
+	return true
+}
@@ -0,0 +1,175 @@
+package hazardous
+
+import (
+	"testing"
+
+	"github.com/hiteshrepo/hazardous/pkg/issue"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGitRule(t *testing.T) {
+	tests := []struct {
+		name         string
+		script       string
+		wantCommand  string
+		wantMessage  string
+		wantSeverity issue.Severity
+	}{
+		{
+			name:         "force push to main",
+			script:       "git push --force origin main",
+			wantCommand:  "git push --force",
+			wantMessage:  "git push --force rewrites the history of branch main on origin",
+			wantSeverity: issue.SeverityError,
+		},
+		{
+			name:         "forced refspec",
+			script:       "git -C repo push origin +HEAD:refs/heads/release/2.0",
+			wantCommand:  "git push +refspec",
+			wantMessage:  "git push +refspec rewrites the history of branch release/2.0 on origin",
+			wantSeverity: issue.SeverityError,
+		},
+		{
+			name:         "forced refspec next to a plain one",
+			script:       "git push origin +feature main",
+			wantCommand:  "git push +refspec",
+			wantMessage:  "git push +refspec rewrites the history of branch feature on origin",
+			wantSeverity: issue.SeverityWarning,
+		},
+		{
+			name:         "force push to a feature branch",
+			script:       "git push -fu origin feature/login",
+			wantCommand:  "git push --force",
+			wantMessage:  "git push --force rewrites the history of branch feature/login on origin",
+			wantSeverity: issue.SeverityWarning,
+		},
+		{
+			name:         "force push of the current branch",
+			script:       "git push -f",
+			wantCommand:  "git push --force",
+			wantMessage:  "git push --force rewrites the history of the branches it pushes",
+			wantSeverity: issue.SeverityWarning,
+		},
+		{
+			name:         "force with lease",
+			script:       "git push --force-with-lease origin topic",
+			wantCommand:  "git push --force-with-lease",
+			wantMessage:  "git push --force-with-lease rewrites the history of branch topic on origin",
+			wantSeverity: issue.SeverityInfo,
+		},
+		{
+			name:         "delete a shared branch",
+			script:       "git push origin :master",
+			wantCommand:  "git push --delete",
+			wantMessage:  "git push --delete deletes branch master on origin",
+			wantSeverity: issue.SeverityError,
+		},
+		{
+			name:         "mirror",
+			script:       "git push --mirror backup",
+			wantCommand:  "git push --mirror",
+			wantMessage:  "git push --mirror overwrites and deletes every branch and tag on backup",
+			wantSeverity: issue.SeverityWarning,
+		},
+		{
+			name:         "hard reset",
+			script:       "git reset --hard origin/main",
+			wantCommand:  "git reset --hard",
+			wantMessage:  "git reset --hard discards uncommitted changes and moves the current branch to origin/main",
+			wantSeverity: issue.SeverityWarning,
+		},
+		{
+			name:         "clean with ignored files",
+			script:       "git clean -fdx",
+			wantCommand:  "git clean -fdx",
+			wantMessage:  "git clean -fdx deletes untracked files and directories, including ignored ones such as .env files and caches",
+			wantSeverity: issue.SeverityWarning,
+		},
+		{
+			name:         "force delete branches",
+			script:       "git branch -D develop topic",
+			wantCommand:  "git branch -D",
+			wantMessage:  "git branch -D deletes branches develop and topic even if not merged",
+			wantSeverity: issue.SeverityError,
+		},
+		{
+			name:         "filter-branch",
+			script:       "git filter-branch --force --index-filter 'git rm --cached -r secrets' -- --all",
+			wantCommand:  "git filter-branch",
+			wantMessage:  "git filter-branch rewrites every commit of every branch and tag",
+			wantSeverity: issue.SeverityError,
+		},
+		{
+			name:         "filter-branch of HEAD",
+			script:       "git filter-branch --msg-filter 'sed s/foo/bar/' HEAD",
+			wantCommand:  "git filter-branch",
+			wantMessage:  "git filter-branch rewrites every commit of the current branch",
+			wantSeverity: issue.SeverityWarning,
+		},
+		{
+			name:         "filter-branch of a range",
+			script:       "git filter-branch --msg-filter 'sed s/foo/bar/' -- main..feature ^v1.0",
+			wantCommand:  "git filter-branch",
+			wantMessage:  "git filter-branch rewrites every commit of branch feature",
+			wantSeverity: issue.SeverityWarning,
+		},
+		{
+			name:   "plain push",
+			script: "git push origin main",
+		},
+		{
+			name:   "dry run",
+			script: "git push -n -f origin main",
+		},
+		{
+			name:   "soft reset",
+			script: "git reset --soft HEAD~1",
+		},
+		{
+			name:   "clean dry run",
+			script: "git clean -ndx",
+		},
+		{
+			name:   "safe branch delete",
+			script: "git branch -d topic",
+		},
+		{
+			name:   "option value named like a subcommand",
+			script: "git -c push.default=current status",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issues := checkScript(t, NewRegistry(gitRule), tt.script)
+			if len(tt.wantCommand) == 0 {
+				assert.Empty(t, issues)
+				return
+			}
+
+			require.Len(t, issues, 1)
+			assert.Equal(t, tt.wantCommand, issues[0].Command)
+			assert.Equal(t, tt.wantMessage, issues[0].Message)
+			assert.Equal(t, tt.wantSeverity, issues[0].Severity)
+		})
+	}
+}
+
+func TestParseRefspec(t *testing.T) {
+	tests := []struct {
+		spec string
+		want refspec
+	}{
+		{spec: "main", want: refspec{branch: "main"}},
+		{spec: "+main", want: refspec{branch: "main", force: true}},
+		{spec: "HEAD:refs/heads/prod", want: refspec{branch: "prod"}},
+		{spec: ":old", want: refspec{branch: "old", delete: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			assert.Equal(t, tt.want, parseRefspec(tt.spec))
+		})
+	}
+}
@@ -1,168 +1,107 @@
 package hazardous
 
 import (
-	"regexp"
-	"strings"
+	"slices"
 
+	"github.com/hiteshrepo/hazardous/pkg/command"
 	"github.com/hiteshrepo/hazardous/pkg/issue"
 	"mvdan.cc/sh/syntax"
 )
 
-type HazardousCommand struct {
-	command string
-	flags   []string
+var rmRule = &commandRule{
+	id:          "rm-rf",
+	description: "recursive file deletion with rm",
+	severity:    issue.SeverityWarning,
+	label:       "rm -rf",
+	message:     "rm -rf deletes files recursively without asking for confirmation",
+	fix:         `guard the target path, e.g. rm -rf "${DIR:?}/build", or drop -f to be prompted`,
+	commands:    []string{"rm"},
+	options:     []string{"recursive"},
+	classify:    classifyRm,
 }
 
-var (
-	// Define known hazardous commands
-	rmCommand = HazardousCommand{
-		command: "rm",
-		flags: []string{
-			"-r", "--recursive",
-			"-f", "--force",
-			"-rf", "-fr", "--recursive --force",
-		},
-	}
-)
+// commandRule is a Rule that flags any of the given commands invoked with any
+// of the given options, named as in the command spec, e.g. "recursive" for -r,
+// -R and --recursive. A rule without options flags every invocation.
+type commandRule struct {
+	id          string
+	description string
+	severity    issue.Severity
+	label       string
+	message     string
+	fix         string
+	commands    []string
+	options     []string
+	category    issue.Category
+
+	// classify, when set, refines the label, severity and message of a
+	// finding, e.g. from the paths the command operates on, and returns false
+	// to drop it.
+	classify func(ctx *Context, c *call, args *command.Parsed, found *issue.Issue) bool
+}
 
-func CheckHazardousCommand(cmd *syntax.CallExpr, filepath string) *issue.Issue {
-	if cmd == nil {
-		return nil
-	}
+func (r *commandRule) ID() string               { return r.id }
+func (r *commandRule) Description() string      { return r.description }
+func (r *commandRule) Severity() issue.Severity { return r.severity }
+func (r *commandRule) Category() issue.Category { return r.category }
 
-	cmdName := extractCommandName(cmd)
-	if cmdName == "" {
+func (r *commandRule) Check(ctx *Context, node syntax.Node) []issue.Issue {
+	cmd, ok := node.(*syntax.CallExpr)
+	if !ok {
 		return nil
 	}
 
-	switch cmdName {
-	case rmCommand.command:
-		if hasHazardousFlags(cmd, rmCommand.flags) {
-			pos := cmd.Pos()
-
-			return &issue.Issue{
-				Filepath: filepath,
-				Line:     pos.Line(),
-				Col:      pos.Col(),
-				Command:  "rm -rf",
-			}
-		}
-	}
-
-	return nil
-}
-
-func CheckHazardousLine(line, command string) (string, uint) {
-	flagTillNow := ""
+	var issues []issue.Issue
 
-	words := splitCommand(line)
-	for _, word := range words {
-		if len(strings.TrimSpace(word)) == 0 ||
-			strings.EqualFold(strings.TrimSpace(word), strings.TrimSpace(command)) {
+	for _, c := range ctx.calls(cmd) {
+		if !slices.Contains(r.commands, c.name) {
 			continue
 		}
 
-		if len(flagTillNow) == 0 {
-			flagTillNow += word
+		args := parseCall(c)
+		if !r.hasOption(args) {
+			continue
 		}
 
-		if len(flagTillNow) > 0 {
-			if strings.Contains(word, "--") {
-				flagTillNow += " " + word
-			}
+		found := ctx.newIssue(r, cmd, r.label, r.message)
+		found.Fix = r.fix
 
-			if strings.Contains(word, "-") {
-				flagTillNow += strings.TrimPrefix(word, "-")
-			}
+		if r.classify != nil && !r.classify(ctx, c, args, &found) {
+			continue
 		}
 
-		for _, flag := range rmCommand.flags {
-			if flagTillNow == flag {
-				return flagTillNow, uint(strings.Index(line, command) + 1)
-			}
-
-			if word == flag {
-				return word, uint(strings.Index(line, command) + 1)
-			}
-		}
+		c.elevate(&found)
+		issues = append(issues, found)
 	}
 
-	return "", 0
+	return issues
 }
 
-func splitCommand(input string) []string {
-	re := regexp.MustCompile(`\s+`)
-
-	parts := re.Split(strings.TrimSpace(input), -1)
-	return parts
-}
-
-func wordValue(wordPart syntax.WordPart) string {
-	// ast.Print(token.NewFileSet(), wordPart)
-
-	switch part := wordPart.(type) {
-	case *syntax.Lit:
-		return part.Value
-
-	case *syntax.SglQuoted:
-		return part.Value
-
-	default:
-		return ""
+func (r *commandRule) hasOption(args *command.Parsed) bool {
+	if len(r.options) == 0 {
+		return true
 	}
-}
 
-func extractCommandName(cmd *syntax.CallExpr) string {
-	if len(cmd.Args) == 0 {
-		return ""
+	for _, name := range r.options {
+		if args.Has(name) {
+			return true
+		}
 	}
 
-	word := cmd.Args[0]
-
-	switch part := word.Parts[0].(type) {
-	case *syntax.DblQuoted:
-		return wordValue(part.Parts[0])
-
-	default:
-		return wordValue(part)
-	}
+	return false
 }
 
-func hasHazardousFlags(cmd *syntax.CallExpr, hazardousFlags []string) bool {
-	flagTillNow := ""
-
-	for _, word := range cmd.Args[1:] {
-		// ast.Print(token.NewFileSet(), word)
-
-		value := ""
-		switch part := word.Parts[0].(type) {
-		case *syntax.DblQuoted:
-			value = wordValue(part.Parts[0])
-
-		default:
-			value = wordValue(part)
-		}
-
-		if len(flagTillNow) == 0 {
-			flagTillNow += value
-		}
-
-		if len(flagTillNow) > 0 {
-			if strings.Contains(value, "--") {
-				flagTillNow += " " + value
-			}
-
-			if strings.Contains(value, "-") {
-				flagTillNow += strings.TrimPrefix(value, "-")
-			}
-		}
+// CheckHazardousCommand runs the rules of the DefaultRegistry against a single
+// command and returns the first issue found, if any.
+func CheckHazardousCommand(cmd *syntax.CallExpr, filepath string) *issue.Issue {
+	if cmd == nil {
+		return nil
+	}
 
-		for _, flag := range hazardousFlags {
-			if value == flag || flagTillNow == flag {
-				return true
-			}
-		}
+	issues := DefaultRegistry.Check(&Context{Filepath: filepath}, cmd)
+	if len(issues) == 0 {
+		return nil
 	}
 
-	return false
+	return &issues[0]
 }
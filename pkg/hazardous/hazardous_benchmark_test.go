@@ -1,20 +1,33 @@
 package hazardous
 
-import "testing"
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
 
-func BenchmarkExtractCommandName(b *testing.B) {
+func BenchmarkParseCall(b *testing.B) {
 	cmd := createCallExpr(&testing.T{}, "rm -rf file.txt")
+	ctx := &Context{Filepath: "test.sh"}
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		extractCommandName(cmd)
+		for _, c := range ctx.calls(cmd) {
+			parseCall(c)
+		}
 	}
 }
 
-func BenchmarkHasHazardousFlags(b *testing.B) {
-	cmd := createCallExpr(&testing.T{}, "rm -rf file.txt")
-	hazardousFlags := []string{"-rf", "-fr"}
+func BenchmarkTrackVariables(b *testing.B) {
+	var script strings.Builder
+	for i := 0; i < 5000; i++ {
+		fmt.Fprintf(&script, "DIR%d=/tmp/build%d\n", i, i)
+		fmt.Fprintf(&script, "if [ -d \"$DIR%d\" ]; then rm -rf \"$DIR%d\"/*; fi\n", i, i)
+		fmt.Fprintf(&script, "curl -fsSL \"$URL\" | jq . > \"$DIR%d/out.json\" && chmod 644 \"$DIR%d/out.json\"\n", i, i)
+	}
+
+	file := parseScript(&testing.T{}, script.String())
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		hasHazardousFlags(cmd, hazardousFlags)
+		trackVariables(file)
 	}
 }
@@ -4,6 +4,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/hiteshrepo/hazardous/pkg/issue"
 	"mvdan.cc/sh/syntax"
 )
 
@@ -27,188 +28,119 @@ func createCallExpr(t *testing.T, cmdStr string) *syntax.CallExpr {
 	return cmd
 }
 
-func TestExtractCommandName(t *testing.T) {
+// parseScript parses script as a shell script named test.sh.
+func parseScript(t *testing.T, script string) *syntax.File {
+	t.Helper()
+
+	file, err := syntax.NewParser().Parse(strings.NewReader(script), "test.sh")
+	if err != nil {
+		t.Fatalf("failed to parse script %q: %v", script, err)
+	}
+
+	return file
+}
+
+// checkScript returns the issues r finds in script.
+func checkScript(t *testing.T, r *Registry, script string) []issue.Issue {
+	t.Helper()
+
+	return r.CheckFile(&Context{Filepath: "test.sh"}, parseScript(t, script))
+}
+
+func TestRmRuleOptions(t *testing.T) {
 	tests := []struct {
 		name        string
 		command     string
 		want        string
 		description string
-		shouldSkip  bool
 	}{
 		{
-			name:        "simple command",
-			command:     "rm file.txt",
-			want:        "rm",
-			description: "Should extract basic command name",
-		},
-		{
-			name:        "command with multiple arguments",
-			command:     "rm -rf /path/to/dir",
-			want:        "rm",
-			description: "Should extract command name with multiple arguments",
-		},
-		{
-			name:        "command with variable",
-			command:     "$CMD file.txt",
-			want:        "",
-			description: "Should handle variable as command name",
-		},
-		{
-			name:        "command with path",
-			command:     "/usr/bin/rm file.txt",
-			want:        "/usr/bin/rm",
-			description: "Should handle command with full path",
-		},
-		{
-			name:        "command with environment variable",
-			command:     "ENV=value rm file.txt",
-			want:        "rm",
-			description: "Should handle command with environment variables",
-		},
-		{
-			name:        "empty command",
-			command:     "",
-			want:        "",
-			description: "Should handle empty command",
+			name:        "bundled flags",
+			command:     "rm -rf file.txt",
+			want:        "rm -rf",
+			description: "Should detect -rf",
 		},
 		{
-			name:        "command with single quotes",
-			command:     "'rm' file.txt",
-			want:        "rm",
-			description: "Should handle single-quoted command",
+			name:        "bundled flags reversed",
+			command:     "rm -fr file.txt",
+			want:        "rm -rf",
+			description: "Should detect -fr",
 		},
 		{
-			name:        "command with double quotes",
-			command:     `"rm" file.txt`,
-			want:        "rm",
-			description: "Should handle double-quoted command",
+			name:        "capital recursive",
+			command:     "rm -Rf file.txt",
+			want:        "rm -rf",
+			description: "Should treat -R as -r",
 		},
 		{
-			name:        "command with spaces",
-			command:     "   rm    file.txt",
-			want:        "rm",
-			description: "Should handle extra spaces",
-		},
-		{
-			name:        "first command before pipe",
-			command:     "rm file.txt | grep something",
-			want:        "rm",
-			description: "Should extract only the first command before pipe",
-		},
-		{
-			name:        "escaped spaces in command",
-			command:     `complex\ command file.txt`,
-			want:        "complex\\ command",
-			description: "Should handle escaped spaces in command name",
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			if tt.shouldSkip {
-				t.Skip()
-				return
-			}
-
-			cmd := createCallExpr(t, tt.command)
-			if cmd == nil && tt.want == "" {
-				return // test passed for empty command case
-			}
-			got := extractCommandName(cmd)
-			if got != tt.want {
-				t.Errorf("\nTest: %s\nDescription: %s\nCommand: %q\nExpected: %q\nGot: %q",
-					tt.name, tt.description, tt.command, tt.want, got)
-			}
-		})
-	}
-}
-
-func TestHasHazardousFlags(t *testing.T) {
-	tests := []struct {
-		name           string
-		command        string
-		hazardousFlags []string
-		want           bool
-		description    string
-	}{
-		{
-			name:           "simple hazardous flag",
-			command:        "rm -rf file.txt",
-			hazardousFlags: []string{"-rf", "-fr"},
-			want:           true,
-			description:    "Should detect -rf flag",
+			name:        "separate flags",
+			command:     "rm -r -f file.txt",
+			want:        "rm -rf",
+			description: "Should combine separate -r and -f",
 		},
 		{
-			name:           "alternative hazardous flag",
-			command:        "rm -fr file.txt",
-			hazardousFlags: []string{"-rf", "-fr"},
-			want:           true,
-			description:    "Should detect -fr flag",
+			name:        "flags after operands",
+			command:     "rm file.txt -r --force",
+			want:        "rm -rf",
+			description: "Should parse options after operands like getopt_long",
 		},
 		{
-			name:           "non-hazardous flag",
-			command:        "rm -r file.txt",
-			hazardousFlags: []string{"-rf", "-fr"},
-			want:           false,
-			description:    "Should not detect non-hazardous flags",
+			name:        "abbreviated long option",
+			command:     "rm --rec file.txt",
+			want:        "rm -r",
+			description: "Should accept unambiguous prefixes of long options",
 		},
 		{
-			name:           "multiple flags",
-			command:        "rm -r -f file.txt",
-			hazardousFlags: []string{"-rf", "-fr"},
-			want:           false,
-			description:    "Should not detect separate -r -f as hazardous",
+			name:        "quoted flags",
+			command:     `rm "-rf" file.txt`,
+			want:        "rm -rf",
+			description: "Should handle quoted flags",
 		},
 		{
-			name:           "flag with value",
-			command:        "rm --force=true file.txt",
-			hazardousFlags: []string{"-rf", "-fr"},
-			want:           false,
-			description:    "Should handle flags with values",
+			name:        "recursive without force",
+			command:     "rm -r dir",
+			want:        "rm -r",
+			description: "Should report recursive deletion without -f separately",
 		},
 		{
-			name:           "quoted flags",
-			command:        `rm "-rf" file.txt`,
-			hazardousFlags: []string{"-rf", "-fr"},
-			want:           true,
-			description:    "Should handle quoted flags",
+			name:        "force only",
+			command:     "rm -f file.txt",
+			description: "Should not flag forced deletion of single files",
 		},
 		{
-			name:           "empty flags list",
-			command:        "rm -rf file.txt",
-			hazardousFlags: []string{},
-			want:           false,
-			description:    "Should handle empty hazardous flags list",
+			name:        "force with a value",
+			command:     "rm -r --force=true file.txt",
+			want:        "rm -r",
+			description: "Should not treat --force=true as --force",
 		},
 		{
-			name:           "flag as variable",
-			command:        "rm $FLAG file.txt",
-			hazardousFlags: []string{"-rf", "-fr"},
-			want:           false,
-			description:    "Should handle variable as flag",
+			name:        "end of options",
+			command:     "rm -- -rf",
+			description: "Should treat arguments after -- as operands",
 		},
 		{
-			name:           "command with no flags",
-			command:        "rm file.txt",
-			hazardousFlags: []string{"-rf", "-fr"},
-			want:           false,
-			description:    "Should handle command with no flags",
+			name:        "flag as variable",
+			command:     "rm $FLAG file.txt",
+			description: "Should treat unknown arguments as operands",
 		},
 		{
-			name:           "case sensitive flags",
-			command:        "rm -RF file.txt",
-			hazardousFlags: []string{"-rf", "-fr"},
-			want:           false,
-			description:    "Should be case sensitive when checking flags",
+			name:        "command with no flags",
+			command:     "rm file.txt",
+			description: "Should handle command with no flags",
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			cmd := createCallExpr(t, tt.command)
-			got := hasHazardousFlags(cmd, tt.hazardousFlags)
+
+			var got string
+			if found := rmRule.Check(&Context{Filepath: "test.sh"}, cmd); len(found) > 0 {
+				got = found[0].Command
+			}
+
 			if got != tt.want {
-				t.Errorf("\nTest: %s\nDescription: %s\nCommand: %q\nExpected: %v\nGot: %v",
+				t.Errorf("\nTest: %s\nDescription: %s\nCommand: %q\nExpected: %q\nGot: %q",
 					tt.name, tt.description, tt.command, tt.want, got)
 			}
 		})
@@ -334,6 +266,22 @@ func TestCheckHazardousCommand(t *testing.T) {
 			if got.Command != "rm -rf" {
 				t.Errorf("CheckHazardousCommand().Command = %v, want %v", got.Command, "rm -rf")
 			}
+
+			if got.RuleID != "rm-rf" {
+				t.Errorf("CheckHazardousCommand().RuleID = %v, want %v", got.RuleID, "rm-rf")
+			}
+
+			if got.Severity != issue.SeverityWarning {
+				t.Errorf("CheckHazardousCommand().Severity = %v, want %v", got.Severity, issue.SeverityWarning)
+			}
+
+			if got.Message == "" || got.Fix == "" {
+				t.Errorf("CheckHazardousCommand() should explain the finding, got message %q and fix %q", got.Message, got.Fix)
+			}
+
+			if got.EndLine < got.Line || (got.EndLine == got.Line && got.EndCol <= got.Col) {
+				t.Errorf("CheckHazardousCommand() end %d,%d should be after start %d,%d", got.EndLine, got.EndCol, got.Line, got.Col)
+			}
 		})
 	}
 }
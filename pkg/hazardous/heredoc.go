@@ -0,0 +1,180 @@
+package hazardous
+
+import (
+	"strings"
+
+	"github.com/hiteshrepo/hazardous/pkg/command"
+	"mvdan.cc/sh/syntax"
+)
+
+// scriptSuffixes mark the files that hold shell scripts.
+var scriptSuffixes = []string{".sh", ".bash"}
+
+// heredoc parses the here-document or here-string of redirect r of st when
+// it is run as shell code: fed to a shell reading its standard input, piped
+// into one by cat or tee, or written to a shell script. pipe is the shell
+// reading the output of st, if any.
+func (ctx *Context) heredoc(st *syntax.Stmt, r *syntax.Redirect, pipe *call) (*script, bool) {
+	if r.Op != syntax.Hdoc && r.Op != syntax.DashHdoc && r.Op != syntax.WordHdoc {
+		return nil, false
+	}
+
+	via, runs, ok := ctx.stdinConsumer(st, pipe)
+	if !ok {
+		return nil, false
+	}
+
+	b := ctx.newCodeBuilder()
+	if r.Op == syntax.WordHdoc {
+		ok = b.word(r.Word)
+	} else {
+		ok = b.heredoc(r.Hdoc, quotedDelimiter(r.Word))
+	}
+
+	if !ok {
+		return nil, false
+	}
+
+	return b.parse(runs, via)
+}
+
+// stdinConsumer returns the shell that runs the standard input of st, and
+// where it runs.
+func (ctx *Context) stdinConsumer(st *syntax.Stmt, pipe *call) (*call, runKind, bool) {
+	cmd, ok := st.Cmd.(*syntax.CallExpr)
+	if !ok {
+		return nil, 0, false
+	}
+
+	calls := ctx.calls(cmd)
+	if len(calls) == 0 {
+		return nil, 0, false
+	}
+
+	last := calls[len(calls)-1]
+	if runs, ok := readsShell(last); ok {
+		return last, runs, true
+	}
+
+	if last.name != "cat" && last.name != "tee" {
+		return nil, 0, false
+	}
+
+	args := parseCall(last)
+	if pipe != nil && (last.name == "tee" || len(args.Operands) == 0) {
+		runs, _ := readsShell(pipe)
+		return pipe, runs, true
+	}
+
+	if writesScript(st, last, args.Operands) {
+		return &call{expr: cmd}, runsDetached, true
+	}
+
+	return nil, 0, false
+}
+
+// stdinShell returns the shell run by st that reads shell code from its
+// standard input, e.g. the bash of `cat <<EOF | sudo bash`.
+func (ctx *Context) stdinShell(st *syntax.Stmt) *call {
+	cmd, ok := st.Cmd.(*syntax.CallExpr)
+	if !ok {
+		return nil
+	}
+
+	calls := ctx.calls(cmd)
+	if len(calls) == 0 {
+		return nil
+	}
+
+	last := calls[len(calls)-1]
+	if _, ok := readsShell(last); !ok {
+		return nil
+	}
+
+	return last
+}
+
+// readsShell reports whether c runs the shell code on its standard input,
+// and where.
+func readsShell(c *call) (runKind, bool) {
+	switch {
+	case shells[c.name]:
+		args := parseCall(c)
+		if args.Has("command") || (len(args.Operands) > 0 && !args.Has("stdin")) || noexec(c, args) {
+			return 0, false
+		}
+
+		return runsInSubshell, true
+
+	case c.name == "ssh":
+		return runsDetached, len(parseCall(c).Operands) == 1
+	}
+
+	return 0, false
+}
+
+// noexec reports whether the shell c only checks the syntax of the code it
+// reads, as with bash -n or bash -o noexec, and runs none of it.
+func noexec(c *call, args *command.Parsed) bool {
+	for _, m := range args.Options {
+		switch m.Option.Name {
+		case "set":
+			if value, _ := literalWord(c.args[m.Index]); strings.HasPrefix(value, "-") && strings.ContainsRune(value[1:], 'n') {
+				return true
+			}
+		case "option":
+			if m.Value == "noexec" {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// writesScript reports whether st writes its input to a shell script, with
+// an output redirect or, for tee, an operand.
+func writesScript(st *syntax.Stmt, c *call, operands []int) bool {
+	var targets []*syntax.Word
+
+	for _, r := range st.Redirs {
+		switch r.Op {
+		case syntax.RdrOut, syntax.AppOut, syntax.ClbOut, syntax.RdrAll, syntax.AppAll:
+			targets = append(targets, r.Word)
+		}
+	}
+
+	if c.name == "tee" {
+		for _, i := range operands {
+			targets = append(targets, c.args[i])
+		}
+	}
+
+	for _, word := range targets {
+		value, _ := literalWord(word)
+		for _, suffix := range scriptSuffixes {
+			if strings.HasSuffix(value, suffix) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// quotedDelimiter reports whether the delimiter of a here-document is quoted,
+// which leaves its body unexpanded.
+func quotedDelimiter(word *syntax.Word) bool {
+	for _, part := range word.Parts {
+		switch p := part.(type) {
+		case *syntax.SglQuoted, *syntax.DblQuoted:
+			return true
+		case *syntax.Lit:
+			if strings.Contains(p.Value, `\`) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,141 @@
+package hazardous
+
+import (
+	"testing"
+
+	"github.com/hiteshrepo/hazardous/pkg/issue"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHeredocs(t *testing.T) {
+	tests := []struct {
+		name        string
+		script      string
+		wantRule    string
+		wantCommand string
+		wantMessage string
+		wantLine    uint
+		wantCol     uint
+	}{
+		{
+			name:        "piped into a shell",
+			script:      "cat <<EOF | bash\nset -e\nrm -rf /\nEOF",
+			wantRule:    "rm-rf",
+			wantCommand: "rm -rf",
+			wantMessage: "rm -rf deletes the entire filesystem (/)",
+			wantLine:    3,
+			wantCol:     1,
+		},
+		{
+			name:        "read by a shell",
+			script:      "bash <<'EOF'\n  rm -rf ~/\nEOF",
+			wantRule:    "rm-rf",
+			wantCommand: "rm -rf",
+			wantMessage: "rm -rf deletes the home directory (~/)",
+			wantLine:    2,
+			wantCol:     3,
+		},
+		{
+			name:        "tabs stripped",
+			script:      "sh -s <<-EOF\n\t\trm -rf /etc\n\tEOF",
+			wantRule:    "rm-rf",
+			wantCommand: "rm -rf",
+			wantMessage: "rm -rf deletes the system directory /etc",
+			wantLine:    2,
+			wantCol:     3,
+		},
+		{
+			name:        "piped into sudo",
+			script:      "cat <<'EOF' | sudo bash\nrm -rf /opt/app\nEOF",
+			wantRule:    "rm-rf",
+			wantCommand: "sudo rm -rf",
+			wantMessage: "rm -rf deletes files recursively without asking for confirmation, running as root through sudo",
+			wantLine:    2,
+			wantCol:     1,
+		},
+		{
+			name:        "here-string",
+			script:      "bash <<< 'rm -rf /'",
+			wantRule:    "rm-rf",
+			wantCommand: "rm -rf",
+			wantMessage: "rm -rf deletes the entire filesystem (/)",
+			wantLine:    1,
+			wantCol:     11,
+		},
+		{
+			name:        "expanded by the enclosing shell",
+			script:      "cat > deploy.sh <<EOF\nrm -rf \"$OUT\"/*\nEOF",
+			wantRule:    "empty-var-path",
+			wantCommand: "rm",
+			wantMessage: `$OUT is never assigned, so rm may be given "/*"`,
+			wantLine:    2,
+			wantCol:     8,
+		},
+		{
+			name:        "written to a script unexpanded",
+			script:      "OUT=build\ncat > deploy.sh <<'EOF'\nOUT=$(mktemp -d)\nrm -rf \"$OUT\"/*\nEOF",
+			wantRule:    "empty-var-path",
+			wantCommand: "rm",
+			wantMessage: `$OUT may be empty: assigned from command output (line 3), so rm may be given "/*"`,
+			wantLine:    4,
+			wantCol:     8,
+		},
+		{
+			name:        "escaped expansion",
+			script:      "cat <<EOF | sh\nrm -rf \\$WORK/*\nEOF",
+			wantRule:    "empty-var-path",
+			wantCommand: "rm",
+			wantMessage: `$WORK is never assigned, so rm may be given "/*"`,
+			wantLine:    2,
+			wantCol:     9,
+		},
+		{
+			name:        "written by tee",
+			script:      "tee -a setup.bash <<'EOF' >/dev/null\nrm -rf /\nEOF",
+			wantRule:    "rm-rf",
+			wantCommand: "rm -rf",
+			wantMessage: "rm -rf deletes the entire filesystem (/)",
+			wantLine:    2,
+			wantCol:     1,
+		},
+		{
+			name:        "ssh",
+			script:      "ssh deploy@host <<'EOF'\nrm -rf /srv\nEOF",
+			wantRule:    "rm-rf",
+			wantCommand: "rm -rf",
+			wantMessage: "rm -rf deletes the system directory /srv",
+			wantLine:    2,
+			wantCol:     1,
+		},
+		{name: "written to a text file", script: "cat <<EOF > notes.txt\nrm -rf /\nEOF"},
+		{name: "piped into another command", script: "cat <<EOF | grep rm\nrm -rf /\nEOF"},
+		{name: "another interpreter", script: "python3 <<EOF\nrm -rf /\nEOF"},
+		{name: "shell running a script file", script: "bash setup.sh <<EOF\nrm -rf /\nEOF"},
+		{name: "not shell code", script: "cat <<EOF | sh\nrm -rf ((\nEOF"},
+		{name: "empty piped into a shell", script: "cat <<EOF | bash\nEOF"},
+		{name: "empty read by a shell", script: "bash <<EOF\nEOF"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var issues []issue.Issue
+			for _, found := range checkScript(t, DefaultRegistry, tt.script) {
+				if len(tt.wantRule) == 0 || found.RuleID == tt.wantRule {
+					issues = append(issues, found)
+				}
+			}
+
+			if len(tt.wantRule) == 0 {
+				assert.Empty(t, issues)
+				return
+			}
+
+			require.Len(t, issues, 1)
+			assert.Equal(t, tt.wantCommand, issues[0].Command)
+			assert.Equal(t, tt.wantMessage, issues[0].Message)
+			assert.Equal(t, tt.wantLine, issues[0].Line)
+			assert.Equal(t, tt.wantCol, issues[0].Col)
+		})
+	}
+}
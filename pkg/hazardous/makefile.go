@@ -0,0 +1,96 @@
+package hazardous
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/hiteshrepo/hazardous/pkg/issue"
+	"github.com/hiteshrepo/hazardous/pkg/makefile"
+	"mvdan.cc/sh/syntax"
+)
+
+// CheckMakefile parses every recipe of a Makefile as shell and runs the
+// enabled rules on it, reporting positions in the Makefile itself. Recipes
+// that are not valid shell are skipped and returned as errors alongside the
+// issues found in the other recipes.
+func (r *Registry) CheckMakefile(filepath string, mf *makefile.File) ([]issue.Issue, error) {
+	var (
+		issues []issue.Issue
+		errs   []error
+	)
+
+	eval := makefile.NewEvaluator(mf)
+
+	for _, rule := range mf.Rules {
+		for _, recipe := range rule.Recipes {
+			file, err := syntax.NewParser().Parse(strings.NewReader(recipe.Shell), filepath)
+			if err != nil {
+				pos := recipe.Position(0)
+				errs = append(errs, fmt.Errorf("parsing recipe at %s:%d: %w", filepath, pos.Line, err))
+				continue
+			}
+
+			ctx := &Context{
+				Filepath:    filepath,
+				MapPosition: recipePositions(recipe),
+				vars:        recipeVariables(eval, rule, recipe, file),
+			}
+
+			issues = append(issues, r.CheckFile(ctx, file)...)
+		}
+	}
+
+	return issues, errors.Join(errs...)
+}
+
+func recipePositions(recipe *makefile.Recipe) PositionMapper {
+	return func(pos syntax.Pos) (uint, uint) {
+		p := recipe.Position(int(pos.Offset()))
+		return uint(p.Line), uint(p.Col)
+	}
+}
+
+// recipeVariables runs the variable analysis over a recipe. The expansions
+// standing in for make references are bound to the value make gives them.
+func recipeVariables(eval *makefile.Evaluator, rule *makefile.Rule, recipe *makefile.Recipe, file *syntax.File) *varTable {
+	vars := trackVariables(file)
+
+	refs := make(map[uint]makefile.Reference, len(recipe.Refs))
+	for _, ref := range recipe.Refs {
+		refs[uint(ref.Offset)] = ref
+	}
+
+	for pe := range vars.uses {
+		if ref, ok := refs[pe.Pos().Offset()]; ok {
+			vars.uses[pe] = makeBinding(ref, eval.Reference(ref, rule))
+		}
+	}
+
+	vars.link()
+
+	return vars
+}
+
+func makeBinding(ref makefile.Reference, val makefile.Value) binding {
+	b := binding{state: varSet, expr: ref.Text, line: uint(val.Line), how: val.Reason}
+
+	if val.Emptiness == makefile.NonEmpty && !strings.Contains(val.Text, "$") {
+		b.value = val.Text
+	}
+
+	switch val.Emptiness {
+	case makefile.Empty:
+		b.state = varEmpty
+	case makefile.MaybeEmpty:
+		b.state = varMaybeEmpty
+	}
+
+	if len(ref.Name) > 0 {
+		b.fix = fmt.Sprintf("give %s a non-empty value or guard the recipe with $(if %s,,$(error %s is empty))", ref.Name, b.expr, ref.Name)
+	} else {
+		b.fix = fmt.Sprintf("check that %s is not empty before using it in the recipe", b.expr)
+	}
+
+	return b
+}
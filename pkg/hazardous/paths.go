@@ -0,0 +1,236 @@
+package hazardous
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/hiteshrepo/hazardous/pkg/command"
+	"github.com/hiteshrepo/hazardous/pkg/issue"
+	"mvdan.cc/sh/syntax"
+)
+
+// pathClass is the blast radius of deleting a path, from least to most
+// severe.
+type pathClass int
+
+const (
+	pathRelative pathClass = iota
+	pathTemp
+	// pathUnknown is an absolute path elsewhere or a path that cannot be
+	// resolved statically.
+	pathUnknown
+	pathOutside
+	pathCurrent
+	pathHome
+	pathSystem
+	pathProtected
+	pathRoot
+)
+
+// systemDirs are the top-level directories of Unix-like systems.
+var systemDirs = map[string]bool{
+	"/bin": true, "/boot": true, "/dev": true, "/etc": true, "/home": true,
+	"/lib": true, "/lib32": true, "/lib64": true, "/media": true, "/mnt": true,
+	"/opt": true, "/proc": true, "/root": true, "/run": true, "/sbin": true,
+	"/srv": true, "/sys": true, "/tmp": true, "/usr": true, "/var": true,
+	"/snap": true, "/Applications": true, "/Library": true, "/System": true,
+	"/Users": true, "/Volumes": true, "/private": true,
+}
+
+// tempDirs hold temporary files, which are safe to delete below them.
+var tempDirs = []string{"/tmp", "/var/tmp", "/dev/shm", "$TMPDIR"}
+
+// Protect adds paths that must never be deleted. Deleting one of them, one of
+// their parents or their contents is reported as an error.
+func (r *Registry) Protect(paths ...string) {
+	for _, p := range paths {
+		if p = strings.TrimSpace(p); len(p) > 0 {
+			r.protected = append(r.protected, path.Clean(p))
+		}
+	}
+}
+
+// classifyPath returns the blast radius of deleting p, a path resolved from a
+// command argument. A last component of * or .* stands for the contents of
+// its directory, while other globs such as *.o only match some of its entries.
+func classifyPath(p string, protected []string) pathClass {
+	dir := path.Clean(p)
+
+	base := path.Base(dir)
+	glob := base == "*" || base == ".*"
+	if glob {
+		dir = path.Dir(dir)
+	}
+
+	if dir == "/" {
+		return pathRoot
+	}
+
+	for _, prot := range protected {
+		if dir == prot || strings.HasPrefix(dir, prot+"/") || strings.HasPrefix(prot, dir+"/") {
+			return pathProtected
+		}
+	}
+
+	switch {
+	case dir == "~" || dir == "$HOME" || isUserHome(dir):
+		return pathHome
+	case systemDirs[dir] && !(glob && dir == "/tmp"):
+		return pathSystem
+	case dir == ".":
+		return pathCurrent
+	case dir == ".." || strings.HasPrefix(dir, "../"):
+		return pathOutside
+	}
+
+	for _, tmp := range tempDirs {
+		if strings.HasPrefix(dir, tmp+"/") || glob && dir == tmp {
+			return pathTemp
+		}
+	}
+
+	if path.IsAbs(dir) || strings.HasPrefix(dir, "~") || strings.HasPrefix(dir, "$") {
+		return pathUnknown
+	}
+
+	return pathRelative
+}
+
+// isUserHome reports whether dir is the home directory of a user, e.g.
+// /home/alice or /Users/alice.
+func isUserHome(dir string) bool {
+	parent := path.Dir(dir)
+	return parent == "/home" || parent == "/Users"
+}
+
+// severity returns the severity of deleting a path of class c.
+func (c pathClass) severity() issue.Severity {
+	switch c {
+	case pathRelative, pathTemp:
+		return issue.SeverityInfo
+	case pathRoot, pathProtected, pathSystem, pathHome:
+		return issue.SeverityError
+	default:
+		return issue.SeverityWarning
+	}
+}
+
+// describe completes "rm -rf deletes ..." for a target of class c written as
+// arg.
+func (c pathClass) describe(arg string) string {
+	switch c {
+	case pathRoot:
+		return fmt.Sprintf("the entire filesystem (%s)", arg)
+	case pathProtected:
+		return fmt.Sprintf("the protected path %s", arg)
+	case pathSystem:
+		return fmt.Sprintf("the system directory %s", arg)
+	case pathHome:
+		return fmt.Sprintf("the home directory (%s)", arg)
+	case pathCurrent:
+		return fmt.Sprintf("everything in the current directory (%s)", arg)
+	case pathOutside:
+		return fmt.Sprintf("%s outside the repository", arg)
+	case pathTemp:
+		return fmt.Sprintf("the temporary path %s", arg)
+	case pathRelative:
+		return fmt.Sprintf("%s inside the repository", arg)
+	default:
+		return ""
+	}
+}
+
+// pathParams are the variables that stand for a known place when they start
+// a path, and how the path is then written.
+var pathParams = map[string]string{"HOME": "~", "PWD": ".", "TMPDIR": "$TMPDIR"}
+
+// wordPath resolves a command argument to a path, substituting variables
+// holding constants. It returns false when the path depends on values only
+// known at run time.
+func (ctx *Context) wordPath(word *syntax.Word) (string, bool) {
+	parts := flattenParts(word.Parts)
+
+	var start string
+	if len(parts) > 0 {
+		if p, ok := parts[0].(*syntax.ParamExp); ok && plainParam(p) && len(pathParams[p.Param.Value]) > 0 {
+			start, parts = pathParams[p.Param.Value], parts[1:]
+		}
+	}
+
+	rest, ok := ctx.constantWord(&syntax.Word{Parts: parts})
+	if !ok {
+		return "", false
+	}
+
+	return start + rest, len(start)+len(rest) > 0
+}
+
+// classifyTargets classifies the operands of a command and returns the most
+// severe class along with the operand it was found in. Operands given at run
+// time by a wrapper such as xargs count as unresolved.
+func (ctx *Context) classifyTargets(c *call, args *command.Parsed) (pathClass, *syntax.Word) {
+	var (
+		worst  pathClass
+		target *syntax.Word
+		seen   = c.input
+	)
+
+	if c.input {
+		worst = pathUnknown
+	}
+
+	for _, word := range operandWords(c, args) {
+		class := pathUnknown
+		if p, ok := ctx.wordPath(word); ok && !c.isPlaceholder(word) {
+			class = classifyPath(p, ctx.protected)
+		}
+
+		if !seen || class > worst {
+			worst, target, seen = class, word, true
+		}
+	}
+
+	return worst, target
+}
+
+// classifyRm sets the severity and message of an rm finding from the blast
+// radius of its targets. Without -f, rm prompts before removing write-protected
+// files, which the label and message reflect.
+func classifyRm(ctx *Context, c *call, args *command.Parsed, found *issue.Issue) bool {
+	if !args.Has("force") {
+		found.Command = "rm -r"
+		found.Message = "rm -r deletes files recursively"
+		found.Fix = `guard the target path, e.g. rm -r "${DIR:?}/build"`
+	}
+
+	class, target := ctx.classifyTargets(c, args)
+	if target == nil || class == pathUnknown {
+		return true
+	}
+
+	found.Severity = class.severity()
+	found.Message = fmt.Sprintf("%s deletes %s", found.Command, class.describe(ctx.wordText(target)))
+
+	return true
+}
+
+// wordText returns a word as written, without its quotes.
+func (ctx *Context) wordText(word *syntax.Word) string {
+	var sb strings.Builder
+
+	for _, part := range flattenParts(word.Parts) {
+		switch p := part.(type) {
+		case *syntax.Lit:
+			sb.WriteString(p.Value)
+		case *syntax.SglQuoted:
+			sb.WriteString(p.Value)
+		case *syntax.ParamExp:
+			sb.WriteString(ctx.expansion(p))
+		default:
+			sb.WriteString("...")
+		}
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,137 @@
+package hazardous
+
+import (
+	"testing"
+
+	"github.com/hiteshrepo/hazardous/pkg/issue"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClassifyPath(t *testing.T) {
+	protected := []string{"/srv/data"}
+
+	tests := []struct {
+		path string
+		want pathClass
+	}{
+		{path: "/", want: pathRoot},
+		{path: "/*", want: pathRoot},
+		{path: "//", want: pathRoot},
+		{path: "/etc", want: pathSystem},
+		{path: "/usr/", want: pathSystem},
+		{path: "/var/*", want: pathSystem},
+		{path: "/tmp", want: pathSystem},
+		{path: "~", want: pathHome},
+		{path: "~/", want: pathHome},
+		{path: "~/*", want: pathHome},
+		{path: "/home/alice", want: pathHome},
+		{path: "/Users/alice/.*", want: pathHome},
+		{path: "*", want: pathCurrent},
+		{path: ".*", want: pathCurrent},
+		{path: "./*", want: pathCurrent},
+		{path: ".", want: pathCurrent},
+		{path: "..", want: pathOutside},
+		{path: "../shared/build", want: pathOutside},
+		{path: "build", want: pathRelative},
+		{path: "./dist/", want: pathRelative},
+		{path: "build/*.o", want: pathRelative},
+		{path: "*.o", want: pathRelative},
+		{path: "build-*", want: pathRelative},
+		{path: "./*.pyc", want: pathRelative},
+		{path: "/tmp/build", want: pathTemp},
+		{path: "/tmp/*", want: pathTemp},
+		{path: "/var/tmp/cache", want: pathTemp},
+		{path: "$TMPDIR/work", want: pathTemp},
+		{path: "/opt/app/build", want: pathUnknown},
+		{path: "~/.cache/app", want: pathUnknown},
+		{path: "/srv/data", want: pathProtected},
+		{path: "/srv/data/*", want: pathProtected},
+		{path: "/srv/data/foo", want: pathProtected},
+		{path: "/srv", want: pathProtected},
+		{path: "/srv/database", want: pathUnknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			assert.Equal(t, tt.want, classifyPath(tt.path, protected))
+		})
+	}
+}
+
+func TestRmTargetSeverity(t *testing.T) {
+	tests := []struct {
+		name         string
+		script       string
+		wantSeverity issue.Severity
+		wantMessage  string
+	}{
+		{
+			name:         "root",
+			script:       "rm -rf /",
+			wantSeverity: issue.SeverityError,
+			wantMessage:  "rm -rf deletes the entire filesystem (/)",
+		},
+		{
+			name:         "most severe target wins",
+			script:       "rm -rf build ~/",
+			wantSeverity: issue.SeverityError,
+			wantMessage:  "rm -rf deletes the home directory (~/)",
+		},
+		{
+			name:         "home variable",
+			script:       `rm -rf "$HOME"/*`,
+			wantSeverity: issue.SeverityError,
+			wantMessage:  "rm -rf deletes the home directory ($HOME/*)",
+		},
+		{
+			name:         "constant variable",
+			script:       "PREFIX=/usr\nrm -rf \"$PREFIX\"",
+			wantSeverity: issue.SeverityError,
+			wantMessage:  "rm -rf deletes the system directory $PREFIX",
+		},
+		{
+			name:         "end of options",
+			script:       "rm -rf -- -weird-name",
+			wantSeverity: issue.SeverityInfo,
+			wantMessage:  "rm -rf deletes -weird-name inside the repository",
+		},
+		{
+			name:         "unresolved target keeps the default",
+			script:       "rm -rf build \"$1\"",
+			wantSeverity: issue.SeverityWarning,
+			wantMessage:  "rm -rf deletes files recursively without asking for confirmation",
+		},
+		{
+			name:         "protected path",
+			script:       "rm -rf /srv",
+			wantSeverity: issue.SeverityError,
+			wantMessage:  "rm -rf deletes the protected path /srv",
+		},
+		{
+			name:         "inside a protected path",
+			script:       "rm -rf /srv/data/foo",
+			wantSeverity: issue.SeverityError,
+			wantMessage:  "rm -rf deletes the protected path /srv/data/foo",
+		},
+		{
+			name:         "glob matching some files",
+			script:       "rm -rf *.o build-*",
+			wantSeverity: issue.SeverityInfo,
+			wantMessage:  "rm -rf deletes *.o inside the repository",
+		},
+	}
+
+	r := NewRegistry(rmRule)
+	r.Protect("/srv/data/", " ")
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issues := checkScript(t, r, tt.script)
+			require.Len(t, issues, 1)
+
+			assert.Equal(t, tt.wantSeverity, issues[0].Severity)
+			assert.Equal(t, tt.wantMessage, issues[0].Message)
+		})
+	}
+}
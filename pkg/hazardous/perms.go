@@ -0,0 +1,287 @@
+package hazardous
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hiteshrepo/hazardous/pkg/command"
+	"github.com/hiteshrepo/hazardous/pkg/issue"
+)
+
+var chmodWorldWritableRule = &commandRule{
+	id:          "chmod-world-writable",
+	description: "chmod making files writable by every user",
+	severity:    issue.SeverityWarning,
+	label:       "chmod",
+	message:     "chmod makes files writable by every user",
+	fix:         "grant write access to the owner or a group instead, e.g. chmod 775 or chmod g+w",
+	commands:    []string{"chmod"},
+	classify:    classifyWorldWritable,
+}
+
+var chmodSetIDRule = &commandRule{
+	id:          "chmod-setuid",
+	description: "chmod setting the setuid or setgid bit",
+	severity:    issue.SeverityWarning,
+	label:       "chmod",
+	message:     "chmod sets the setuid or setgid bit",
+	fix:         "drop the setuid and setgid bits and grant the privileges needed with sudo rules or file capabilities",
+	commands:    []string{"chmod"},
+	classify:    classifySetID,
+}
+
+var chmodRecursiveRule = &commandRule{
+	id:          "chmod-recursive",
+	description: "recursive chmod of system or home directories",
+	severity:    issue.SeverityError,
+	label:       "chmod -R",
+	message:     "chmod -R changes the permissions of every file below a directory",
+	fix:         "change the permissions of the files that need it only",
+	commands:    []string{"chmod"},
+	options:     []string{"recursive"},
+	classify:    classifyRecursiveChmod,
+}
+
+var chownRecursiveRule = &commandRule{
+	id:          "chown-recursive",
+	description: "recursive chown or chgrp of system or home directories",
+	severity:    issue.SeverityError,
+	label:       "chown -R",
+	message:     "chown -R changes the owner of every file below a directory",
+	fix:         "change the owner of the files that need it only",
+	commands:    []string{"chown", "chgrp"},
+	options:     []string{"recursive"},
+	classify:    classifyRecursiveChown,
+}
+
+// modeChange is what a chmod mode grants.
+type modeChange struct {
+	worldWritable bool
+	setuid        bool
+	setgid        bool
+	sticky        bool
+}
+
+// parseMode parses a numeric mode such as 4755 or a symbolic one such as
+// u+s,o+w. Symbolic modes without a user class, e.g. +w, leave the bits set in
+// the umask alone, which keeps files from becoming writable by others under
+// the usual umask of 022. Modes setting the sticky bit along with write
+// access for others, e.g. 1777, are not world-writable: like /tmp, every
+// user may add files but only remove their own.
+func parseMode(mode string) (modeChange, bool) {
+	var m modeChange
+
+	if len(mode) > 0 && strings.Trim(mode, "01234567") == "" {
+		bits, err := strconv.ParseUint(mode, 8, 32)
+		if err != nil || bits > 0o7777 {
+			return m, false
+		}
+
+		m.worldWritable = bits&0o002 != 0
+		m.setuid = bits&0o4000 != 0
+		m.setgid = bits&0o2000 != 0
+		m.sticky = bits&0o1000 != 0
+		m.worldWritable = m.worldWritable && !m.sticky
+
+		return m, true
+	}
+
+	for _, clause := range strings.Split(mode, ",") {
+		i := 0
+		for i < len(clause) && strings.IndexByte("ugoa", clause[i]) >= 0 {
+			i++
+		}
+
+		who := clause[:i]
+		if i == len(clause) {
+			return m, false
+		}
+
+		for i < len(clause) {
+			op := clause[i]
+			if strings.IndexByte("+-=", op) < 0 {
+				return m, false
+			}
+
+			start := i + 1
+			for i = start; i < len(clause) && strings.IndexByte("rwxXstugo", clause[i]) >= 0; i++ {
+			}
+
+			if op == '-' {
+				continue
+			}
+
+			perms := clause[start:i]
+			if strings.Contains(perms, "w") && strings.ContainsAny(who, "oa") {
+				m.worldWritable = true
+			}
+
+			if strings.Contains(perms, "s") {
+				m.setuid = m.setuid || who == "" || strings.ContainsAny(who, "ua")
+				m.setgid = m.setgid || who == "" || strings.ContainsAny(who, "ga")
+			}
+
+			if strings.Contains(perms, "t") {
+				m.sticky = m.sticky || who == "" || strings.ContainsAny(who, "oa")
+			}
+		}
+	}
+
+	m.worldWritable = m.worldWritable && !m.sticky
+
+	return m, true
+}
+
+// chmodMode returns the mode given to chmod as written, and what it grants.
+// Modes given as an option, e.g. -w, only take permissions away.
+func (ctx *Context) chmodMode(c *call, args *command.Parsed) (string, modeChange, bool) {
+	if args.Has("reference") || len(args.Operands) == 0 {
+		return "", modeChange{}, false
+	}
+
+	if m, ok := args.Lookup("mode"); ok {
+		value, _ := literalWord(c.args[m.Index])
+		return value, modeChange{}, false
+	}
+
+	word := c.args[args.Operands[0]]
+
+	value, ok := ctx.wordPath(word)
+	if !ok {
+		return ctx.wordText(word), modeChange{}, false
+	}
+
+	change, ok := parseMode(value)
+
+	return ctx.wordText(word), change, ok
+}
+
+// permsTargets classifies the files a chmod, chown or chgrp call changes,
+// skipping the mode or owner operand unless the call copies it from a
+// reference file.
+func (ctx *Context) permsTargets(c *call, args *command.Parsed) (pathClass, string) {
+	targets := *args
+	if !args.Has("reference") && !(c.name == "chmod" && args.Has("mode")) && len(targets.Operands) > 0 {
+		targets.Operands = targets.Operands[1:]
+	}
+
+	class, target := ctx.classifyTargets(c, &targets)
+	if target == nil {
+		return class, "files"
+	}
+
+	text := ctx.wordText(target)
+	if desc := class.describe(text); len(desc) > 0 {
+		text = desc
+	}
+
+	if args.Has("recursive") && class != pathRoot && class != pathCurrent {
+		text += " and everything in it"
+	}
+
+	return class, text
+}
+
+// chmodLabel returns the label of a chmod finding, e.g. chmod -R 777.
+func chmodLabel(args *command.Parsed, mode string) string {
+	label := "chmod"
+	if args.Has("recursive") {
+		label += " -R"
+	}
+
+	return label + " " + mode
+}
+
+// classifyWorldWritable keeps chmod calls granting write access to every
+// user, graded by the files they change.
+func classifyWorldWritable(ctx *Context, c *call, args *command.Parsed, found *issue.Issue) bool {
+	mode, change, ok := ctx.chmodMode(c, args)
+	if !ok || !change.worldWritable {
+		return false
+	}
+
+	class, target := ctx.permsTargets(c, args)
+
+	found.Command = chmodLabel(args, mode)
+	found.Message = fmt.Sprintf("%s makes %s writable by every user", found.Command, target)
+	found.Severity = max(class.severity(), issue.SeverityWarning)
+
+	return true
+}
+
+// classifySetID keeps chmod calls setting the setuid or setgid bit, which
+// are errors on system files, recursively or along with write access for
+// every user.
+func classifySetID(ctx *Context, c *call, args *command.Parsed, found *issue.Issue) bool {
+	mode, change, ok := ctx.chmodMode(c, args)
+	if !ok || !change.setuid && !change.setgid {
+		return false
+	}
+
+	class, target := ctx.permsTargets(c, args)
+
+	// the setgid bit on a directory passes its group to new files instead,
+	// and the setuid bit is ignored there
+	var bits, effect string
+
+	switch {
+	case change.setuid && change.setgid:
+		bits, effect = "the setuid and setgid bits", "which then runs with the privileges of its owner and group if it is a program, or passes its group to new files if it is a directory"
+	case change.setuid:
+		bits, effect = "the setuid bit", "which then runs with the privileges of its owner"
+	default:
+		bits, effect = "the setgid bit", "which then runs with the privileges of its group if it is a program, or passes its group to new files if it is a directory"
+	}
+
+	found.Command = chmodLabel(args, mode)
+	found.Message = fmt.Sprintf("%s sets %s on %s, %s", found.Command, bits, target, effect)
+
+	if change.worldWritable || args.Has("recursive") || class.severity() == issue.SeverityError {
+		found.Severity = issue.SeverityError
+	}
+
+	return true
+}
+
+// classifyRecursiveChmod keeps recursive chmod calls on system or home
+// directories that the other chmod rules do not report.
+func classifyRecursiveChmod(ctx *Context, c *call, args *command.Parsed, found *issue.Issue) bool {
+	mode, change, _ := ctx.chmodMode(c, args)
+	if change.worldWritable || change.setuid || change.setgid {
+		return false
+	}
+
+	class, target := ctx.permsTargets(c, args)
+	if class.severity() != issue.SeverityError {
+		return false
+	}
+
+	found.Command = chmodLabel(args, mode)
+	found.Message = fmt.Sprintf("%s changes the permissions of %s", found.Command, target)
+
+	return true
+}
+
+// classifyRecursiveChown keeps recursive chown and chgrp calls on system or
+// home directories.
+func classifyRecursiveChown(ctx *Context, c *call, args *command.Parsed, found *issue.Issue) bool {
+	class, target := ctx.permsTargets(c, args)
+	if class.severity() != issue.SeverityError {
+		return false
+	}
+
+	what := "owner"
+	if c.name == "chgrp" {
+		what = "group"
+	}
+
+	found.Command = c.name + " -R"
+	found.Message = fmt.Sprintf("%s changes the %s of %s", found.Command, what, target)
+
+	if !args.Has("reference") && len(args.Operands) > 0 {
+		found.Message += " to " + ctx.wordText(c.args[args.Operands[0]])
+	}
+
+	return true
+}
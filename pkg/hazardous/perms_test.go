@@ -0,0 +1,174 @@
+package hazardous
+
+import (
+	"testing"
+
+	"github.com/hiteshrepo/hazardous/pkg/issue"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPermsRules(t *testing.T) {
+	tests := []struct {
+		name         string
+		script       string
+		wantRule     string
+		wantCommand  string
+		wantMessage  string
+		wantSeverity issue.Severity
+	}{
+		{
+			name:         "world-writable web root",
+			script:       "chmod -R 777 /var/www",
+			wantRule:     "chmod-world-writable",
+			wantCommand:  "chmod -R 777",
+			wantMessage:  "chmod -R 777 makes /var/www and everything in it writable by every user",
+			wantSeverity: issue.SeverityWarning,
+		},
+		{
+			name:         "world-writable system directory",
+			script:       "chmod o+w /etc",
+			wantRule:     "chmod-world-writable",
+			wantCommand:  "chmod o+w",
+			wantMessage:  "chmod o+w makes the system directory /etc writable by every user",
+			wantSeverity: issue.SeverityError,
+		},
+		{
+			name:         "world-writable file in the repository",
+			script:       "chmod a=rwx build/out.log",
+			wantRule:     "chmod-world-writable",
+			wantCommand:  "chmod a=rwx",
+			wantMessage:  "chmod a=rwx makes build/out.log inside the repository writable by every user",
+			wantSeverity: issue.SeverityWarning,
+		},
+		{
+			name:         "setuid",
+			script:       "chmod u+s ./helper",
+			wantRule:     "chmod-setuid",
+			wantCommand:  "chmod u+s",
+			wantMessage:  "chmod u+s sets the setuid bit on ./helper inside the repository, which then runs with the privileges of its owner",
+			wantSeverity: issue.SeverityWarning,
+		},
+		{
+			name:         "setgid on a system binary",
+			script:       "sudo chmod 2755 /usr/bin/tool",
+			wantRule:     "chmod-setuid",
+			wantCommand:  "sudo chmod 2755",
+			wantMessage:  "chmod 2755 sets the setgid bit on /usr/bin/tool, which then runs with the privileges of its group if it is a program, or passes its group to new files if it is a directory, running as root through sudo",
+			wantSeverity: issue.SeverityError,
+		},
+		{
+			name:         "recursive chmod of the home directory",
+			script:       "chmod -R 700 ~",
+			wantRule:     "chmod-recursive",
+			wantCommand:  "chmod -R 700",
+			wantMessage:  "chmod -R 700 changes the permissions of the home directory (~) and everything in it",
+			wantSeverity: issue.SeverityError,
+		},
+		{
+			name:         "permissions taken away recursively",
+			script:       "chmod -R o-w /",
+			wantRule:     "chmod-recursive",
+			wantCommand:  "chmod -R o-w",
+			wantMessage:  "chmod -R o-w changes the permissions of the entire filesystem (/)",
+			wantSeverity: issue.SeverityError,
+		},
+		{
+			name:         "recursive chown of the filesystem",
+			script:       "chown -R $USER /",
+			wantRule:     "chown-recursive",
+			wantCommand:  "chown -R",
+			wantMessage:  "chown -R changes the owner of the entire filesystem (/) to $USER",
+			wantSeverity: issue.SeverityError,
+		},
+		{
+			name:         "recursive chgrp of a system directory",
+			script:       "chgrp --recursive staff /usr",
+			wantRule:     "chown-recursive",
+			wantCommand:  "chgrp -R",
+			wantMessage:  "chgrp -R changes the group of the system directory /usr and everything in it to staff",
+			wantSeverity: issue.SeverityError,
+		},
+		{
+			name:   "executable bit",
+			script: "chmod +x ./install.sh",
+		},
+		{
+			name:   "write access without a user class",
+			script: "chmod +w config.yml",
+		},
+		{
+			name:   "recursive chmod in the repository",
+			script: "chmod -R 755 dist",
+		},
+		{
+			name:   "recursive chown in the repository",
+			script: "chown -R app:app ./data",
+		},
+		{
+			name:   "mode from a reference file",
+			script: "chmod --reference=/etc/passwd /etc/shadow",
+		},
+		{
+			name:   "shared directory with the sticky bit",
+			script: "chmod 1777 /tmp/shared",
+		},
+		{
+			name:   "sticky bit added with write access",
+			script: "chmod +t,o+w /srv/uploads",
+		},
+		{
+			name:   "mode only known at run time",
+			script: `chmod "$MODE" /etc`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issues := checkScript(t, NewRegistry(chmodWorldWritableRule, chmodSetIDRule, chmodRecursiveRule, chownRecursiveRule), tt.script)
+			if len(tt.wantCommand) == 0 {
+				assert.Empty(t, issues)
+				return
+			}
+
+			require.Len(t, issues, 1)
+			assert.Equal(t, tt.wantRule, issues[0].RuleID)
+			assert.Equal(t, tt.wantCommand, issues[0].Command)
+			assert.Equal(t, tt.wantMessage, issues[0].Message)
+			assert.Equal(t, tt.wantSeverity, issues[0].Severity)
+		})
+	}
+}
+
+func TestParseMode(t *testing.T) {
+	tests := []struct {
+		mode   string
+		want   modeChange
+		wantOK bool
+	}{
+		{mode: "755", wantOK: true},
+		{mode: "0777", want: modeChange{worldWritable: true}, wantOK: true},
+		{mode: "6755", want: modeChange{setuid: true, setgid: true}, wantOK: true},
+		{mode: "o+w", want: modeChange{worldWritable: true}, wantOK: true},
+		{mode: "u=rwx,go=rwx", want: modeChange{worldWritable: true}, wantOK: true},
+		{mode: "+w", wantOK: true},
+		{mode: "a-w", wantOK: true},
+		{mode: "g+s", want: modeChange{setgid: true}, wantOK: true},
+		{mode: "+s", want: modeChange{setuid: true, setgid: true}, wantOK: true},
+		{mode: "u+x-w", wantOK: true},
+		{mode: "1777", want: modeChange{sticky: true}, wantOK: true},
+		{mode: "o+wt", want: modeChange{sticky: true}, wantOK: true},
+		{mode: "u+t,o+w", want: modeChange{worldWritable: true}, wantOK: true},
+		{mode: "17777"},
+		{mode: "u"},
+		{mode: "x+w"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			got, ok := parseMode(tt.mode)
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,202 @@
+package hazardous
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/hiteshrepo/hazardous/pkg/issue"
+	"mvdan.cc/sh/syntax"
+)
+
+// criticalFiles are system files without which users cannot log in, names do
+// not resolve or the system does not boot.
+var criticalFiles = []string{
+	"/etc/passwd", "/etc/shadow", "/etc/group", "/etc/gshadow", "/etc/sudoers", "/etc/fstab",
+	"/etc/hosts", "/etc/hostname", "/etc/resolv.conf", "/etc/crontab", "/etc/nsswitch.conf",
+}
+
+// criticalDirs hold files the system cannot run without.
+var criticalDirs = []string{
+	"/boot", "/bin", "/sbin", "/lib", "/lib32", "/lib64", "/usr/bin", "/usr/sbin", "/usr/lib",
+	"/usr/lib64", "/etc/ssh", "/etc/sudoers.d", "/etc/pam.d", "/etc/systemd",
+}
+
+// configDirs hold the configuration and state of the system.
+var configDirs = []string{"/etc", "/usr", "/var/lib", "/var/log", "/var/spool", "/Library", "/System"}
+
+var redirectRule = &redirectOverwriteRule{}
+
+// redirectOverwriteRule flags redirections truncating system files, writing
+// to disks, or writing to a path built from a variable that may be empty.
+type redirectOverwriteRule struct{}
+
+func (r *redirectOverwriteRule) ID() string { return "redirect-overwrite" }
+
+func (r *redirectOverwriteRule) Description() string {
+	return "redirection overwriting a system file or a disk"
+}
+
+func (r *redirectOverwriteRule) Severity() issue.Severity { return issue.SeverityWarning }
+
+func (r *redirectOverwriteRule) Check(ctx *Context, node syntax.Node) []issue.Issue {
+	rd, ok := node.(*syntax.Redirect)
+	if !ok || rd.Word == nil {
+		return nil
+	}
+
+	var truncates bool
+
+	switch rd.Op {
+	case syntax.RdrOut, syntax.ClbOut, syntax.RdrAll:
+		truncates = true
+	case syntax.AppOut, syntax.AppAll:
+		// appending only destroys data on a disk
+	default:
+		return nil
+	}
+
+	op := rd.Op.String()
+	if rd.N != nil {
+		op = rd.N.Value + op
+	}
+
+	if class, text := ctx.wordDevice(rd.Word); class == deviceDisk {
+		found := ctx.newIssue(r, rd, op, fmt.Sprintf("%s overwrites %s", op, class.describe(text)))
+		found.Severity = class.severity()
+		found.Fix = diskFix
+
+		return []issue.Issue{found}
+	}
+
+	if !truncates {
+		return nil
+	}
+
+	if p, ok := ctx.wordPath(rd.Word); ok {
+		class := classifyFile(p, ctx.protected)
+		if class == fileOther {
+			return nil
+		}
+
+		text := ctx.wordText(rd.Word)
+		if p != text {
+			text = fmt.Sprintf("%s (%s)", p, text)
+		}
+
+		found := ctx.newIssue(r, rd, op, fmt.Sprintf("%s truncates %s", op, class.describe(text)))
+		found.Severity = class.severity()
+		found.Fix = "write to a temporary file and move it into place once it is complete, or append with >>"
+
+		return []issue.Issue{found}
+	}
+
+	if ctx.vars == nil {
+		return nil
+	}
+
+	// targets are reported the way empty-var-path reports operands: only
+	// when what is left of them reaches further, as "$ROOT/etc/app.conf"
+	// does and "$NAME.log" does not
+	for _, e := range ctx.emptyExpansions(rd.Word) {
+		message := fmt.Sprintf("%s, so %s may truncate %q", e.desc, op, e.expanded)
+
+		switch {
+		case len(e.expanded) == 0:
+			// the shell refuses to redirect to an empty name, failing the
+			// command
+			message = fmt.Sprintf("%s, so %s has no file to write to", e.desc, op)
+			e.severity = issue.SeverityWarning
+		case !widens(e.expanded, ctx.protected):
+			continue
+		}
+
+		found := ctx.newIssue(r, rd, op, message)
+		found.Severity = e.severity
+		found.Fix = e.fix
+
+		return []issue.Issue{found}
+	}
+
+	return nil
+}
+
+// fileClass tells how much a system depends on a file, from least to most.
+type fileClass int
+
+const (
+	fileOther fileClass = iota
+	// fileSystem holds configuration or state, e.g. /etc/motd.
+	fileSystem
+	// fileCritical is needed to boot or log in, e.g. /etc/fstab or
+	// ~/.ssh/authorized_keys.
+	fileCritical
+	// fileProtected is a protected path or lies below one.
+	fileProtected
+)
+
+// classifyFile returns how much a system depends on the file p, a path
+// resolved from a command argument.
+func classifyFile(p string, protected []string) fileClass {
+	p = path.Clean(p)
+
+	for _, prot := range protected {
+		if p == prot || strings.HasPrefix(p, prot+"/") {
+			return fileProtected
+		}
+	}
+
+	switch {
+	case !path.IsAbs(p) && !strings.HasPrefix(p, "~"):
+		return fileOther
+	case strings.Contains(p, "/.ssh/"):
+		return fileCritical
+	}
+
+	for _, f := range criticalFiles {
+		if p == f {
+			return fileCritical
+		}
+	}
+
+	for _, dir := range criticalDirs {
+		if strings.HasPrefix(p, dir+"/") {
+			return fileCritical
+		}
+	}
+
+	for _, dir := range configDirs {
+		if strings.HasPrefix(p, dir+"/") {
+			return fileSystem
+		}
+	}
+
+	return fileOther
+}
+
+// severity returns the severity of truncating a file of class c.
+func (c fileClass) severity() issue.Severity {
+	switch c {
+	case fileProtected, fileCritical:
+		return issue.SeverityError
+	case fileSystem:
+		return issue.SeverityWarning
+	default:
+		return issue.SeverityInfo
+	}
+}
+
+// describe names a file of class c written as arg, e.g. "the system file
+// /etc/motd".
+func (c fileClass) describe(arg string) string {
+	switch c {
+	case fileProtected:
+		return "the protected path " + arg
+	case fileCritical:
+		return "the critical system file " + arg
+	case fileSystem:
+		return "the system file " + arg
+	default:
+		return arg
+	}
+}
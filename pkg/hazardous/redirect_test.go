@@ -0,0 +1,149 @@
+package hazardous
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hiteshrepo/hazardous/pkg/issue"
+	"github.com/hiteshrepo/hazardous/pkg/makefile"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedirectRule(t *testing.T) {
+	tests := []struct {
+		name         string
+		script       string
+		wantCommand  string
+		wantMessage  string
+		wantSeverity issue.Severity
+	}{
+		{
+			name:         "critical system file",
+			script:       "> /etc/hosts",
+			wantCommand:  ">",
+			wantMessage:  "> truncates the critical system file /etc/hosts",
+			wantSeverity: issue.SeverityError,
+		},
+		{
+			name:         "log file",
+			script:       ": > /var/log/syslog",
+			wantCommand:  ">",
+			wantMessage:  "> truncates the system file /var/log/syslog",
+			wantSeverity: issue.SeverityWarning,
+		},
+		{
+			name:         "authorized keys",
+			script:       `echo "$KEY" > "$HOME/.ssh/authorized_keys"`,
+			wantCommand:  ">",
+			wantMessage:  "> truncates the critical system file ~/.ssh/authorized_keys ($HOME/.ssh/authorized_keys)",
+			wantSeverity: issue.SeverityError,
+		},
+		{
+			name:         "protected path",
+			script:       "pg_dump app 2>| /srv/data/dump.sql",
+			wantCommand:  "2>|",
+			wantMessage:  "2>| truncates the protected path /srv/data/dump.sql",
+			wantSeverity: issue.SeverityError,
+		},
+		{
+			name:         "disk",
+			script:       "echo x > /dev/sda",
+			wantCommand:  ">",
+			wantMessage:  "> overwrites the disk /dev/sda",
+			wantSeverity: issue.SeverityError,
+		},
+		{
+			name:         "append to a disk",
+			script:       "DISK=/dev/nvme0n1\ncat image >> $DISK",
+			wantCommand:  ">>",
+			wantMessage:  ">> overwrites the disk /dev/nvme0n1 ($DISK)",
+			wantSeverity: issue.SeverityError,
+		},
+		{
+			name:         "variable that may be empty",
+			script:       "read -r ROOT\ncat > \"$ROOT/etc/app.conf\" <<EOF\nkey=value\nEOF",
+			wantCommand:  ">",
+			wantMessage:  `$ROOT may be empty: read from input (line 1), so > may truncate "/etc/app.conf"`,
+			wantSeverity: issue.SeverityWarning,
+		},
+		{
+			name:         "unset variable",
+			script:       "CONFIG=app.conf\nunset CONFIG\necho x > \"$CONFIG\"",
+			wantCommand:  ">",
+			wantMessage:  "$CONFIG is unset (line 2), so > has no file to write to",
+			wantSeverity: issue.SeverityWarning,
+		},
+		{
+			name:   "append to a log",
+			script: "echo done >> /var/log/deploy.log",
+		},
+		{
+			name:   "file in the repository",
+			script: "echo x > build/out.txt",
+		},
+		{
+			name:   "discarded output",
+			script: "make 2>/dev/null &> /dev/null",
+		},
+		{
+			name:   "input",
+			script: "wc -l < /etc/hosts",
+		},
+		{
+			name:   "variable with a value",
+			script: "OUT=out.txt\necho x > \"$OUT\"",
+		},
+		{
+			name:   "name built from an argument",
+			script: `echo x > "$1.log"`,
+		},
+		{
+			name:   "every expansion leaves a relative path",
+			script: "read -r DESTDIR prefix\necho x > $DESTDIR$prefix/Info.plist",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry := NewRegistry(redirectRule)
+			registry.Protect("/srv/data")
+
+			issues := checkScript(t, registry, tt.script)
+			if len(tt.wantCommand) == 0 {
+				assert.Empty(t, issues)
+				return
+			}
+
+			require.Len(t, issues, 1)
+			assert.Equal(t, tt.wantCommand, issues[0].Command)
+			assert.Equal(t, tt.wantMessage, issues[0].Message)
+			assert.Equal(t, tt.wantSeverity, issues[0].Severity)
+		})
+	}
+}
+
+func TestRedirectRuleMakefile(t *testing.T) {
+	mf, err := makefile.Parse(strings.NewReader(`install:
+	echo x > $(DESTDIR)$(prefix)/Info.plist
+	echo x > $(ROOT)/etc/app.conf
+`), "Makefile")
+	require.NoError(t, err)
+
+	issues, err := NewRegistry(redirectRule).CheckMakefile("Makefile", mf)
+	require.NoError(t, err)
+
+	require.Len(t, issues, 1)
+	assert.Equal(t, `$(ROOT) may be empty: not defined in the Makefile and empty unless set in the environment, so > may truncate "/etc/app.conf"`, issues[0].Message)
+	assert.Equal(t, uint(3), issues[0].Line)
+}
+
+func TestRedirectRulePosition(t *testing.T) {
+	issues := checkScript(t, NewRegistry(redirectRule), "set -e\necho 127.0.0.1 >/etc/hosts\n")
+	require.Len(t, issues, 1)
+
+	assert.Equal(t, uint(2), issues[0].Line)
+	assert.Equal(t, uint(16), issues[0].Col)
+	assert.Equal(t, uint(2), issues[0].EndLine)
+	assert.Equal(t, uint(27), issues[0].EndCol)
+}
@@ -0,0 +1,568 @@
+package hazardous
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/hiteshrepo/hazardous/pkg/command"
+	"github.com/hiteshrepo/hazardous/pkg/issue"
+	"mvdan.cc/sh/syntax"
+)
+
+// fetchers download the URL given as their first operand.
+var fetchers = map[string]bool{"curl": true, "wget": true}
+
+// interpreters run the script named by their first operand, or the one on
+// their standard input when there is none or it is "-".
+var interpreters = map[string]bool{
+	"node": true, "perl": true, "php": true, "python": true, "python2": true, "python3": true, "ruby": true,
+}
+
+// checksumTools compute or check the checksums of files.
+var checksumTools = map[string]bool{
+	"b2sum": true, "cksum": true, "md5sum": true, "sha1sum": true, "sha224sum": true, "sha256sum": true,
+	"sha384sum": true, "sha512sum": true, "shasum": true,
+}
+
+var remoteExecRule = &remoteExecutionRule{}
+
+// remoteExecutionRule flags code downloaded with curl or wget and run by a
+// shell or another interpreter before anything checks it: piped into the
+// interpreter, handed to it through a process or command substitution, or
+// saved to a file that is run without a checksum or signature check in
+// between.
+type remoteExecutionRule struct{}
+
+func (r *remoteExecutionRule) ID() string { return "remote-exec" }
+
+func (r *remoteExecutionRule) Description() string {
+	return "downloaded code run by an interpreter without verification"
+}
+
+func (r *remoteExecutionRule) Severity() issue.Severity { return issue.SeverityWarning }
+
+func (r *remoteExecutionRule) Check(ctx *Context, node syntax.Node) []issue.Issue {
+	switch n := node.(type) {
+	case *syntax.BinaryCmd:
+		return r.checkPipe(ctx, n)
+	case *syntax.CallExpr:
+		return r.checkSubst(ctx, n)
+	case *syntax.File:
+		return r.checkDownloads(ctx, n)
+	}
+
+	return nil
+}
+
+// fetch is a download made by curl or wget.
+type fetch struct {
+	call *call
+	// url is the URL as written, with variables holding constants resolved.
+	url string
+	// plain is set for URLs fetched without TLS, and insecure holds the
+	// option turning off certificate checks, e.g. -k, when TLS is used
+	// without them.
+	plain    bool
+	insecure string
+	// stdout is set when the download is written to standard output, and
+	// file is where it is saved otherwise, if known.
+	stdout bool
+	file   string
+}
+
+// checkPipe flags a pipeline feeding a download to an interpreter. As a | b | c
+// nests as a | (b | c), the pair is reported on the pipe splitting them.
+func (r *remoteExecutionRule) checkPipe(ctx *Context, cmd *syntax.BinaryCmd) []issue.Issue {
+	if cmd.Op != syntax.Pipe && cmd.Op != syntax.PipeAll {
+		return nil
+	}
+
+	f, ok := ctx.streamedFetch(cmd.X)
+	if !ok {
+		return nil
+	}
+
+	for _, st := range pipeStages(cmd.Y) {
+		c := ctx.scriptReader(st)
+		if c == nil {
+			continue
+		}
+
+		found := r.report(ctx, cmd, f, c, c.name+" runs a script", "", 0)
+		found.Command = fmt.Sprintf("%s | %s", f.call.name, found.Command)
+
+		return []issue.Issue{found}
+	}
+
+	return nil
+}
+
+// checkSubst flags downloads handed to an interpreter through a process
+// substitution, as in `bash <(curl ...)`, or a command substitution holding
+// the code to run, as in `eval "$(curl ...)"`.
+func (r *remoteExecutionRule) checkSubst(ctx *Context, cmd *syntax.CallExpr) []issue.Issue {
+	var issues []issue.Issue
+
+	for _, c := range ctx.calls(cmd) {
+		var (
+			words  []*syntax.Word
+			script = true
+		)
+
+		switch args := parseCall(c); {
+		case c.name == "eval":
+			words, script = c.args, false
+		case shells[c.name] && noexec(c, args):
+		case shells[c.name] && args.Has("command"):
+			script = false
+			fallthrough
+		case shells[c.name] || interpreters[c.name] || c.name == "source" || c.name == ".":
+			if len(args.Operands) > 0 {
+				words = c.args[args.Operands[0] : args.Operands[0]+1]
+			}
+		}
+
+		for _, word := range words {
+			for _, part := range flattenParts(word.Parts) {
+				var (
+					f     fetch
+					ok    bool
+					label string
+				)
+
+				switch p := part.(type) {
+				case *syntax.ProcSubst:
+					if script && p.Op == syntax.CmdIn {
+						f, ok = ctx.streamedFetch(p)
+						label = "%s <(%s)"
+					}
+				case *syntax.CmdSubst:
+					if !script {
+						f, ok = ctx.streamedFetch(p)
+						label = `%s "$(%s)"`
+						if c.name != "eval" {
+							label = `%s -c "$(%s)"`
+						}
+					}
+				}
+
+				if !ok {
+					continue
+				}
+
+				found := r.report(ctx, cmd, f, c, c.name+" runs a script", "", 0)
+				found.Command = fmt.Sprintf(label, found.Command, f.call.name)
+				issues = append(issues, found)
+			}
+		}
+	}
+
+	return issues
+}
+
+// checkDownloads follows the files downloaded in a script, with -o, -O or a
+// redirect of the output, and flags those run by an interpreter, or executed,
+// before a checksum or signature check.
+func (r *remoteExecutionRule) checkDownloads(ctx *Context, file *syntax.File) []issue.Issue {
+	var issues []issue.Issue
+
+	downloads := make(map[string]fetch)
+
+	syntax.Walk(file, func(node syntax.Node) bool {
+		st, ok := node.(*syntax.Stmt)
+		if !ok {
+			return true
+		}
+
+		cmd, ok := st.Cmd.(*syntax.CallExpr)
+		if !ok {
+			return true
+		}
+
+		for _, c := range ctx.calls(cmd) {
+			if f, ok := ctx.fetch(c); ok {
+				if f.stdout {
+					f = ctx.redirected(st, f)
+				}
+
+				if !f.stdout && len(f.file) > 0 {
+					downloads[f.file] = f
+				}
+				continue
+			}
+
+			if check, ok := verifies(c); ok {
+				ctx.verified(c, check, downloads)
+				continue
+			}
+
+			name, direct, ok := ctx.scriptFile(c)
+			if !ok {
+				continue
+			}
+
+			if f, ok := downloads[name]; ok {
+				subject := fmt.Sprintf("%s runs %s", c.name, name)
+				if direct {
+					subject = name + " is executed as"
+				}
+
+				line, _ := ctx.position(f.call.expr.Pos())
+				found := r.report(ctx, cmd, f, c, subject, name, line)
+				if !direct {
+					found.Command += " " + name
+				}
+
+				issues = append(issues, found)
+				delete(downloads, name)
+			}
+		}
+
+		return true
+	})
+
+	return issues
+}
+
+// redirected returns the download f, written to standard output, as saved to
+// the file st redirects its output to, as in curl -s URL > install.sh.
+func (ctx *Context) redirected(st *syntax.Stmt, f fetch) fetch {
+	for _, r := range st.Redirs {
+		if r.N != nil && r.N.Value != "1" {
+			continue
+		}
+
+		switch r.Op {
+		case syntax.RdrOut, syntax.AppOut, syntax.ClbOut, syntax.RdrAll, syntax.AppAll:
+			f.stdout, f.file = false, path.Clean(ctx.argText(r.Word))
+		}
+	}
+
+	return f
+}
+
+// report builds the finding for the code downloaded by f and run by c.
+// subject names the run, e.g. "bash runs a script", and file the downloaded
+// file when the download happened earlier, at line.
+func (r *remoteExecutionRule) report(ctx *Context, node syntax.Node, f fetch, c *call, subject, file string, line uint) issue.Issue {
+	msg := fmt.Sprintf("%s downloaded by %s%s", subject, f.call.name, f.transport())
+
+	if len(f.url) > 0 {
+		msg += " from " + f.url
+	}
+
+	if line > 0 {
+		msg += fmt.Sprintf(" (line %d)", line)
+	}
+
+	name := c.name
+	if len(c.privileged) > 0 {
+		name = c.privileged + " " + name
+	}
+
+	found := ctx.newIssue(r, node, name, msg)
+	found.Fix = "download it to a file and check it against a published checksum (e.g. sha256sum -c) before running it"
+
+	switch {
+	case f.plain || len(f.insecure) > 0:
+		found.Severity = issue.SeverityError
+		found.Message += ", which anyone on the network path can tamper with"
+		found.Fix = "download it over https:// with certificate checks on, and check it against a published checksum (e.g. sha256sum -c) before running it"
+	case len(file) > 0:
+		found.Message += " without verifying its checksum"
+		found.Fix = fmt.Sprintf("check %s against a published checksum (e.g. sha256sum -c) before running it", file)
+	default:
+		found.Message += " without verifying it"
+	}
+
+	c.elevate(&found)
+	found.Command = name
+
+	return found
+}
+
+// fetch returns the download made by c, if it runs curl or wget.
+func (ctx *Context) fetch(c *call) (fetch, bool) {
+	if !fetchers[c.name] {
+		return fetch{}, false
+	}
+
+	args := parseCall(c)
+	f := fetch{call: c}
+
+	if m, ok := args.Lookup("url"); ok {
+		f.url = ctx.optionValue(c, m)
+	} else if len(args.Operands) > 0 {
+		f.url = ctx.argText(c.args[args.Operands[0]])
+	}
+
+	proto := ""
+	if m, ok := args.Lookup("proto-default"); ok {
+		proto = m.Value
+	}
+	f.plain = plainURL(f.url, proto)
+
+	for _, name := range []string{"insecure", "no-check-certificate"} {
+		if m, ok := args.Lookup(name); ok && !f.plain {
+			f.insecure = optionFlag(c, m)
+		}
+	}
+
+	var (
+		output, dir string
+		named       bool
+	)
+
+	switch c.name {
+	case "curl":
+		if m, ok := args.Lookup("output"); ok {
+			output, named = ctx.optionValue(c, m), true
+		} else if args.Has("remote-name") || args.Has("remote-name-all") {
+			output, named = remoteName(f.url), true
+		}
+
+		if m, ok := args.Lookup("output-dir"); ok {
+			dir = ctx.optionValue(c, m)
+		}
+
+	case "wget":
+		output, named = remoteName(f.url), true
+		if m, ok := args.Lookup("output-document"); ok {
+			output = ctx.optionValue(c, m)
+		} else if m, ok := args.Lookup("directory-prefix"); ok {
+			dir = ctx.optionValue(c, m)
+		}
+	}
+
+	switch {
+	case !named || output == "-":
+		f.stdout = true
+	case len(output) > 0:
+		f.file = path.Clean(path.Join(dir, output))
+	}
+
+	return f, true
+}
+
+// transport describes how f was downloaded when it was not over TLS with
+// certificate checks, e.g. " over plain HTTP".
+func (f fetch) transport() string {
+	switch {
+	case f.plain:
+		return " over plain HTTP"
+	case len(f.insecure) > 0:
+		return fmt.Sprintf(" with certificate checks turned off (%s)", f.insecure)
+	}
+
+	return ""
+}
+
+// streamedFetch returns a download written to standard output below node.
+func (ctx *Context) streamedFetch(node syntax.Node) (fetch, bool) {
+	var (
+		found fetch
+		ok    bool
+	)
+
+	syntax.Walk(node, func(node syntax.Node) bool {
+		cmd, isCall := node.(*syntax.CallExpr)
+		if ok || !isCall {
+			return !ok
+		}
+
+		for _, c := range ctx.calls(cmd) {
+			if f, isFetch := ctx.fetch(c); isFetch && f.stdout {
+				found, ok = f, true
+				break
+			}
+		}
+
+		return !ok
+	})
+
+	return found, ok
+}
+
+// pipeStages returns the commands of the pipeline st, in order.
+func pipeStages(st *syntax.Stmt) []*syntax.Stmt {
+	if b, ok := st.Cmd.(*syntax.BinaryCmd); ok && (b.Op == syntax.Pipe || b.Op == syntax.PipeAll) {
+		return append(pipeStages(b.X), pipeStages(b.Y)...)
+	}
+
+	return []*syntax.Stmt{st}
+}
+
+// scriptReader returns the interpreter run by st that reads its script from
+// standard input, e.g. the bash of `curl ... | sudo bash`.
+func (ctx *Context) scriptReader(st *syntax.Stmt) *call {
+	last := ctx.lastCall(st)
+	if last == nil {
+		return nil
+	}
+
+	if _, ok := readsShell(last); ok {
+		return last
+	}
+
+	if !interpreters[last.name] {
+		return nil
+	}
+
+	args := parseCall(last)
+	if len(args.Operands) > 0 {
+		if value, _ := literalWord(last.args[args.Operands[0]]); value != "-" {
+			return nil
+		}
+	}
+
+	return last
+}
+
+// scriptFile returns the file run by c: the script given to a shell, another
+// interpreter or source, or the command itself when named by a path, in which
+// case direct is set.
+func (ctx *Context) scriptFile(c *call) (name string, direct, ok bool) {
+	args := parseCall(c)
+
+	switch {
+	case shells[c.name] && (args.Has("command") || args.Has("stdin") || noexec(c, args)):
+		return "", false, false
+	case shells[c.name] || interpreters[c.name] || c.name == "source" || c.name == ".":
+		if len(args.Operands) == 0 {
+			return "", false, false
+		}
+
+		return path.Clean(ctx.argText(c.args[args.Operands[0]])), false, true
+	}
+
+	if c.word == nil {
+		return "", false, false
+	}
+
+	name = ctx.argText(c.word)
+	if !strings.Contains(name, "/") {
+		return "", false, false
+	}
+
+	return path.Clean(name), true, true
+}
+
+// verifies reports whether c checks the checksum or signature of a file, and
+// whether it checks files listed elsewhere, as sha256sum -c does.
+func verifies(c *call) (check, ok bool) {
+	switch c.name {
+	case "gpgv":
+		return false, true
+	case "gpg", "gpg2":
+		for _, word := range c.args {
+			if value, _ := literalWord(word); value == "--verify" {
+				return false, true
+			}
+		}
+	case "cosign":
+		if len(c.args) > 0 {
+			value, _ := literalWord(c.args[0])
+			return false, strings.HasPrefix(value, "verify")
+		}
+	case "minisign":
+		for _, word := range c.args {
+			if value, _ := literalWord(word); strings.HasPrefix(value, "-V") {
+				return false, true
+			}
+		}
+	}
+
+	if !checksumTools[c.name] {
+		return false, false
+	}
+
+	return parseCall(c).Has("check"), true
+}
+
+// verified marks the downloads checked by c as safe to run: the files it
+// names, or every file downloaded so far when it names none of them or checks
+// the files listed in a checksum file.
+func (ctx *Context) verified(c *call, check bool, downloads map[string]fetch) {
+	var named []string
+
+	for _, word := range c.args {
+		if name := path.Clean(ctx.argText(word)); len(downloads[name].file) > 0 {
+			named = append(named, name)
+		}
+	}
+
+	if check || len(named) == 0 {
+		named = named[:0]
+		for name := range downloads {
+			named = append(named, name)
+		}
+	}
+
+	for _, name := range named {
+		delete(downloads, name)
+	}
+}
+
+// optionValue returns the argument of the option m of c, given in the same
+// word or in the following one.
+func (ctx *Context) optionValue(c *call, m command.Match) string {
+	if value, _ := literalWord(c.args[m.Index]); len(m.Value) > 0 && strings.HasSuffix(value, m.Value) {
+		return m.Value
+	}
+
+	if m.Index+1 < len(c.args) {
+		return ctx.argText(c.args[m.Index+1])
+	}
+
+	return m.Value
+}
+
+// argText returns an argument with variables holding constants resolved, or
+// as written when it depends on values only known at run time.
+func (ctx *Context) argText(word *syntax.Word) string {
+	if value, ok := ctx.wordPath(word); ok {
+		return value
+	}
+
+	return ctx.wordText(word)
+}
+
+// plainURL reports whether url is fetched without TLS: http:// and ftp://
+// URLs, and URLs without a scheme, which curl and wget fetch over HTTP unless
+// curl is given another default protocol.
+func plainURL(url, proto string) bool {
+	if len(url) == 0 || strings.HasPrefix(url, "$") {
+		return false
+	}
+
+	scheme, _, ok := strings.Cut(url, "://")
+	if !ok {
+		scheme = proto
+	}
+
+	switch strings.ToLower(scheme) {
+	case "", "http", "ftp":
+		return true
+	}
+
+	return false
+}
+
+// remoteName returns the name of the file curl -O and wget save a download
+// from url to, or "" when it is not known.
+func remoteName(url string) string {
+	if _, rest, ok := strings.Cut(url, "://"); ok {
+		url = rest
+	}
+
+	url, _, _ = strings.Cut(url, "?")
+	url, _, _ = strings.Cut(url, "#")
+
+	_, p, ok := strings.Cut(url, "/")
+	if !ok || len(p) == 0 || strings.HasSuffix(p, "/") {
+		return ""
+	}
+
+	return path.Base(p)
+}
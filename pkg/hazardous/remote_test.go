@@ -0,0 +1,171 @@
+package hazardous
+
+import (
+	"testing"
+
+	"github.com/hiteshrepo/hazardous/pkg/issue"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRemoteExecRule(t *testing.T) {
+	tests := []struct {
+		name         string
+		script       string
+		wantCommand  string
+		wantMessage  string
+		wantSeverity issue.Severity
+	}{
+		{
+			name:         "curl piped into bash",
+			script:       "curl -fsSL https://get.example.com/install.sh | bash",
+			wantCommand:  "curl | bash",
+			wantMessage:  "bash runs a script downloaded by curl from https://get.example.com/install.sh without verifying it",
+			wantSeverity: issue.SeverityWarning,
+		},
+		{
+			name:         "wget to stdout over plain HTTP",
+			script:       "wget -qO- http://get.example.com/install.sh | sh -s -- --yes",
+			wantCommand:  "wget | sh",
+			wantMessage:  "sh runs a script downloaded by wget over plain HTTP from http://get.example.com/install.sh, which anyone on the network path can tamper with",
+			wantSeverity: issue.SeverityError,
+		},
+		{
+			name:         "certificate checks turned off",
+			script:       "curl -sk https://get.example.com/install.sh | bash",
+			wantCommand:  "curl | bash",
+			wantMessage:  "bash runs a script downloaded by curl with certificate checks turned off (-k) from https://get.example.com/install.sh, which anyone on the network path can tamper with",
+			wantSeverity: issue.SeverityError,
+		},
+		{
+			name:         "URL without a scheme",
+			script:       "curl -s get.example.com | sh",
+			wantCommand:  "curl | sh",
+			wantMessage:  "sh runs a script downloaded by curl over plain HTTP from get.example.com, which anyone on the network path can tamper with",
+			wantSeverity: issue.SeverityError,
+		},
+		{
+			name:         "URL from a constant",
+			script:       "URL=https://example.com/setup.py\ncurl -sSL \"$URL\" | tee setup.log | python3 -",
+			wantCommand:  "curl | python3",
+			wantMessage:  "python3 runs a script downloaded by curl from https://example.com/setup.py without verifying it",
+			wantSeverity: issue.SeverityWarning,
+		},
+		{
+			name:         "piped into sudo",
+			script:       "curl -fsSL --url https://example.com/i.sh | sudo -E bash",
+			wantCommand:  "curl | sudo bash",
+			wantMessage:  "bash runs a script downloaded by curl from https://example.com/i.sh without verifying it, running as root through sudo",
+			wantSeverity: issue.SeverityError,
+		},
+		{
+			name:         "process substitution",
+			script:       "source <(curl -s https://example.com/env.sh)",
+			wantCommand:  "source <(curl)",
+			wantMessage:  "source runs a script downloaded by curl from https://example.com/env.sh without verifying it",
+			wantSeverity: issue.SeverityWarning,
+		},
+		{
+			name:         "script operand",
+			script:       "bash <(wget -O - http://example.com/i.sh) --prefix /opt",
+			wantCommand:  "bash <(wget)",
+			wantMessage:  "bash runs a script downloaded by wget over plain HTTP from http://example.com/i.sh, which anyone on the network path can tamper with",
+			wantSeverity: issue.SeverityError,
+		},
+		{
+			name:         "command string",
+			script:       `/bin/bash -c "$(curl -fsSL https://example.com/install.sh)"`,
+			wantCommand:  `bash -c "$(curl)"`,
+			wantMessage:  "bash runs a script downloaded by curl from https://example.com/install.sh without verifying it",
+			wantSeverity: issue.SeverityWarning,
+		},
+		{
+			name:         "eval",
+			script:       `eval "$(curl -s "$BASE/env")"`,
+			wantCommand:  `eval "$(curl)"`,
+			wantMessage:  "eval runs a script downloaded by curl from $BASE/env without verifying it",
+			wantSeverity: issue.SeverityWarning,
+		},
+		{
+			name:         "downloaded file run later",
+			script:       "curl -fsSLo /tmp/install.sh https://example.com/install.sh\nbash /tmp/install.sh",
+			wantCommand:  "bash /tmp/install.sh",
+			wantMessage:  "bash runs /tmp/install.sh downloaded by curl from https://example.com/install.sh (line 1) without verifying its checksum",
+			wantSeverity: issue.SeverityWarning,
+		},
+		{
+			name:         "remote name executed",
+			script:       "wget http://example.com/dl/install.sh\nchmod +x install.sh\n./install.sh",
+			wantCommand:  "install.sh",
+			wantMessage:  "install.sh is executed as downloaded by wget over plain HTTP from http://example.com/dl/install.sh (line 1), which anyone on the network path can tamper with",
+			wantSeverity: issue.SeverityError,
+		},
+		{
+			name:         "output redirected to a file run later",
+			script:       "curl -s https://x.example.com/a.sh > /tmp/a.sh && sh /tmp/a.sh",
+			wantCommand:  "sh /tmp/a.sh",
+			wantMessage:  "sh runs /tmp/a.sh downloaded by curl from https://x.example.com/a.sh (line 1) without verifying its checksum",
+			wantSeverity: issue.SeverityWarning,
+		},
+		{
+			name:   "checksum verified before running",
+			script: "curl -fsSLO https://example.com/install.sh\necho \"$SUM  install.sh\" | sha256sum -c -\nsh install.sh",
+		},
+		{
+			name:   "signature verified before running",
+			script: "wget -O setup.sh https://example.com/setup.sh\ngpg --verify setup.sh.asc setup.sh\nbash ./setup.sh",
+		},
+		{
+			name:   "download to a file",
+			script: "curl -fsSL -o get.sh https://example.com/get.sh",
+		},
+		{
+			name:   "output not run",
+			script: "curl -fsSL https://example.com/data.json | jq .",
+		},
+		{
+			name:   "syntax check only",
+			script: "curl -fsSL https://example.com/install.sh | bash -n",
+		},
+		{
+			name:   "syntax check of a downloaded file",
+			script: "wget -qO- https://example.com/install.sh > install.sh\nsh -o noexec install.sh",
+		},
+		{
+			name:   "output redirected to /dev/null",
+			script: "curl -s https://example.com/ping > /dev/null\nbash ./build.sh",
+		},
+		{
+			name:   "shell given a script file",
+			script: "curl -s https://example.com/x | bash ./build.sh",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issues := checkScript(t, NewRegistry(remoteExecRule), tt.script)
+			if len(tt.wantCommand) == 0 {
+				assert.Empty(t, issues)
+				return
+			}
+
+			require.Len(t, issues, 1)
+			assert.Equal(t, tt.wantCommand, issues[0].Command)
+			assert.Equal(t, tt.wantMessage, issues[0].Message)
+			assert.Equal(t, tt.wantSeverity, issues[0].Severity)
+		})
+	}
+}
+
+func TestRemoteExecRulePosition(t *testing.T) {
+	file := parseScript(t, "set -e\n  curl -sL https://example.com/i.sh | bash\n")
+
+	issues := NewRegistry(remoteExecRule).CheckFile(&Context{Filepath: "test.sh"}, file)
+	require.Len(t, issues, 1)
+
+	assert.Equal(t, uint(2), issues[0].Line)
+	assert.Equal(t, uint(3), issues[0].Col)
+	assert.Equal(t, uint(2), issues[0].EndLine)
+	assert.Equal(t, uint(43), issues[0].EndCol)
+	assert.Equal(t, "download it to a file and check it against a published checksum (e.g. sha256sum -c) before running it", issues[0].Fix)
+}
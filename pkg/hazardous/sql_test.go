@@ -0,0 +1,147 @@
+package hazardous
+
+import (
+	"testing"
+
+	"github.com/hiteshrepo/hazardous/pkg/issue"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDBDestroyRule(t *testing.T) {
+	tests := []struct {
+		name         string
+		script       string
+		wantCommand  string
+		wantMessage  string
+		wantSeverity issue.Severity
+	}{
+		{
+			name:         "psql dropping a database",
+			script:       `psql -h db -U admin -c "DROP DATABASE prod"`,
+			wantCommand:  "psql -c",
+			wantMessage:  "psql -c runs DROP DATABASE prod, which deletes the database prod and all its data",
+			wantSeverity: issue.SeverityError,
+		},
+		{
+			name:         "mysql truncating a table",
+			script:       `mysql -uroot -p"$PASS" app -e 'truncate table sessions'`,
+			wantCommand:  "mysql -e",
+			wantMessage:  "mysql -e runs TRUNCATE sessions, which deletes every row of sessions",
+			wantSeverity: issue.SeverityError,
+		},
+		{
+			name:         "delete without where",
+			script:       `mysql --execute="DELETE FROM users;"`,
+			wantCommand:  "mysql -e",
+			wantMessage:  "mysql -e runs DELETE FROM users without a WHERE clause, which deletes every row of users",
+			wantSeverity: issue.SeverityWarning,
+		},
+		{
+			name:         "tables dropped from a here-document",
+			script:       "psql \"$DATABASE_URL\" <<'SQL'\n-- reset\nDELETE FROM jobs WHERE done;\nDROP TABLE IF EXISTS events, \"Audit\";\nSQL",
+			wantCommand:  "psql <<",
+			wantMessage:  `psql << runs DROP TABLE events, "Audit", which deletes the tables events and "Audit" and all their data`,
+			wantSeverity: issue.SeverityError,
+		},
+		{
+			name:         "schema from a here-string",
+			script:       `sudo -u postgres psql <<< "drop schema $SCHEMA cascade"`,
+			wantCommand:  "sudo psql <<<",
+			wantMessage:  "psql <<< runs DROP SCHEMA $SCHEMA, which deletes the schema $SCHEMA and all its data, running as postgres through sudo",
+			wantSeverity: issue.SeverityError,
+		},
+		{
+			name:         "sqlite statements as operands",
+			script:       `sqlite3 -batch app.db "DELETE FROM cache"`,
+			wantCommand:  "sqlite3",
+			wantMessage:  "sqlite3 runs DELETE FROM cache without a WHERE clause, which deletes every row of cache",
+			wantSeverity: issue.SeverityWarning,
+		},
+		{
+			name:         "statement piped into the client",
+			script:       `echo "DROP DATABASE staging;" | mysql -h db`,
+			wantCommand:  "| mysql",
+			wantMessage:  "| mysql runs DROP DATABASE staging, which deletes the database staging and all its data",
+			wantSeverity: issue.SeverityError,
+		},
+		{
+			name:         "redis flushall",
+			script:       "redis-cli -h cache -n 0 FLUSHALL",
+			wantCommand:  "redis-cli",
+			wantMessage:  "redis-cli runs FLUSHALL, which deletes every key of every database",
+			wantSeverity: issue.SeverityError,
+		},
+		{
+			name:         "redis commands piped in",
+			script:       "printf 'SELECT 2\\nflushdb\\n' | redis-cli",
+			wantCommand:  "| redis-cli",
+			wantMessage:  "| redis-cli runs FLUSHDB, which deletes every key of the database",
+			wantSeverity: issue.SeverityError,
+		},
+		{
+			name:   "delete with a where clause",
+			script: `psql -c "DELETE FROM users WHERE id = 42"`,
+		},
+		{
+			name:   "drop in a string literal",
+			script: `psql -c "INSERT INTO log VALUES ('DROP TABLE users; TRUNCATE x')"`,
+		},
+		{
+			name:   "drop in a comment",
+			script: "psql <<EOF\n/* DROP DATABASE prod; */\nSELECT 1;\nEOF",
+		},
+		{
+			name:   "drop of an index",
+			script: `psql -c "DROP INDEX users_email_idx"`,
+		},
+		{
+			name:   "sqlite database only",
+			script: "sqlite3 drop.db",
+		},
+		{
+			name:   "redis read",
+			script: "redis-cli GET flushall",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issues := checkScript(t, NewRegistry(dbDestroyRule), tt.script)
+			if len(tt.wantCommand) == 0 {
+				assert.Empty(t, issues)
+				return
+			}
+
+			require.Len(t, issues, 1)
+			assert.Equal(t, "db-destroy", issues[0].RuleID)
+			assert.Equal(t, tt.wantCommand, issues[0].Command)
+			assert.Equal(t, tt.wantMessage, issues[0].Message)
+			assert.Equal(t, tt.wantSeverity, issues[0].Severity)
+		})
+	}
+}
+
+func TestTokenizeSQL(t *testing.T) {
+	tests := []struct {
+		sql  string
+		want []string
+	}{
+		{sql: "DROP TABLE a;", want: []string{"DROP", "TABLE", "a", ";"}},
+		{sql: "select 'it''s; fine' -- DROP\n;", want: []string{"select", "'it'", "'s; fine'", ";"}},
+		{sql: "DELETE FROM `t` /* x; y */ ;", want: []string{"DELETE", "FROM", "`t`", ";"}},
+		{sql: "DO $$ BEGIN DELETE FROM t; END $$;", want: []string{"DO", "$$ BEGIN DELETE FROM t; END $$", ";"}},
+		{sql: "TRUNCATE public.t, ${TABLE}", want: []string{"TRUNCATE", "public.t", ",", "${TABLE}"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.sql, func(t *testing.T) {
+			var got []string
+			for _, token := range tokenizeSQL(tt.sql) {
+				got = append(got, token.value)
+			}
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,389 @@
+package hazardous
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/hiteshrepo/hazardous/pkg/command"
+	"github.com/hiteshrepo/hazardous/pkg/issue"
+)
+
+// pipCommands run pip, directly or as python -m pip.
+var pipCommands = []string{"pip", "pip3", "python", "python3"}
+
+// sshClients take ssh options with -o.
+var sshClients = []string{"ssh", "scp", "sftp"}
+
+// binDirs hold the programs found on the PATH of most systems.
+var binDirs = []string{
+	"/bin", "/sbin", "/usr/bin", "/usr/sbin", "/usr/local/bin", "/usr/local/sbin",
+	"~/bin", "~/.local/bin", "$HOME/bin", "$HOME/.local/bin",
+}
+
+// falseValues are the values git reads as false.
+var falseValues = []string{"false", "no", "off", "0", ""}
+
+var tlsBypassRule = &commandRule{
+	id:          "tls-bypass",
+	description: "TLS certificate verification turned off for a download, clone or package install",
+	severity:    issue.SeverityWarning,
+	label:       "curl -k",
+	message:     "curl -k turns off certificate verification",
+	fix:         "keep certificate verification on, and trust a private CA with curl --cacert, GIT_SSL_CAINFO or pip --cert instead",
+	commands:    append([]string{"curl", "wget", "git"}, pipCommands...),
+	classify:    classifyTLSBypass,
+}
+
+var hostKeyRule = &commandRule{
+	id:          "ssh-host-key",
+	description: "ssh connection accepting any host key",
+	severity:    issue.SeverityWarning,
+	label:       "ssh -o StrictHostKeyChecking=no",
+	message:     "ssh -o StrictHostKeyChecking=no accepts any host key",
+	fix:         "add the host key to known_hosts beforehand, checked against a published fingerprint, or use StrictHostKeyChecking=accept-new",
+	commands:    sshClients,
+	options:     []string{"option"},
+	classify:    classifyHostKey,
+}
+
+var insecureInstallRule = &commandRule{
+	id:          "insecure-install",
+	description: "program or package downloaded over plain HTTP or without certificate checks and installed",
+	severity:    issue.SeverityError,
+	label:       "curl",
+	message:     "curl installs a program downloaded over plain HTTP",
+	fix:         "download it over https:// with certificate checks on, and check it against a published checksum before installing it",
+	commands:    append([]string{"curl", "wget"}, pipCommands...),
+	classify:    classifyInsecureInstall,
+}
+
+// optionFlag returns the option m of c as written, e.g. -k or --insecure,
+// with abbreviations of long options spelled out.
+func optionFlag(c *call, m command.Match) string {
+	value, _ := literalWord(c.args[m.Index])
+	if !strings.HasPrefix(value, "--") && len(m.Option.Short) > 0 {
+		return "-" + m.Option.Short[:1]
+	}
+
+	name, _, _ := strings.Cut(strings.TrimPrefix(value, "--"), "=")
+	for _, long := range m.Option.Long {
+		if strings.HasPrefix(long, name) {
+			return "--" + long
+		}
+	}
+
+	return value
+}
+
+// pipCall returns the call of pip made by c, either directly or as python -m
+// pip, along with the command line naming it, e.g. "python3 -m pip".
+func pipCall(c *call) (*call, string, bool) {
+	if c.name == "pip" || c.name == "pip3" {
+		return c, c.name, true
+	}
+
+	if len(c.args) < 2 {
+		return nil, "", false
+	}
+
+	flag, _ := literalWord(c.args[0])
+	module, _ := literalWord(c.args[1])
+
+	if flag != "-m" || module != "pip" {
+		return nil, "", false
+	}
+
+	pip := *c
+	pip.name, pip.word, pip.args = "pip", c.args[1], c.args[2:]
+
+	return &pip, c.name + " -m pip", true
+}
+
+// classifyTLSBypass keeps curl -k, wget --no-check-certificate, git with
+// http.sslVerify turned off and pip --trusted-host, naming the bypass.
+func classifyTLSBypass(ctx *Context, c *call, args *command.Parsed, found *issue.Issue) bool {
+	var target string
+
+	switch c.name {
+	case "curl", "wget":
+		m, ok := args.Lookup("insecure")
+		if c.name == "wget" {
+			m, ok = args.Lookup("no-check-certificate")
+		}
+
+		if !ok {
+			return false
+		}
+
+		found.Command = c.name + " " + optionFlag(c, m)
+		if f, _ := ctx.fetch(c); len(f.url) > 0 {
+			target = " for " + f.url
+		}
+
+	case "git":
+		var ok bool
+		if target, ok = ctx.gitSSLBypass(c, args, found); !ok {
+			return false
+		}
+
+	default:
+		pip, line, ok := pipCall(c)
+		if !ok {
+			return false
+		}
+
+		hosts := trustedHosts(ctx, pip, parseCall(pip))
+		if len(hosts) == 0 {
+			return false
+		}
+
+		found.Command = line + " --trusted-host"
+		found.Message = fmt.Sprintf("%s turns off certificate verification for %s and lets pip fetch packages from it over plain HTTP, "+
+			"so anyone on the network path can impersonate the package index", found.Command, nameList(hosts))
+		found.Fix = "serve the index over https:// with a certificate pip trusts, adding a private CA with --cert if needed"
+
+		return true
+	}
+
+	found.Message = fmt.Sprintf("%s turns off certificate verification%s, so anyone on the network path can impersonate the server",
+		found.Command, target)
+
+	return true
+}
+
+// gitSSLBypass returns what git turns off certificate verification for, when
+// it does so through -c http.sslVerify=false, git config or the
+// GIT_SSL_NO_VERIFY variable. The bypass is set as the command of found.
+func (ctx *Context) gitSSLBypass(c *call, args *command.Parsed, found *issue.Issue) (string, bool) {
+	sub, ok := c.subcommand(args)
+
+	target := ""
+	if ok {
+		target = " for git " + strings.TrimPrefix(sub.name, "git-")
+	}
+
+	for _, m := range args.Options {
+		// -c http.sslVerify, without a value, turns it on
+		key, value, set := strings.Cut(m.Value, "=")
+		if m.Option.Name == "config" && set && sslVerifyKey(key) && slices.Contains(falseValues, strings.ToLower(value)) {
+			found.Command = "git -c " + m.Value
+			return target, true
+		}
+	}
+
+	for _, assign := range c.expr.Assigns {
+		if assign.Name != nil && assign.Name.Value == "GIT_SSL_NO_VERIFY" && assign.Value != nil {
+			found.Command = fmt.Sprintf("GIT_SSL_NO_VERIFY=%s git", ctx.wordText(assign.Value))
+			return target, true
+		}
+	}
+
+	if !ok || sub.name != "git-config" {
+		return "", false
+	}
+
+	configArgs := parseCall(sub)
+	operands := literalOperands(sub, configArgs)
+
+	if len(operands) > 0 && operands[0] == "set" {
+		// git config set KEY VALUE, from git 2.46
+		operands = operands[1:]
+	}
+
+	if configArgs.Has("read") || len(operands) != 2 || !sslVerifyKey(operands[0]) ||
+		!slices.Contains(falseValues, strings.ToLower(operands[1])) {
+		return "", false
+	}
+
+	scope := "--local"
+	if m, ok := configArgs.Lookup("scope"); ok {
+		scope = optionFlag(sub, m)
+	}
+
+	found.Command = "git config " + strings.Join(operands, " ")
+	if scope != "--local" {
+		found.Command = fmt.Sprintf("git config %s %s", scope, strings.Join(operands, " "))
+	}
+
+	switch scope {
+	case "--global":
+		return " for every repository of the user", true
+	case "--system":
+		return " for every repository on the host", true
+	case "--worktree":
+		return " for every later fetch and push of the worktree", true
+	}
+
+	return " for every later fetch and push of the repository", true
+}
+
+// sslVerifyKey reports whether key is the git setting turning certificate
+// verification on or off, http.sslVerify or http.<url>.sslVerify.
+func sslVerifyKey(key string) bool {
+	key = strings.ToLower(key)
+	return strings.HasPrefix(key, "http.") && strings.HasSuffix(key, ".sslverify")
+}
+
+// trustedHosts returns the hosts given to pip with --trusted-host.
+func trustedHosts(ctx *Context, c *call, args *command.Parsed) []string {
+	var hosts []string
+
+	for _, m := range args.Options {
+		if m.Option.Name == "trusted-host" {
+			hosts = append(hosts, ctx.optionValue(c, m))
+		}
+	}
+
+	return hosts
+}
+
+// classifyHostKey keeps ssh connections made with StrictHostKeyChecking
+// turned off, which accept the key of any host, including one impersonating
+// the server.
+func classifyHostKey(ctx *Context, c *call, args *command.Parsed, found *issue.Issue) bool {
+	var strict, known string
+
+	for _, m := range args.Options {
+		if m.Option.Name != "option" {
+			continue
+		}
+
+		value := ctx.optionValue(c, m)
+
+		key, setting, ok := strings.Cut(value, "=")
+		if !ok {
+			key, setting, _ = strings.Cut(value, " ")
+		}
+
+		switch key, setting = strings.ToLower(strings.TrimSpace(key)), strings.ToLower(strings.TrimSpace(setting)); {
+		case key == "stricthostkeychecking" && (setting == "no" || setting == "off"):
+			strict = "-o " + value
+		case key == "userknownhostsfile" && setting == "/dev/null":
+			known = "-o " + value
+		}
+	}
+
+	if len(strict) == 0 {
+		// otherwise unknown host keys are still prompted for or refused
+		return false
+	}
+
+	server := "the server"
+	if host := sshHost(ctx, c, args); len(host) > 0 {
+		server = host
+	}
+
+	found.Command = c.name + " " + strict
+	found.Message = fmt.Sprintf("%s accepts any host key, so anyone on the network path can impersonate %s", found.Command, server)
+
+	if len(known) > 0 {
+		// host keys are never remembered, so even a later change goes
+		// unnoticed
+		found.Command += " " + known
+		found.Message = fmt.Sprintf("%s accepts any host key and forgets it, so anyone on the network path can impersonate %s "+
+			"on every connection", found.Command, server)
+	}
+
+	return true
+}
+
+// sshHost returns the host c connects to, e.g. deploy@prod, or "" when it is
+// not known: the destination of ssh and sftp, or the host of the first remote
+// path given to scp.
+func sshHost(ctx *Context, c *call, args *command.Parsed) string {
+	for _, operand := range ctx.operandTexts(c, args) {
+		if c.name != "scp" {
+			return strings.TrimPrefix(operand, "sftp://")
+		}
+
+		if host, _, ok := strings.Cut(operand, ":"); ok && !strings.Contains(host, "/") {
+			return host
+		}
+	}
+
+	return ""
+}
+
+// classifyInsecureInstall keeps programs saved to a directory on the PATH
+// after a download over plain HTTP or without certificate checks, and
+// packages pip fetches over plain HTTP, whose build scripts it runs.
+func classifyInsecureInstall(ctx *Context, c *call, args *command.Parsed, found *issue.Issue) bool {
+	if f, ok := ctx.fetch(c); ok {
+		if (!f.plain && len(f.insecure) == 0) || f.stdout || !slices.Contains(binDirs, path.Dir(f.file)) {
+			return false
+		}
+
+		found.Command = c.name
+		found.Message = fmt.Sprintf("%s saves %s, a program on the PATH, downloaded%s", c.name, f.file, f.transport())
+
+		if len(f.url) > 0 {
+			found.Message += " from " + f.url
+		}
+
+		found.Message += ", which anyone on the network path can tamper with"
+
+		return true
+	}
+
+	pip, line, ok := pipCall(c)
+	if !ok {
+		return false
+	}
+
+	pipArgs := parseCall(pip)
+
+	operands := literalOperands(pip, pipArgs)
+	if len(operands) == 0 || (operands[0] != "install" && operands[0] != "download") {
+		return false
+	}
+
+	found.Command = fmt.Sprintf("%s %s", line, operands[0])
+
+	for _, operand := range operands[1:] {
+		if plainURL(operand, "https") {
+			found.Message = fmt.Sprintf("%s fetches the package %s over plain HTTP and runs its build scripts, "+
+				"which anyone on the network path can tamper with", found.Command, operand)
+
+			return true
+		}
+	}
+
+	trusted := trustedHosts(ctx, pip, pipArgs)
+
+	for _, m := range pipArgs.Options {
+		switch m.Option.Name {
+		case "index-url", "extra-index-url", "find-links":
+		default:
+			continue
+		}
+
+		// pip ignores indexes served over plain HTTP unless their host is
+		// trusted
+		index := ctx.optionValue(pip, m)
+		if u, err := url.Parse(index); err != nil || u.Scheme != "http" || !trustedHost(u, trusted) {
+			continue
+		}
+
+		found.Message = fmt.Sprintf("%s fetches packages over plain HTTP from %s and runs their build scripts, "+
+			"which anyone on the network path can tamper with", found.Command, index)
+
+		return true
+	}
+
+	return false
+}
+
+// trustedHost reports whether u is served by one of the hosts given to pip
+// with --trusted-host, which may include a port.
+func trustedHost(u *url.URL, trusted []string) bool {
+	for _, host := range trusted {
+		if host == u.Host || host == u.Hostname() {
+			return true
+		}
+	}
+
+	return false
+}
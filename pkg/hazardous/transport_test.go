@@ -0,0 +1,193 @@
+package hazardous
+
+import (
+	"testing"
+
+	"github.com/hiteshrepo/hazardous/pkg/issue"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransportRules(t *testing.T) {
+	tests := []struct {
+		name         string
+		script       string
+		wantRule     string
+		wantCommand  string
+		wantMessage  string
+		wantSeverity issue.Severity
+	}{
+		{
+			name:         "curl insecure",
+			script:       "curl -fsSLk https://artifacts.internal/app.tgz -o app.tgz",
+			wantRule:     "tls-bypass",
+			wantCommand:  "curl -k",
+			wantMessage:  "curl -k turns off certificate verification for https://artifacts.internal/app.tgz, so anyone on the network path can impersonate the server",
+			wantSeverity: issue.SeverityWarning,
+		},
+		{
+			name:         "abbreviated long option",
+			script:       `curl --insec "$API/health"`,
+			wantRule:     "tls-bypass",
+			wantCommand:  "curl --insecure",
+			wantMessage:  "curl --insecure turns off certificate verification for $API/health, so anyone on the network path can impersonate the server",
+			wantSeverity: issue.SeverityWarning,
+		},
+		{
+			name:         "wget without certificate checks",
+			script:       "wget --no-check-certificate https://example.com/data.csv",
+			wantRule:     "tls-bypass",
+			wantCommand:  "wget --no-check-certificate",
+			wantMessage:  "wget --no-check-certificate turns off certificate verification for https://example.com/data.csv, so anyone on the network path can impersonate the server",
+			wantSeverity: issue.SeverityWarning,
+		},
+		{
+			name:         "git clone with sslVerify off",
+			script:       "git -c http.sslVerify=false clone https://git.internal/app.git",
+			wantRule:     "tls-bypass",
+			wantCommand:  "git -c http.sslVerify=false",
+			wantMessage:  "git -c http.sslVerify=false turns off certificate verification for git clone, so anyone on the network path can impersonate the server",
+			wantSeverity: issue.SeverityWarning,
+		},
+		{
+			name:         "sslVerify off in the global config",
+			script:       "git config --global http.sslverify no",
+			wantRule:     "tls-bypass",
+			wantCommand:  "git config --global http.sslverify no",
+			wantMessage:  "git config --global http.sslverify no turns off certificate verification for every repository of the user, so anyone on the network path can impersonate the server",
+			wantSeverity: issue.SeverityWarning,
+		},
+		{
+			name:         "sslVerify off for one remote",
+			script:       "git config set http.https://git.internal/.sslVerify false",
+			wantRule:     "tls-bypass",
+			wantCommand:  "git config http.https://git.internal/.sslVerify false",
+			wantMessage:  "git config http.https://git.internal/.sslVerify false turns off certificate verification for every later fetch and push of the repository, so anyone on the network path can impersonate the server",
+			wantSeverity: issue.SeverityWarning,
+		},
+		{
+			name:         "GIT_SSL_NO_VERIFY",
+			script:       "GIT_SSL_NO_VERIFY=1 git fetch origin",
+			wantRule:     "tls-bypass",
+			wantCommand:  "GIT_SSL_NO_VERIFY=1 git",
+			wantMessage:  "GIT_SSL_NO_VERIFY=1 git turns off certificate verification for git fetch, so anyone on the network path can impersonate the server",
+			wantSeverity: issue.SeverityWarning,
+		},
+		{
+			name:         "pip trusted host",
+			script:       "python3 -m pip install --trusted-host pypi.internal -r requirements.txt",
+			wantRule:     "tls-bypass",
+			wantCommand:  "python3 -m pip --trusted-host",
+			wantMessage:  "python3 -m pip --trusted-host turns off certificate verification for pypi.internal and lets pip fetch packages from it over plain HTTP, so anyone on the network path can impersonate the package index",
+			wantSeverity: issue.SeverityWarning,
+		},
+		{
+			name:         "ssh accepting any host key",
+			script:       "ssh -o StrictHostKeyChecking=no deploy@prod ./restart.sh",
+			wantRule:     "ssh-host-key",
+			wantCommand:  "ssh -o StrictHostKeyChecking=no",
+			wantMessage:  "ssh -o StrictHostKeyChecking=no accepts any host key, so anyone on the network path can impersonate deploy@prod",
+			wantSeverity: issue.SeverityWarning,
+		},
+		{
+			name:         "scp forgetting host keys",
+			script:       "scp -oStrictHostKeyChecking=no -o UserKnownHostsFile=/dev/null build.tgz ci@10.0.0.5:/srv",
+			wantRule:     "ssh-host-key",
+			wantCommand:  "scp -o StrictHostKeyChecking=no -o UserKnownHostsFile=/dev/null",
+			wantMessage:  "scp -o StrictHostKeyChecking=no -o UserKnownHostsFile=/dev/null accepts any host key and forgets it, so anyone on the network path can impersonate ci@10.0.0.5 on every connection",
+			wantSeverity: issue.SeverityWarning,
+		},
+		{
+			name:         "program saved on the PATH over plain HTTP",
+			script:       "sudo curl -L http://dl.example.com/kubectl -o /usr/local/bin/kubectl",
+			wantRule:     "insecure-install",
+			wantCommand:  "sudo curl",
+			wantMessage:  "curl saves /usr/local/bin/kubectl, a program on the PATH, downloaded over plain HTTP from http://dl.example.com/kubectl, which anyone on the network path can tamper with, running as root through sudo",
+			wantSeverity: issue.SeverityError,
+		},
+		{
+			name:         "program saved on the PATH without certificate checks",
+			script:       "wget --no-check-certificate -P ~/.local/bin https://example.com/tool",
+			wantRule:     "insecure-install",
+			wantCommand:  "wget",
+			wantMessage:  "wget saves ~/.local/bin/tool, a program on the PATH, downloaded with certificate checks turned off (--no-check-certificate) from https://example.com/tool, which anyone on the network path can tamper with",
+			wantSeverity: issue.SeverityError,
+		},
+		{
+			name:         "package over plain HTTP",
+			script:       "pip install http://files.internal/app-1.0.tar.gz",
+			wantRule:     "insecure-install",
+			wantCommand:  "pip install",
+			wantMessage:  "pip install fetches the package http://files.internal/app-1.0.tar.gz over plain HTTP and runs its build scripts, which anyone on the network path can tamper with",
+			wantSeverity: issue.SeverityError,
+		},
+		{
+			name:         "trusted index over plain HTTP",
+			script:       "pip3 install -i http://pypi.internal:8080/simple --trusted-host pypi.internal app",
+			wantRule:     "insecure-install",
+			wantCommand:  "pip3 install",
+			wantMessage:  "pip3 install fetches packages over plain HTTP from http://pypi.internal:8080/simple and runs their build scripts, which anyone on the network path can tamper with",
+			wantSeverity: issue.SeverityError,
+		},
+		{
+			name:   "curl with a private CA",
+			script: "curl --cacert ca.pem https://artifacts.internal/app.tgz -o app.tgz",
+		},
+		{
+			name:   "sslVerify turned on",
+			script: "git -c http.sslVerify clone https://git.internal/app.git",
+		},
+		{
+			name:   "sslVerify read",
+			script: "git config --get http.sslVerify",
+		},
+		{
+			name:   "new host keys accepted",
+			script: "ssh -o StrictHostKeyChecking=accept-new -o ConnectTimeout=5 deploy@prod uptime",
+		},
+		{
+			name:   "known hosts only forgotten",
+			script: "ssh -o UserKnownHostsFile=/dev/null deploy@prod uptime",
+		},
+		{
+			name:   "program saved over https",
+			script: "curl -fsSL https://dl.example.com/kubectl -o /usr/local/bin/kubectl",
+		},
+		{
+			name:   "download over plain HTTP outside the PATH",
+			script: "curl -o data.json http://example.com/data.json",
+		},
+		{
+			name:   "untrusted index over plain HTTP",
+			script: "pip install -i http://pypi.internal/simple app",
+		},
+		{
+			name:   "other python module",
+			script: "python3 -m venv --trusted-host .venv",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issues := checkScript(t, NewRegistry(tlsBypassRule, hostKeyRule, insecureInstallRule), tt.script)
+			if len(tt.wantCommand) == 0 {
+				assert.Empty(t, issues)
+				return
+			}
+
+			// a download on the PATH without certificate checks is also a
+			// TLS bypass
+			var matched []issue.Issue
+			for _, found := range issues {
+				if found.RuleID == tt.wantRule {
+					matched = append(matched, found)
+				}
+			}
+
+			require.Len(t, matched, 1)
+			assert.Equal(t, tt.wantCommand, matched[0].Command)
+			assert.Equal(t, tt.wantMessage, matched[0].Message)
+			assert.Equal(t, tt.wantSeverity, matched[0].Severity)
+		})
+	}
+}
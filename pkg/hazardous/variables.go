@@ -0,0 +1,1139 @@
+package hazardous
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hiteshrepo/hazardous/pkg/helpers"
+	"mvdan.cc/sh/syntax"
+)
+
+// varState classifies what a variable may hold at a given point of a script.
+type varState int
+
+const (
+	// varUnknown is used for expansions the analysis does not track.
+	varUnknown varState = iota
+	// varSet means the variable holds a non-empty value.
+	varSet
+	// varMaybeEmpty means the variable may hold the empty string, e.g.
+	// because it was read from input or only assigned on some code paths.
+	varMaybeEmpty
+	// varEmpty means the variable holds the empty string.
+	varEmpty
+	// varUnset means the variable was never assigned or was unset.
+	varUnset
+)
+
+// origin is a position in the scanned source, or in shell code embedded in it
+// when script is set.
+type origin struct {
+	pos    syntax.Pos
+	script *script
+}
+
+func (o origin) valid() bool { return o.pos.IsValid() }
+
+// binding is what is known about a variable at a given point of a script.
+type binding struct {
+	state varState
+
+	// pos locates the assignment, read or unset that produced the state. It
+	// is invalid when the variable was never assigned.
+	pos origin
+
+	// how describes the origin of the state, e.g. "read from input".
+	how string
+
+	// value is the value of variables assigned a constant, empty otherwise.
+	value string
+
+	// nounset is set when `set -u` is in effect, so that expanding an unset
+	// variable aborts the script instead of producing an empty string.
+	nounset bool
+
+	// checked is set for variables only known to be set because a test such
+	// as [ -n "$VAR" ] guards the code, which says nothing of other paths.
+	checked bool
+
+	// exported is set for variables passed on to the commands the script
+	// runs, such as the shell of bash -c.
+	exported bool
+
+	// expr, line and fix are set for make references in recipes: the
+	// reference as written, the line of the make assignment that defined it
+	// and how to guard it.
+	expr string
+	line uint
+	fix  string
+}
+
+// mayBeEmpty reports whether an expansion with this binding may produce an
+// empty string.
+func (b binding) mayBeEmpty() bool {
+	switch b.state {
+	case varMaybeEmpty, varEmpty:
+		return true
+	case varUnset:
+		return !b.nounset
+	default:
+		return false
+	}
+}
+
+// environmentVars are assumed to be set by the environment of any script.
+var environmentVars = map[string]bool{
+	"HOME": true, "PATH": true, "PWD": true, "USER": true, "LOGNAME": true,
+	"SHELL": true, "HOSTNAME": true, "UID": true, "EUID": true, "PPID": true,
+	"RANDOM": true, "LINENO": true, "SECONDS": true, "BASH": true,
+	"BASH_SOURCE": true, "BASH_VERSION": true, "FUNCNAME": true, "IFS": true,
+	"OSTYPE": true, "HOSTTYPE": true, "MACHTYPE": true, "SHLVL": true,
+	"?": true, "#": true, "$": true, "!": true, "0": true, "-": true,
+}
+
+// varTable holds the result of the variable analysis of a script: the
+// binding of every variable expansion at the point where it is evaluated.
+type varTable struct {
+	uses map[*syntax.ParamExp]binding
+
+	// scripts holds the shell code embedded in the arguments of commands,
+	// such as bash -c or eval, and in here-documents fed to a shell, keyed by
+	// the command or redirect it was found in. The rules check it as well.
+	scripts map[syntax.Node][]*script
+
+	// piped holds the pipes reading the output of an earlier stage, such as
+	// b | c in a | b | c, which nests as a | (b | c).
+	piped map[*syntax.BinaryCmd]bool
+}
+
+// scriptsIn returns the shell code embedded in node.
+func (t *varTable) scriptsIn(node syntax.Node) []*script {
+	if t == nil {
+		return nil
+	}
+
+	return t.scripts[node]
+}
+
+// link binds the placeholders in embedded code to the expansions of the
+// enclosing script they stand for.
+func (t *varTable) link() {
+	for _, scripts := range t.scripts {
+		for _, sc := range scripts {
+			for inner, outer := range sc.links {
+				if b, ok := t.uses[outer]; ok {
+					t.uses[inner] = b
+				} else {
+					delete(t.uses, inner)
+				}
+			}
+		}
+	}
+}
+
+// isPiped reports whether the pipe cmd reads the output of an earlier stage.
+func (t *varTable) isPiped(cmd *syntax.BinaryCmd) bool {
+	if t == nil {
+		return false
+	}
+
+	return t.piped[cmd]
+}
+
+func (t *varTable) lookup(pe *syntax.ParamExp) (binding, bool) {
+	if t == nil {
+		return binding{}, false
+	}
+
+	b, ok := t.uses[pe]
+
+	return b, ok
+}
+
+// scope is the set of variable bindings at a given point of a script. The
+// scope of a branch only holds what the branch changes, and looks everything
+// else up in the scope it branched from, so branching costs nothing however
+// many variables are known.
+type scope struct {
+	vars   map[string]binding
+	parent *scope
+}
+
+func newScope() *scope {
+	return &scope{vars: make(map[string]binding)}
+}
+
+// branch returns the scope of a code path starting from s.
+func (s *scope) branch() *scope {
+	return &scope{vars: make(map[string]binding), parent: s}
+}
+
+// copy returns a scope holding the bindings of s, independent of it.
+func (s *scope) copy() *scope {
+	c := newScope()
+
+	for ; s != nil; s = s.parent {
+		for name, b := range s.vars {
+			if _, ok := c.vars[name]; !ok {
+				c.vars[name] = b
+			}
+		}
+	}
+
+	return c
+}
+
+func (s *scope) lookup(name string) (binding, bool) {
+	for ; s != nil; s = s.parent {
+		if b, ok := s.vars[name]; ok {
+			return b, true
+		}
+	}
+
+	return binding{}, false
+}
+
+func (s *scope) get(name string) binding {
+	if b, ok := s.lookup(name); ok {
+		return b
+	}
+
+	if environmentVars[name] {
+		return binding{state: varSet, how: "set by the environment", exported: true}
+	}
+
+	if isPositional(name) {
+		return binding{state: varMaybeEmpty, how: "a script or function argument"}
+	}
+
+	return binding{state: varUnset, how: "never assigned"}
+}
+
+func (s *scope) set(name string, b binding) {
+	s.vars[name] = b
+}
+
+// exports returns the scope of a new shell started from s, as with bash -c,
+// which only inherits the exported variables. Others are not known there:
+// the shell may still get them from the environment the script runs in.
+func (s *scope) exports() *scope {
+	c := s.copy()
+
+	for name, b := range c.vars {
+		if !b.exported {
+			c.vars[name] = binding{state: varMaybeEmpty, pos: b.pos, how: "not exported to the new shell"}
+		}
+	}
+
+	return c
+}
+
+// join merges the code paths that branched from s, and s itself for a path
+// that skips them all, into s once they join again. Only the variables the
+// branches changed are merged, and the branches are not used afterwards.
+func (s *scope) join(branches ...*scope) *scope {
+	merged := make(map[string]binding)
+
+	for _, br := range branches {
+		for c := br; c != s && c != nil; c = c.parent {
+			for name := range c.vars {
+				if _, ok := merged[name]; ok {
+					continue
+				}
+
+				b := branches[0].get(name)
+				for _, other := range branches[1:] {
+					b = mergeBinding(b, other.get(name))
+				}
+
+				merged[name] = b
+			}
+		}
+	}
+
+	for name, b := range merged {
+		s.vars[name] = b
+	}
+
+	return s
+}
+
+func mergeBinding(a, b binding) binding {
+	merged := mergeState(a, b)
+	// a variable exported on some paths only may not reach a new shell
+	merged.exported = a.exported && b.exported
+
+	return merged
+}
+
+func mergeState(a, b binding) binding {
+	if a.state == b.state {
+		return a
+	}
+
+	if a.state == varSet {
+		a, b = b, a
+	}
+
+	if b.checked {
+		// the variable is as it was on the paths the test did not guard
+		return a
+	}
+
+	if b.state == varSet {
+		return binding{state: varMaybeEmpty, pos: b.pos, how: "only assigned on some code paths", nounset: a.nounset}
+	}
+
+	// the reason of a binding that may be empty holds for the merged one,
+	// while "never assigned" or "assigned an empty value" only hold on some
+	// paths
+	if b.state == varMaybeEmpty {
+		a, b = b, a
+	}
+
+	if a.state == varMaybeEmpty {
+		return binding{state: varMaybeEmpty, pos: a.pos, how: a.how, nounset: a.nounset}
+	}
+
+	pos := a.pos
+	if !pos.valid() {
+		pos = b.pos
+	}
+
+	return binding{state: varMaybeEmpty, pos: pos, how: "empty or unset depending on the code path", nounset: a.nounset}
+}
+
+// varTracker walks a script in execution order, following assignments,
+// reads and unsets of variables and recording the binding of every
+// expansion. Branches are merged conservatively: a variable assigned on only
+// some paths may be empty after them.
+type varTracker struct {
+	table   *varTable
+	nounset bool
+	// allexport is set by set -a, which exports every variable assigned.
+	allexport bool
+
+	// assigned holds the last assignment of every variable anywhere in the
+	// script. Function bodies and traps are checked against it, since they
+	// may run at any point after the assignments they rely on.
+	assigned *scope
+	deferred []deferredCode
+
+	// script is the embedded code being tracked, nil for the script itself.
+	script *script
+	// pipe is the shell reading the output of the statement about to be
+	// tracked, for here-documents piped into it.
+	pipe *call
+}
+
+// deferredCode is code run at some later point, such as a function body or a
+// trap, and the embedded code it was found in.
+type deferredCode struct {
+	stmts  []*syntax.Stmt
+	script *script
+}
+
+// at returns the origin of a position in the code being tracked.
+func (t *varTracker) at(pos syntax.Pos) origin {
+	return origin{pos: pos, script: t.script}
+}
+
+// trackVariables runs the variable analysis over a parsed script.
+func trackVariables(file *syntax.File) *varTable {
+	t := &varTracker{
+		table: &varTable{
+			uses:    make(map[*syntax.ParamExp]binding),
+			scripts: make(map[syntax.Node][]*script),
+			piped:   make(map[*syntax.BinaryCmd]bool),
+		},
+		assigned: newScope(),
+	}
+
+	t.stmts(file.Stmts, newScope())
+
+	for i := 0; i < len(t.deferred); i++ {
+		t.script = t.deferred[i].script
+		t.stmts(t.deferred[i].stmts, t.assigned.copy())
+	}
+
+	t.table.link()
+
+	return t.table
+}
+
+func (t *varTracker) stmts(stmts []*syntax.Stmt, s *scope) *scope {
+	for _, st := range stmts {
+		s = t.stmt(st, s)
+	}
+
+	return s
+}
+
+func (t *varTracker) stmt(st *syntax.Stmt, s *scope) *scope {
+	if st == nil {
+		return s
+	}
+
+	for _, r := range st.Redirs {
+		t.uses(r.Word, s)
+		t.uses(r.Hdoc, s)
+	}
+
+	pipe := t.pipe
+	t.pipe = nil
+	s = t.heredocs(st, pipe, s)
+
+	if st.Background {
+		// runs concurrently in a subshell, nothing it assigns is visible
+		t.command(st.Cmd, s.branch())
+		return s
+	}
+
+	return t.command(st.Cmd, s)
+}
+
+func (t *varTracker) command(cmd syntax.Command, s *scope) *scope {
+	switch c := cmd.(type) {
+	case *syntax.CallExpr:
+		return t.call(c, s)
+
+	case *syntax.DeclClause:
+		for _, w := range c.Opts {
+			t.uses(w, s)
+		}
+
+		for _, a := range c.Assigns {
+			t.uses(a.Value, s)
+			t.arrayUses(a.Array, s)
+		}
+
+		for _, a := range c.Assigns {
+			switch {
+			case a.Name == nil:
+			case !a.Naked:
+				s = t.assign(s, a)
+			case c.Variant.Value == "local" || c.Variant.Value == "declare" || c.Variant.Value == "typeset":
+				if _, ok := s.lookup(a.Name.Value); !ok || c.Variant.Value == "local" {
+					s.set(a.Name.Value, binding{state: varEmpty, pos: t.at(a.Pos()), how: "declared without a value"})
+				}
+			}
+
+			if a.Name != nil && exports(c) {
+				b := s.get(a.Name.Value)
+				b.exported = true
+				s.set(a.Name.Value, b)
+				t.assigned.set(a.Name.Value, b)
+			}
+		}
+
+		return s
+
+	case *syntax.Block:
+		return t.stmts(c.Stmts, s)
+
+	case *syntax.Subshell:
+		t.stmts(c.Stmts, s.branch())
+		return s
+
+	case *syntax.IfClause:
+		s = t.stmts(c.Cond.Stmts, s)
+		cond := lastStmt(c.Cond.Stmts)
+		then := t.stmts(c.Then.Stmts, t.narrow(cond, s.branch(), true))
+
+		otherwise := t.narrow(cond, s.branch(), false)
+		if len(c.Else.Stmts) > 0 {
+			otherwise = t.stmts(c.Else.Stmts, otherwise)
+		}
+
+		// past a branch that exits, as in if [ -z "$DIR" ]; then exit 1; fi,
+		// only the other one went on
+		switch {
+		case exits(c.Then.Stmts):
+			return s.join(otherwise)
+		case exits(c.Else.Stmts):
+			return s.join(then)
+		}
+
+		return s.join(then, otherwise)
+
+	case *syntax.WhileClause:
+		s = t.stmts(c.Cond.Stmts, s)
+		return s.join(s, t.stmts(c.Do.Stmts, s.branch()))
+
+	case *syntax.ForClause:
+		body := s.branch()
+
+		if iter, ok := c.Loop.(*syntax.WordIter); ok {
+			for _, w := range iter.Items {
+				t.uses(w, s)
+			}
+
+			body.set(iter.Name.Value, binding{state: varSet, pos: t.at(iter.Name.Pos()), how: "a loop variable"})
+		} else {
+			t.uses(c.Loop, s)
+		}
+
+		return s.join(s, t.stmts(c.Do.Stmts, body))
+
+	case *syntax.CaseClause:
+		t.uses(c.Word, s)
+
+		var branches []*scope
+		exhaustive := false
+		for _, item := range c.Items {
+			for _, p := range item.Patterns {
+				t.uses(p, s)
+				if p.Lit() == "*" {
+					exhaustive = true
+				}
+			}
+
+			branches = append(branches, t.stmts(item.Stmts, s.branch()))
+		}
+
+		if len(branches) == 0 {
+			return s
+		}
+
+		if !exhaustive {
+			branches = append(branches, s)
+		}
+
+		return s.join(branches...)
+
+	case *syntax.BinaryCmd:
+		switch c.Op {
+		case syntax.AndStmt, syntax.OrStmt:
+			// the right-hand side runs when the left one succeeds for &&,
+			// and when it fails for ||
+			s = t.stmt(c.X, s)
+			y := t.stmt(c.Y, t.narrow(c.X, s.branch(), c.Op == syntax.AndStmt))
+
+			if exits([]*syntax.Stmt{c.Y}) {
+				// [ -n "$DIR" ] || exit 1
+				return t.narrow(c.X, s, c.Op == syntax.OrStmt)
+			}
+
+			return s.join(s, y)
+		default:
+			// each side of a pipeline runs in its own subshell
+			if y, ok := c.Y.Cmd.(*syntax.BinaryCmd); ok {
+				t.table.piped[y] = y.Op == syntax.Pipe || y.Op == syntax.PipeAll
+			}
+
+			ctx := t.context()
+			t.pipe = ctx.stdinShell(c.Y)
+			t.stmt(c.X, s.branch())
+
+			// the payload is decoded once the constants it is built from
+			// are known
+			if sc, ok := ctx.decodedPipeScript(c); ok {
+				t.track(c, sc, s)
+			}
+
+			t.stmt(c.Y, s.branch())
+			return s
+		}
+
+	case *syntax.FuncDecl:
+		t.deferred = append(t.deferred, deferredCode{stmts: []*syntax.Stmt{c.Body}, script: t.script})
+		return s
+
+	case *syntax.TimeClause:
+		return t.stmt(c.Stmt, s)
+
+	case *syntax.CoprocClause:
+		t.stmt(c.Stmt, s.branch())
+		return s
+
+	case nil:
+		return s
+
+	default:
+		t.uses(c, s)
+		return s
+	}
+}
+
+func (t *varTracker) call(c *syntax.CallExpr, s *scope) *scope {
+	for _, a := range c.Assigns {
+		t.uses(a.Value, s)
+		t.arrayUses(a.Array, s)
+	}
+
+	for _, w := range c.Args {
+		t.uses(w, s)
+	}
+
+	if len(c.Args) == 0 {
+		for _, a := range c.Assigns {
+			s = t.assign(s, a)
+		}
+
+		return s
+	}
+
+	s = t.embedded(c, s)
+
+	args := make([]string, len(c.Args))
+	for i, w := range c.Args {
+		args[i] = w.Lit()
+	}
+
+	switch args[0] {
+	case "unset":
+		functions := false
+		for i, arg := range args[1:] {
+			switch arg {
+			case "-f":
+				functions = true
+			case "-v", "":
+			default:
+				if !functions {
+					s.set(arg, binding{state: varUnset, pos: t.at(c.Args[i+1].Pos()), how: "unset", nounset: t.nounset})
+				}
+			}
+		}
+
+	case "read":
+		names := readNames(c.Args[1:])
+		if len(names) == 0 {
+			names = []*syntax.Word{{Parts: []syntax.WordPart{&syntax.Lit{ValuePos: c.Args[0].Pos(), Value: "REPLY"}}}}
+		}
+
+		for _, w := range names {
+			s.set(w.Lit(), binding{state: varMaybeEmpty, pos: t.at(w.Pos()), how: "read from input"})
+			t.assigned.set(w.Lit(), s.get(w.Lit()))
+		}
+
+	case "set":
+		t.setOptions(args[1:])
+	}
+
+	return s
+}
+
+// embedded tracks the shell code embedded in the arguments of c, or decoded
+// from a payload they hold, in the scope it runs in.
+func (t *varTracker) embedded(c *syntax.CallExpr, s *scope) *scope {
+	ctx := t.context()
+
+	for _, sc := range append(ctx.embed(c), ctx.decodedScripts(c)...) {
+		s = t.track(c, sc, s)
+	}
+
+	return s
+}
+
+// heredocs tracks the here-documents of st that are run as shell code.
+func (t *varTracker) heredocs(st *syntax.Stmt, pipe *call, s *scope) *scope {
+	ctx := t.context()
+
+	for _, r := range st.Redirs {
+		if sc, ok := ctx.heredoc(st, r, pipe); ok {
+			s = t.track(r, sc, s)
+		}
+	}
+
+	return s
+}
+
+// context returns a context resolving commands and constants from the
+// variables tracked so far.
+func (t *varTracker) context() *Context {
+	return &Context{vars: t.table, script: t.script}
+}
+
+// track records sc as embedded in node and tracks it in the scope it runs in.
+// Code nested too deep, or running itself again, is skipped.
+func (t *varTracker) track(node syntax.Node, sc *script, s *scope) *scope {
+	if !sc.nests(t.script) {
+		return s
+	}
+
+	t.table.scripts[node] = append(t.table.scripts[node], sc)
+	sc.parent = t.script
+
+	if sc.runs == runsLater {
+		t.deferred = append(t.deferred, deferredCode{stmts: sc.file.Stmts, script: sc})
+		return s
+	}
+
+	outer := t.script
+	t.script = sc
+
+	switch sc.runs {
+	case runsInline:
+		s = t.stmts(sc.file.Stmts, s)
+	case runsInSubshell:
+		t.stmts(sc.file.Stmts, s.exports())
+	case runsDetached:
+		t.stmts(sc.file.Stmts, newScope())
+	}
+
+	t.script = outer
+
+	return s
+}
+
+// readNames returns the variable names passed to the read builtin, skipping
+// its options and their arguments.
+func readNames(args []*syntax.Word) []*syntax.Word {
+	var names []*syntax.Word
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i].Lit()
+		if strings.HasPrefix(arg, "-") && len(names) == 0 {
+			if last := arg[len(arg)-1]; strings.IndexByte("adinNptu", last) >= 0 && len(arg) == 2 {
+				i++
+			}
+			continue
+		}
+
+		if helpers.IsShellName(arg) {
+			names = append(names, args[i])
+		}
+	}
+
+	return names
+}
+
+func (t *varTracker) setOptions(args []string) {
+	for i, arg := range args {
+		switch {
+		case arg == "-o" || arg == "+o":
+			if i+1 < len(args) && args[i+1] == "nounset" {
+				t.nounset = arg == "-o"
+			}
+			if i+1 < len(args) && args[i+1] == "allexport" {
+				t.allexport = arg == "-o"
+			}
+		case strings.HasPrefix(arg, "-") && !strings.HasPrefix(arg, "--"):
+			t.nounset = t.nounset || strings.Contains(arg, "u")
+			t.allexport = t.allexport || strings.Contains(arg, "a")
+		case strings.HasPrefix(arg, "+"):
+			t.nounset = t.nounset && !strings.Contains(arg, "u")
+			t.allexport = t.allexport && !strings.Contains(arg, "a")
+		}
+	}
+}
+
+// exports reports whether a declaration exports the variables it names, as
+// export and declare -x do.
+func exports(c *syntax.DeclClause) bool {
+	if c.Variant.Value == "export" {
+		return true
+	}
+
+	if c.Variant.Value != "declare" && c.Variant.Value != "typeset" {
+		return false
+	}
+
+	for _, w := range c.Opts {
+		if opt := w.Lit(); strings.HasPrefix(opt, "-") && strings.Contains(opt, "x") {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (t *varTracker) assign(s *scope, a *syntax.Assign) *scope {
+	if a.Name == nil {
+		return s
+	}
+
+	name := a.Name.Value
+	b := binding{state: varSet, pos: t.at(a.Pos()), how: "assigned"}
+
+	switch {
+	case a.Array != nil || a.Index != nil:
+		// arrays and array elements are not tracked
+	case a.Append:
+		if prev := s.get(name); prev.state == varSet || !t.wordMayBeEmpty(a.Value, s).mayBeEmpty() {
+			b.state = varSet
+		} else {
+			b = prev
+		}
+	default:
+		b = t.wordMayBeEmpty(a.Value, s)
+		b.pos = t.at(a.Pos())
+	}
+
+	// assigning an exported variable keeps it exported
+	b.exported = t.allexport || s.get(name).exported
+
+	s.set(name, b)
+	t.assigned.set(name, b)
+
+	return s
+}
+
+// wordMayBeEmpty returns the binding a variable assigned word would have.
+func (t *varTracker) wordMayBeEmpty(w *syntax.Word, s *scope) binding {
+	if w == nil {
+		return binding{state: varEmpty, how: "assigned an empty value"}
+	}
+
+	state, how := partsState(w.Parts, s)
+	switch state {
+	case varSet:
+		value, _ := constantValue(w.Parts, s)
+		return binding{state: varSet, how: "assigned", value: value}
+	case varEmpty:
+		if len(how) == 0 {
+			how = "assigned an empty value"
+		}
+		return binding{state: varEmpty, how: how}
+	default:
+		return binding{state: varMaybeEmpty, how: how}
+	}
+}
+
+// partsState classifies the concatenation of word parts: varSet if some part
+// is never empty, varEmpty if all of them are always empty and varMaybeEmpty
+// otherwise.
+func partsState(parts []syntax.WordPart, s *scope) (varState, string) {
+	result, how := varEmpty, ""
+
+	for _, part := range parts {
+		state, partHow := partState(part, s)
+
+		switch state {
+		case varSet:
+			return varSet, ""
+		case varMaybeEmpty:
+			if result != varMaybeEmpty {
+				result, how = varMaybeEmpty, partHow
+			}
+		case varEmpty:
+			if len(how) == 0 {
+				how = partHow
+			}
+		}
+	}
+
+	return result, how
+}
+
+func partState(part syntax.WordPart, s *scope) (varState, string) {
+	switch p := part.(type) {
+	case *syntax.Lit:
+		if len(p.Value) > 0 {
+			return varSet, ""
+		}
+		return varEmpty, ""
+
+	case *syntax.SglQuoted:
+		if len(p.Value) > 0 {
+			return varSet, ""
+		}
+		return varEmpty, ""
+
+	case *syntax.DblQuoted:
+		return partsState(p.Parts, s)
+
+	case *syntax.ParamExp:
+		b := paramBinding(p, s.get(p.Param.Value))
+		switch {
+		case b.state == varSet:
+			return varSet, ""
+		case b.state == varEmpty || b.state == varUnset:
+			return varEmpty, fmt.Sprintf("assigned from $%s, which is %s", p.Param.Value, b.how)
+		default:
+			return varMaybeEmpty, fmt.Sprintf("assigned from $%s, which may be empty", p.Param.Value)
+		}
+
+	case *syntax.CmdSubst:
+		return varMaybeEmpty, "assigned from command output"
+
+	default:
+		return varSet, ""
+	}
+}
+
+// constantValue returns the value of word parts made of literals and
+// variables holding constants.
+func constantValue(parts []syntax.WordPart, s *scope) (string, bool) {
+	var sb strings.Builder
+
+	for _, part := range parts {
+		switch p := part.(type) {
+		case *syntax.Lit:
+			sb.WriteString(p.Value)
+		case *syntax.SglQuoted:
+			sb.WriteString(p.Value)
+		case *syntax.DblQuoted:
+			value, ok := constantValue(p.Parts, s)
+			if !ok {
+				return "", false
+			}
+			sb.WriteString(value)
+		case *syntax.ParamExp:
+			b := paramBinding(p, s.get(p.Param.Value))
+			if len(b.value) == 0 {
+				return "", false
+			}
+			sb.WriteString(b.value)
+		default:
+			return "", false
+		}
+	}
+
+	return sb.String(), true
+}
+
+// paramBinding adjusts the binding of a variable for the way it is expanded:
+// ${VAR:?} aborts instead of expanding to nothing, ${VAR:-default} falls back
+// to its default, ${#VAR} is always a number, and so on.
+func paramBinding(pe *syntax.ParamExp, b binding) binding {
+	if pe.Length || pe.Width {
+		return binding{state: varSet}
+	}
+
+	if pe.Excl || pe.Index != nil || pe.Slice != nil || pe.Repl != nil {
+		b.value = ""
+	}
+
+	if pe.Exp == nil {
+		return b
+	}
+
+	defaultSet := pe.Exp.Word != nil && pe.Exp.Word.Lit() != ""
+
+	switch pe.Exp.Op {
+	case syntax.SubstColQuest, syntax.SubstQuest:
+		return binding{state: varSet, how: "checked with ${VAR:?}"}
+	case syntax.SubstColMinus, syntax.SubstMinus, syntax.SubstColAssgn, syntax.SubstAssgn:
+		if defaultSet || b.state == varSet {
+			return binding{state: varSet, how: "given a default value"}
+		}
+		return binding{state: varMaybeEmpty, pos: b.pos, how: b.how, nounset: b.nounset}
+	case syntax.SubstColPlus, syntax.SubstPlus:
+		return binding{state: varMaybeEmpty, pos: b.pos, how: "only expanded when set", nounset: b.nounset}
+	default:
+		// pattern removal and case conversion may empty any value
+		if b.state == varSet {
+			return binding{state: varMaybeEmpty, pos: b.pos, how: "modified by a parameter expansion"}
+		}
+		b.value = ""
+		return b
+	}
+}
+
+// uses records the binding of every expansion within node. Command and
+// process substitutions are analysed as subshells.
+func (t *varTracker) uses(node syntax.Node, s *scope) {
+	if node == nil {
+		return
+	}
+
+	if w, ok := node.(*syntax.Word); ok && w == nil {
+		return
+	}
+
+	syntax.Walk(node, func(n syntax.Node) bool {
+		switch n := n.(type) {
+		case *syntax.ParamExp:
+			if n.Param != nil {
+				b := s.get(n.Param.Value)
+				if b.state == varUnset {
+					b.nounset = t.nounset
+				}
+				t.table.uses[n] = paramBinding(n, b)
+				t.paramAssign(n, s)
+			}
+
+		case *syntax.CmdSubst:
+			t.stmts(n.Stmts, s.branch())
+			return false
+
+		case *syntax.ProcSubst:
+			t.stmts(n.Stmts, s.branch())
+			return false
+		}
+
+		return true
+	})
+}
+
+// paramAssign updates s after expansions that assign their variable or abort
+// the script when it is not set: ${VAR:=default} assigns the default and
+// ${VAR:?} makes sure later statements see VAR set. ${VAR=default} and
+// ${VAR?} leave a variable assigned an empty value as it is.
+func (t *varTracker) paramAssign(pe *syntax.ParamExp, s *scope) {
+	if pe.Exp == nil || pe.Excl || pe.Index != nil {
+		return
+	}
+
+	name := pe.Param.Value
+	if prev := s.get(name); prev.state == varSet ||
+		prev.state == varEmpty && (pe.Exp.Op == syntax.SubstAssgn || pe.Exp.Op == syntax.SubstQuest) {
+		return
+	}
+
+	switch pe.Exp.Op {
+	case syntax.SubstColAssgn, syntax.SubstAssgn:
+		b := t.wordMayBeEmpty(pe.Exp.Word, s)
+		b.pos = t.at(pe.Pos())
+		if b.state == varSet {
+			b.how = "given a default value"
+		}
+
+		s.set(name, b)
+		t.assigned.set(name, b)
+
+	case syntax.SubstColQuest, syntax.SubstQuest:
+		s.set(name, binding{state: varSet, pos: t.at(pe.Pos()), how: "checked with ${VAR:?}"})
+	}
+}
+
+// narrow updates s, in place, for the code run when the condition st
+// succeeded or failed: [ -n "$VAR" ] succeeding, or [ -z "$VAR" ] failing,
+// means that VAR is not empty.
+func (t *varTracker) narrow(st *syntax.Stmt, s *scope, success bool) *scope {
+	if st == nil {
+		return s
+	}
+
+	if st.Negated {
+		success = !success
+	}
+
+	if c, ok := st.Cmd.(*syntax.BinaryCmd); ok {
+		switch {
+		case c.Op == syntax.AndStmt && success:
+			return t.narrow(c.Y, t.narrow(c.X, s, true), true)
+		case c.Op == syntax.OrStmt && !success:
+			return t.narrow(c.Y, t.narrow(c.X, s, false), false)
+		}
+
+		return s
+	}
+
+	name, nonEmpty, ok := testedVar(st.Cmd)
+	if ok && nonEmpty == success && s.get(name).state != varSet {
+		s.set(name, binding{state: varSet, pos: t.at(st.Pos()), how: "checked by a test", checked: true})
+	}
+
+	return s
+}
+
+// testedVar returns the variable whose emptiness cmd tests, and whether the
+// test succeeds when it is not empty, as in [ -n "$VAR" ], [ "$VAR" ],
+// test -z "$VAR" and [[ -n $VAR ]].
+func testedVar(cmd syntax.Command) (name string, nonEmpty, ok bool) {
+	switch c := cmd.(type) {
+	case *syntax.CallExpr:
+		args := c.Args
+		if len(args) == 0 {
+			return "", false, false
+		}
+
+		switch args[0].Lit() {
+		case "[":
+			if len(args) < 2 || args[len(args)-1].Lit() != "]" {
+				return "", false, false
+			}
+			args = args[1 : len(args)-1]
+		case "test":
+			args = args[1:]
+		default:
+			return "", false, false
+		}
+
+		switch {
+		case len(args) == 1:
+			name, ok = expandedVar(args[0])
+			return name, true, ok
+		case len(args) == 2 && (args[0].Lit() == "-n" || args[0].Lit() == "-z"):
+			name, ok = expandedVar(args[1])
+			return name, args[0].Lit() == "-n", ok
+		}
+
+	case *syntax.TestClause:
+		switch x := c.X.(type) {
+		case *syntax.Word:
+			name, ok = expandedVar(x)
+			return name, true, ok
+		case *syntax.UnaryTest:
+			if w, isWord := x.X.(*syntax.Word); isWord && (x.Op == syntax.TsNempStr || x.Op == syntax.TsEmpStr) {
+				name, ok = expandedVar(w)
+				return name, x.Op == syntax.TsNempStr, ok
+			}
+		}
+	}
+
+	return "", false, false
+}
+
+// expandedVar returns the variable a word expands as a whole, e.g. VAR for
+// "$VAR" or ${VAR}.
+func expandedVar(w *syntax.Word) (string, bool) {
+	parts := w.Parts
+	if len(parts) == 1 {
+		if dq, ok := parts[0].(*syntax.DblQuoted); ok {
+			parts = dq.Parts
+		}
+	}
+
+	if len(parts) != 1 {
+		return "", false
+	}
+
+	pe, ok := parts[0].(*syntax.ParamExp)
+	if !ok || pe.Param == nil || pe.Exp != nil || pe.Length || pe.Width || pe.Excl ||
+		pe.Index != nil || pe.Slice != nil || pe.Repl != nil {
+		return "", false
+	}
+
+	return pe.Param.Value, true
+}
+
+// exits reports whether stmts always leave the script or function, as the
+// branch of if [ -z "$DIR" ]; then echo "DIR is required" >&2; exit 1; fi
+// does.
+func exits(stmts []*syntax.Stmt) bool {
+	last := lastStmt(stmts)
+	if last == nil {
+		return false
+	}
+
+	switch c := last.Cmd.(type) {
+	case *syntax.CallExpr:
+		return len(c.Args) > 0 && (c.Args[0].Lit() == "exit" || c.Args[0].Lit() == "return")
+	case *syntax.Block:
+		return exits(c.Stmts)
+	}
+
+	return false
+}
+
+// lastStmt returns the last of stmts, or nil if there are none.
+func lastStmt(stmts []*syntax.Stmt) *syntax.Stmt {
+	if len(stmts) == 0 {
+		return nil
+	}
+
+	return stmts[len(stmts)-1]
+}
+
+func (t *varTracker) arrayUses(arr *syntax.ArrayExpr, s *scope) {
+	if arr != nil {
+		t.uses(arr, s)
+	}
+}
+
+func isPositional(name string) bool {
+	if name == "@" || name == "*" {
+		return true
+	}
+
+	for _, c := range name {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+
+	return len(name) > 0
+}
@@ -0,0 +1,241 @@
+package hazardous
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hiteshrepo/hazardous/pkg/issue"
+	"github.com/hiteshrepo/hazardous/pkg/makefile"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func checkEmptyVars(t *testing.T, script string) []string {
+	t.Helper()
+
+	var messages []string
+	for _, found := range checkScript(t, NewRegistry(emptyVarRule), script) {
+		messages = append(messages, found.Message)
+	}
+
+	return messages
+}
+
+func TestEmptyVariableRule(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+		want   []string
+	}{
+		{
+			name:   "never assigned",
+			script: `rm -rf "$OUT_DIR/"*`,
+			want:   []string{`$OUT_DIR is never assigned, so rm may be given "/*"`},
+		},
+		{
+			name:   "assigned a constant",
+			script: "OUT_DIR=build\nrm -rf \"$OUT_DIR/\"*",
+		},
+		{
+			name:   "assigned an empty value",
+			script: "OUT_DIR=\"\"\nrm -rf $OUT_DIR/bin",
+			want:   []string{`$OUT_DIR is empty: assigned an empty value (line 1), so rm may be given "/bin"`},
+		},
+		{
+			name:   "copied from an unassigned variable",
+			script: "OUT_DIR=$BUILD\nrm -rf $OUT_DIR/bin",
+			want:   []string{`$OUT_DIR is empty: assigned from $BUILD, which is never assigned (line 1), so rm may be given "/bin"`},
+		},
+		{
+			name:   "unset",
+			script: "DIR=/opt/app\nunset DIR\nrm -rf $DIR/lib",
+			want:   []string{`$DIR is unset (line 2), so rm may be given "/lib"`},
+		},
+		{
+			name:   "emptied on one branch, never assigned on the other",
+			script: "if [ -f .clean ]; then\n  commit=\"\"\nfi\nrm -rf \"$commit\"/",
+			want:   []string{`$commit may be empty: empty or unset depending on the code path (line 2), so rm may be given "/"`},
+		},
+		{
+			name:   "read on one branch, never assigned on the other",
+			script: "if [ -t 0 ]; then\n  read -r commit\nfi\nrm -rf \"$commit\"/",
+			want:   []string{`$commit may be empty: read from input (line 2), so rm may be given "/"`},
+		},
+		{
+			name:   "later expansion widens the path",
+			script: "read -r NAME SUB\nrm -rf \"out$NAME/$SUB/..\"",
+			want:   []string{`$SUB may be empty: read from input (line 1), so rm may be given "out$NAME//.."`},
+		},
+		{
+			name:   "read from input",
+			script: "read -r -p 'dir: ' DIR\nrm -rf \"$DIR\"/*",
+			want:   []string{`$DIR may be empty: read from input (line 1), so rm may be given "/*"`},
+		},
+		{
+			name:   "run through sudo",
+			script: "read DIR\nsudo rm -rf \"$DIR\"/*",
+			want:   []string{`$DIR may be empty: read from input (line 1), so rm may be given "/*", running as root through sudo`},
+		},
+		{
+			name:   "run by find -exec",
+			script: "find . -exec rm -rf $PREFIX/{} +",
+			want:   []string{`$PREFIX is never assigned, so rm may be given "/{}"`},
+		},
+		{
+			name:   "command output",
+			script: "DIR=$(mktemp -d)\nchmod -R 700 \"$DIR/\"",
+			want:   []string{`$DIR may be empty: assigned from command output (line 1), so chmod may be given "/"`},
+		},
+		{
+			name:   "assigned on one branch only",
+			script: "if [ -n \"$CI\" ]; then\n  DIR=out\nfi\nrm -rf \"$DIR/cache\"",
+			want:   []string{`$DIR may be empty: only assigned on some code paths (line 2), so rm may be given "/cache"`},
+		},
+		{
+			name:   "assigned on every branch",
+			script: "case $1 in\n  a) DIR=a ;;\n  *) DIR=b ;;\nesac\nrm -rf \"$DIR/cache\"",
+		},
+		{
+			name:   "assignment in a subshell does not persist",
+			script: "(DIR=out)\nrm -rf $DIR/cache",
+			want:   []string{`$DIR is never assigned, so rm may be given "/cache"`},
+		},
+		{
+			name:   "script argument",
+			script: `rm -rf "$1/build"`,
+			want:   []string{`$1 may be empty: a script or function argument, so rm may be given "/build"`},
+		},
+		{
+			name:   "function body sees later assignments",
+			script: "cleanup() { rm -rf \"$WORK/tmp\"; }\nWORK=/var/work\ntrap cleanup EXIT",
+		},
+		{
+			name:   "guarded expansions",
+			script: "rm -rf \"${DIR:?}/\"* \"${OUT:-build}/\"* \"$HOME/.cache\"",
+		},
+		{
+			name:   "default assigned with :=",
+			script: ": \"${OUT:=/tmp/out}\"\nrm -rf \"$OUT/\"*",
+		},
+		{
+			name:   "empty default assigned with :=",
+			script: ": \"${OUT:=}\"\nrm -rf \"$OUT/\"*",
+			want:   []string{`$OUT is empty: assigned an empty value (line 1), so rm may be given "/*"`},
+		},
+		{
+			name:   "checked with :? beforehand",
+			script: ": \"${Y:?}\"\nrm -r \"$Y/\"",
+		},
+		{
+			name:   "checked with a test before &&",
+			script: `[ -n "$Z" ] && rm -r "$Z/"`,
+		},
+		{
+			name:   "checked with [[ ]] before ||",
+			script: `[[ -z $Z ]] || rm -r "$Z/"`,
+		},
+		{
+			name:   "checked in an if condition",
+			script: "if test \"$Z\"; then\n  rm -r \"$Z/\"\nfi",
+		},
+		{
+			name:   "if branch exiting on an empty variable",
+			script: "if [ -z \"$W\" ]; then exit 1; fi\nrm -r \"$W/\"",
+		},
+		{
+			name:   "guard exiting with ||",
+			script: "[ -n \"$W\" ] || { echo 'W is required' >&2; exit 1; }\nrm -r \"$W/\"",
+		},
+		{
+			name:   "test only guarding its own branch",
+			script: "if [ -n \"$W\" ]; then echo \"$W\"; fi\nrm -r \"$W/\"",
+			want:   []string{`$W is never assigned, so rm may be given "/"`},
+		},
+		{
+			name:   "guard that does not exit",
+			script: "[ -n \"$W\" ] || echo 'W is not set'\nrm -r \"$W/\"",
+			want:   []string{`$W is never assigned, so rm may be given "/"`},
+		},
+		{
+			name:   "nounset aborts on unset variables",
+			script: "set -euo pipefail\nrm -rf \"$DIR/\"*",
+		},
+		{
+			name:   "nounset does not catch empty values",
+			script: "set -u\nDIR=\nrm -rf \"$DIR/\"*",
+			want:   []string{`$DIR is empty: assigned an empty value (line 2), so rm may be given "/*"`},
+		},
+		{
+			name:   "argument that disappears entirely",
+			script: `rm -rf $DIR "$OTHER"`,
+		},
+		{
+			name:   "empty command substitution",
+			script: `rm -rf $(OUT_DIR)/*`,
+			want:   []string{`the command substitution may produce no output, so rm may be given "/*"`},
+		},
+		{
+			name:   "suffix left behind",
+			script: "rm -f $NAME.log\nchmod 644 $F.txt\nmv \"$BASE-old\" \"$BASE\"",
+		},
+		{
+			name:   "glob left behind",
+			script: `rm -f "$PREFIX"*`,
+			want:   []string{`$PREFIX is never assigned, so rm may be given "*"`},
+		},
+		{
+			name:   "relative path left behind",
+			script: `rm -rf "$DIR"build`,
+		},
+		{
+			name:   "not a destructive command",
+			script: `echo "$DIR/"*`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, checkEmptyVars(t, tt.script))
+		})
+	}
+}
+
+func TestEmptyVariableRuleSeverity(t *testing.T) {
+	issues := checkScript(t, NewRegistry(emptyVarRule), "read DIR\nrm -r $DIR/x $NEVER/y")
+	require.Len(t, issues, 2)
+
+	assert.Equal(t, issue.SeverityWarning, issues[0].Severity, "possibly empty variables are warnings")
+	assert.Equal(t, issue.SeverityError, issues[1].Severity, "certainly empty variables are errors")
+	assert.Equal(t, `use "${NEVER:?}" so the command aborts when the variable is empty`, issues[1].Fix)
+}
+
+func TestEmptyVariableRuleMakefile(t *testing.T) {
+	mf, err := makefile.Parse(strings.NewReader(`OUT_DIR := ""
+BUILD = build
+clean:
+	rm -rf ${OUT_DIR}/* $(BUILD)/* $(DIST)/* $$TMP/x
+	rm -f $(DESTDIR)$(BINDIR)/app $(NAME).tar.gz
+`), "Makefile")
+	require.NoError(t, err)
+
+	issues, err := NewRegistry(emptyVarRule).CheckMakefile("Makefile", mf)
+	require.NoError(t, err)
+
+	var messages []string
+	for _, found := range issues {
+		messages = append(messages, found.Message)
+	}
+
+	assert.Equal(t, []string{
+		`${OUT_DIR} is empty: assigned an empty value (line 1), so rm may be given "/*"`,
+		`$(DIST) may be empty: not defined in the Makefile and empty unless set in the environment, so rm may be given "/*"`,
+		`$TMP is never assigned, so rm may be given "/x"`,
+	}, messages)
+
+	require.Len(t, issues, 3)
+	assert.Equal(t, issue.SeverityError, issues[0].Severity)
+	assert.Equal(t, uint(4), issues[0].Line)
+	assert.Equal(t, uint(9), issues[0].Col)
+	assert.Equal(t, "give OUT_DIR a non-empty value or guard the recipe with $(if ${OUT_DIR},,$(error OUT_DIR is empty))", issues[0].Fix)
+	assert.Equal(t, issue.SeverityWarning, issues[1].Severity)
+}
@@ -39,3 +39,29 @@ func ExtractVarName(arg string) (string, string) {
 
 	return "", ""
 }
+
+// IsShellName reports whether s is a valid shell variable name: a letter or
+// underscore followed by letters, digits or underscores.
+//
+// Parameters:
+// - s: The candidate name, e.g. OUT_DIR.
+//
+// Returns:
+// - true if s can be assigned or expanded as a shell variable.
+func IsShellName(s string) bool {
+	if len(s) == 0 {
+		return false
+	}
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '_', 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z':
+		case i > 0 && '0' <= c && c <= '9':
+		default:
+			return false
+		}
+	}
+
+	return true
+}
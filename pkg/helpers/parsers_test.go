@@ -0,0 +1,27 @@
+package helpers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsShellName(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{name: "OUT_DIR", want: true},
+		{name: "_tmp2", want: true},
+		{name: "", want: false},
+		{name: "2DIR", want: false},
+		{name: "OUT-DIR", want: false},
+		{name: "DIR)", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, IsShellName(tt.name))
+		})
+	}
+}
@@ -2,23 +2,71 @@ package issue
 
 import (
 	"fmt"
-	"log"
+	"strings"
 )
 
+// Category groups findings by the kind of harm they warn about. Findings
+// about lost data or untrusted code have no category.
+type Category string
+
+// CategoryDisruption marks commands that disrupt the host they run on, e.g.
+// reboot or kill -9 -1, without destroying data.
+const CategoryDisruption Category = "disruption"
+
+// Issue is a single finding reported by a rule.
 type Issue struct {
-	Filepath string
-	Line     uint
-	Col      uint
-	Command  string
+	Filepath string   `json:"filepath"`
+	Line     uint     `json:"line"`
+	Col      uint     `json:"col"`
+	EndLine  uint     `json:"endLine,omitempty"`
+	EndCol   uint     `json:"endCol,omitempty"`
+	Command  string   `json:"command"`
+	RuleID   string   `json:"ruleId,omitempty"`
+	Severity Severity `json:"severity"`
+	// Message explains why the command is dangerous.
+	Message string `json:"message,omitempty"`
+	// Fix optionally suggests a safer alternative.
+	Fix      string   `json:"fix,omitempty"`
+	Category Category `json:"category,omitempty"`
+	// Source is the text of the lines the issue spans, which reporters
+	// fingerprint findings with.
+	Source string `json:"-"`
 }
 
-func ReportIssues(issues []Issue) {
-	for _, issue := range issues {
-		log.Printf("unsafe code found at position %d,%d in %s",
-			issue.Line, issue.Col, issue.Filepath)
+// AttachSource sets the Source of each issue to the lines of content, the
+// scanned file, that it spans.
+func AttachSource(issues []Issue, content string) {
+	lines := strings.Split(content, "\n")
+
+	for idx := range issues {
+		i := &issues[idx]
+		if i.Line == 0 || int(i.Line) > len(lines) {
+			continue
+		}
+
+		end := i.EndLine
+		if end < i.Line || int(end) > len(lines) {
+			end = i.Line
+		}
+
+		i.Source = strings.Join(lines[i.Line-1:end], "\n")
 	}
 }
 
 func (i Issue) String() string {
-	return fmt.Sprintf("unsafe code found at position %d,%d in %s", i.Line, i.Col, i.Filepath)
+	message := i.Message
+	if len(message) == 0 {
+		message = "unsafe code found"
+	}
+
+	s := fmt.Sprintf("%s: %s at position %d,%d in %s", i.Severity, message, i.Line, i.Col, i.Filepath)
+	if len(i.RuleID) > 0 {
+		s += fmt.Sprintf(" [%s]", i.RuleID)
+	}
+
+	if len(i.Category) > 0 {
+		s += fmt.Sprintf(" (%s)", i.Category)
+	}
+
+	return s
 }
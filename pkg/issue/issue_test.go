@@ -0,0 +1,75 @@
+package issue
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIssueString(t *testing.T) {
+	tests := []struct {
+		name  string
+		issue Issue
+		want  string
+	}{
+		{
+			name: "full issue",
+			issue: Issue{
+				Filepath: "test.sh",
+				Line:     2,
+				Col:      1,
+				RuleID:   "rm-rf",
+				Severity: SeverityError,
+				Message:  "rm -rf deletes files recursively",
+			},
+			want: "error: rm -rf deletes files recursively at position 2,1 in test.sh [rm-rf]",
+		},
+		{
+			name: "categorized issue",
+			issue: Issue{
+				Filepath: "deploy.sh",
+				Line:     7,
+				Col:      3,
+				RuleID:   "system-shutdown",
+				Severity: SeverityError,
+				Message:  "reboot restarts the host",
+				Category: CategoryDisruption,
+			},
+			want: "error: reboot restarts the host at position 7,3 in deploy.sh [system-shutdown] (disruption)",
+		},
+		{
+			name:  "issue without message",
+			issue: Issue{Filepath: "Makefile", Line: 3, Col: 2},
+			want:  "info: unsafe code found at position 3,2 in Makefile",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.issue.String())
+		})
+	}
+}
+
+func TestSeverityString(t *testing.T) {
+	assert.Equal(t, "info", SeverityInfo.String())
+	assert.Equal(t, "warning", SeverityWarning.String())
+	assert.Equal(t, "error", SeverityError.String())
+	assert.Equal(t, "unknown", Severity(42).String())
+}
+
+func TestAttachSource(t *testing.T) {
+	issues := []Issue{
+		{Line: 2, EndLine: 2},
+		{Line: 3, EndLine: 4},
+		{Line: 4},
+		{Line: 9},
+	}
+
+	AttachSource(issues, "#!/bin/sh\nrm -rf build\nfind . -delete \\\n  -name '*.o'\n")
+
+	assert.Equal(t, "rm -rf build", issues[0].Source)
+	assert.Equal(t, "find . -delete \\\n  -name '*.o'", issues[1].Source)
+	assert.Equal(t, "  -name '*.o'", issues[2].Source)
+	assert.Empty(t, issues[3].Source, "lines past the end of the file have no source")
+}
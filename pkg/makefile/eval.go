@@ -0,0 +1,431 @@
+package makefile
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Emptiness tells whether an expansion may be empty once the shell has
+// removed its quotes, e.g. `OUT_DIR := ""` hands the shell an empty word.
+type Emptiness int
+
+const (
+	NonEmpty Emptiness = iota
+	MaybeEmpty
+	Empty
+)
+
+// Value is the result of evaluating a make reference.
+type Value struct {
+	// Text is the expansion. Parts that cannot be known without running make,
+	// such as $(shell ...) or variables taken from the environment, are left
+	// as written.
+	Text      string
+	Emptiness Emptiness
+
+	// Defined is set when the variable is assigned in the Makefile, and Line
+	// is then the line of the assignment that gave it its value.
+	Defined bool
+	Line    int
+
+	// Reason explains why the value is or may be empty.
+	Reason string
+}
+
+// builtinVariables are set by make itself or by the environment of any build.
+var builtinVariables = map[string]bool{
+	"CURDIR": true, "MAKE": true, "MAKEFLAGS": true, "MAKEFILE_LIST": true,
+	"MAKE_VERSION": true, "SHELL": true, "RM": true, "CC": true, "CXX": true,
+	"CPP": true, "AR": true, "AS": true, "FC": true, "LEX": true, "YACC": true,
+	"HOME": true, "PATH": true, "PWD": true, "USER": true, "LOGNAME": true,
+}
+
+// builtinDefaults are the values make gives its built-in variables when
+// neither the Makefile nor the environment sets them.
+var builtinDefaults = map[string]string{"RM": "rm -f"}
+
+// maxDepth bounds the expansion of recursive variables, which make reports as
+// an error when they refer to themselves.
+const maxDepth = 32
+
+// variable is a make variable as defined by the Makefile so far.
+type variable struct {
+	name      string
+	recursive bool
+	// value is the unexpanded value of recursive variables and the expanded
+	// value of simple ones, whose evaluation is kept in simple.
+	value    string
+	simple   Value
+	shell    bool
+	override bool
+	line     int
+
+	// conditional variables are assigned in an ifeq, ifneq, ifdef or ifndef
+	// block; fallback is what the variable holds when the block is skipped.
+	conditional bool
+	fallback    *variable
+}
+
+// Evaluator resolves make variables the way make does when it runs recipes:
+// recursive variables are expanded at use, simple ones when assigned,
+// override assignments win over later plain ones and variables missing from
+// the Makefile fall back to the environment.
+type Evaluator struct {
+	file    *File
+	globals map[string]*variable
+}
+
+// NewEvaluator evaluates the global assignments of f.
+func NewEvaluator(f *File) *Evaluator {
+	e := &Evaluator{file: f, globals: make(map[string]*variable)}
+
+	for _, a := range f.Assignments {
+		if len(a.Targets) == 0 {
+			e.assign(e.globals, a)
+		}
+	}
+
+	return e
+}
+
+// Lookup evaluates the variable name as seen by the recipes of rule, or at
+// the global scope if rule is nil.
+func (e *Evaluator) Lookup(name string, rule *Rule) Value {
+	return e.reference(name, e.scope(rule), 0)
+}
+
+// Reference evaluates a reference found in a recipe of rule.
+func (e *Evaluator) Reference(ref Reference, rule *Rule) Value {
+	return e.reference(ref.Expr, e.scope(rule), 0)
+}
+
+// scope returns the variables visible to the recipes of rule, applying its
+// target-specific variables on top of the global ones.
+func (e *Evaluator) scope(rule *Rule) map[string]*variable {
+	if rule == nil {
+		return e.globals
+	}
+
+	var vars map[string]*variable
+
+	for _, a := range e.file.Assignments {
+		if !appliesTo(a, rule) {
+			continue
+		}
+
+		if vars == nil {
+			vars = make(map[string]*variable, len(e.globals))
+			for k, v := range e.globals {
+				vars[k] = v
+			}
+		}
+
+		e.assign(vars, a)
+	}
+
+	if vars == nil {
+		return e.globals
+	}
+
+	return vars
+}
+
+func appliesTo(a *Assignment, rule *Rule) bool {
+	for _, pattern := range a.Targets {
+		for _, target := range rule.Targets {
+			if matchTarget(pattern, target) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// matchTarget matches a target against a target or `%` pattern.
+func matchTarget(pattern, target string) bool {
+	prefix, suffix, ok := strings.Cut(pattern, "%")
+	if !ok {
+		return pattern == target
+	}
+
+	return len(target) >= len(prefix)+len(suffix) &&
+		strings.HasPrefix(target, prefix) && strings.HasSuffix(target, suffix)
+}
+
+func (e *Evaluator) assign(vars map[string]*variable, a *Assignment) {
+	prev := vars[a.Name]
+
+	if a.Op == "undefine" {
+		if prev == nil || !prev.override || a.Override {
+			delete(vars, a.Name)
+		}
+		return
+	}
+
+	if prev != nil && prev.override && !a.Override {
+		return
+	}
+
+	if a.Op == "?=" && prev != nil {
+		return
+	}
+
+	v := &variable{name: a.Name, line: a.Line, override: a.Override}
+
+	switch a.Op {
+	case "=", "?=":
+		v.recursive, v.value = true, a.Value
+	case ":=", "::=":
+		v.simple = e.expand(a.Value, vars, 0)
+		v.value = v.simple.Text
+	case "!=":
+		v.shell, v.value = true, a.Value
+	case "+=":
+		v = e.appendTo(prev, a, vars)
+	}
+
+	if a.Conditional {
+		v.conditional, v.fallback = true, prev
+	}
+
+	vars[a.Name] = v
+}
+
+// appendTo returns the variable prev becomes once the value of a is appended
+// to it. When prev is only assigned inside a conditional, so is the result,
+// which falls back to the value appended to what prev replaced.
+func (e *Evaluator) appendTo(prev *variable, a *Assignment, vars map[string]*variable) *variable {
+	v := &variable{name: a.Name, line: a.Line, override: a.Override}
+
+	switch {
+	case prev == nil:
+		v.recursive, v.value = true, a.Value
+	case prev.recursive:
+		v.recursive, v.value = true, joinValue(prev.value, a.Value)
+	case prev.shell:
+		v.shell, v.value = true, prev.value
+	default:
+		appended := e.expand(a.Value, vars, 0)
+		v.simple = concat(prev.simple, appended)
+		v.value = v.simple.Text
+	}
+
+	if prev != nil && prev.conditional {
+		v.conditional, v.fallback = true, e.appendTo(prev.fallback, a, vars)
+	}
+
+	return v
+}
+
+func joinValue(a, b string) string {
+	if len(a) == 0 {
+		return b
+	}
+
+	return a + " " + b
+}
+
+// value evaluates a variable defined in the Makefile.
+func (e *Evaluator) value(v *variable, vars map[string]*variable, depth int) Value {
+	var val Value
+
+	switch {
+	case v.shell:
+		val = Value{Text: "$(shell " + v.value + ")", Emptiness: MaybeEmpty, Reason: "assigned from shell output"}
+	case v.recursive:
+		val = e.expand(v.value, vars, depth+1)
+	default:
+		val = v.simple
+	}
+
+	val.Defined, val.Line = true, v.line
+	if val.Emptiness == Empty && len(val.Reason) == 0 {
+		val.Reason = "assigned an empty value"
+	}
+
+	if !v.conditional {
+		return val
+	}
+
+	fallback := undefined(v.name)
+	if v.fallback != nil {
+		fallback = e.value(v.fallback, vars, depth+1)
+	}
+
+	switch {
+	case val.Emptiness == NonEmpty && fallback.Emptiness == NonEmpty:
+	case val.Emptiness == Empty && fallback.Emptiness == Empty:
+	default:
+		val.Emptiness = MaybeEmpty
+		val.Reason = "only assigned inside a conditional"
+	}
+
+	return val
+}
+
+func undefined(name string) Value {
+	return Value{
+		Text:      "$(" + name + ")",
+		Emptiness: MaybeEmpty,
+		Reason:    "not defined in the Makefile and empty unless set in the environment",
+	}
+}
+
+// reference evaluates the text between the parentheses of a reference, or
+// the single character of references like `$@`.
+func (e *Evaluator) reference(expr string, vars map[string]*variable, depth int) Value {
+	if depth > maxDepth {
+		return Value{Text: "$(" + expr + ")", Emptiness: MaybeEmpty, Reason: "refers to itself"}
+	}
+
+	name, isVar := expr, true
+	if i := strings.IndexByte(name, ':'); i > 0 && !strings.ContainsAny(name[:i], " \t$") {
+		// substitution reference such as $(SRCS:.c=.o)
+		name = name[:i]
+	} else if strings.ContainsAny(name, " \t,") {
+		isVar = false
+	}
+
+	if isVar && strings.Contains(name, "$") {
+		// computed name such as $($(ARCH)_DIR)
+		computed := e.expand(name, vars, depth+1)
+		if computed.Emptiness != NonEmpty || strings.Contains(computed.Text, "$") {
+			return Value{Text: "$(" + expr + ")", Emptiness: MaybeEmpty, Reason: "a computed variable name"}
+		}
+		name = strings.TrimSpace(computed.Text)
+	}
+
+	if !isVar {
+		fn := firstWord(expr)
+		return Value{Text: "$(" + expr + ")", Emptiness: MaybeEmpty, Reason: fmt.Sprintf("the result of $(%s ...)", fn)}
+	}
+
+	if isAutomatic(name) {
+		return Value{Text: "$" + name, Emptiness: NonEmpty}
+	}
+
+	if v, ok := vars[name]; ok {
+		return e.value(v, vars, depth)
+	}
+
+	if text, ok := builtinDefaults[name]; ok {
+		return Value{Text: text, Emptiness: NonEmpty}
+	}
+
+	if builtinVariables[name] {
+		return Value{Text: "$(" + name + ")", Emptiness: NonEmpty}
+	}
+
+	return undefined(name)
+}
+
+// isAutomatic reports whether name is an automatic variable such as @ or <D.
+func isAutomatic(name string) bool {
+	if len(name) == 2 && (name[1] == 'D' || name[1] == 'F') {
+		name = name[:1]
+	}
+
+	return len(name) == 1 && strings.Contains("@<^+*?%|", name)
+}
+
+// expand expands the references in s.
+func (e *Evaluator) expand(s string, vars map[string]*variable, depth int) Value {
+	var (
+		sb       strings.Builder
+		nonEmpty bool
+		maybe    bool
+		reason   string
+	)
+
+	for i := 0; i < len(s); {
+		c := s[i]
+		if c != '$' || i+1 >= len(s) {
+			sb.WriteByte(c)
+			if !isBlank(c) && c != '"' && c != '\'' {
+				nonEmpty = true
+			}
+			i++
+			continue
+		}
+
+		var expr, written string
+
+		switch next := s[i+1]; {
+		case next == '$':
+			// a shell expansion, which make leaves to the shell
+			sb.WriteString("$$")
+			maybe = true
+			if len(reason) == 0 {
+				reason = "expands a shell variable"
+			}
+			i += 2
+			continue
+
+		case next == '(' || next == '{':
+			end := matchingClose([]byte(s), i+1)
+			if end < 0 {
+				sb.WriteString(s[i:])
+				nonEmpty = true
+				i = len(s)
+				continue
+			}
+			expr, written = s[i+2:end], s[i:end+1]
+			i = end + 1
+
+		default:
+			expr, written = string(next), s[i:i+2]
+			i += 2
+		}
+
+		v := e.reference(expr, vars, depth)
+		sb.WriteString(v.Text)
+
+		switch v.Emptiness {
+		case NonEmpty:
+			nonEmpty = true
+		case MaybeEmpty:
+			if !maybe {
+				reason = fmt.Sprintf("assigned from %s, which may be empty", written)
+				if !v.Defined {
+					reason = fmt.Sprintf("assigned from %s, which is not defined in the Makefile", written)
+				}
+			}
+			maybe = true
+		case Empty:
+			if len(reason) == 0 {
+				reason = fmt.Sprintf("assigned from %s, which is empty", written)
+			}
+		}
+	}
+
+	val := Value{Text: sb.String(), Emptiness: Empty, Reason: reason}
+
+	switch {
+	case nonEmpty:
+		val.Emptiness, val.Reason = NonEmpty, ""
+	case maybe:
+		val.Emptiness = MaybeEmpty
+	}
+
+	return val
+}
+
+// concat evaluates the concatenation of two values separated by a space.
+func concat(a, b Value) Value {
+	val := Value{Text: joinValue(a.Text, b.Text)}
+
+	switch {
+	case a.Emptiness == NonEmpty || b.Emptiness == NonEmpty:
+		val.Emptiness = NonEmpty
+	case a.Emptiness == Empty && b.Emptiness == Empty:
+		val.Emptiness, val.Reason = Empty, a.Reason
+	default:
+		val.Emptiness, val.Reason = MaybeEmpty, a.Reason
+		if a.Emptiness != MaybeEmpty {
+			val.Reason = b.Reason
+		}
+	}
+
+	return val
+}
@@ -0,0 +1,101 @@
+package makefile
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvaluatorLookup(t *testing.T) {
+	f := parse(t, `EMPTY :=
+QUOTED := ""
+LATE = $(LATER)
+LATER = out
+EARLY := $(SOON)
+SOON = out
+DEFAULT ?= build
+DEFAULT ?= ignored
+LIST = a
+LIST += b
+APPENDED :=
+APPENDED += dist
+override FORCED = /opt
+FORCED = ignored
+OUTPUT != git rev-parse --show-toplevel
+GONE = x
+undefine GONE
+ifdef CI
+  MAYBE = ci
+endif
+SELF = $(SELF)
+TOOLS = $(CC) $(HOME)
+ifdef CI
+  GROWN = ci
+endif
+GROWN += $(EMPTY)
+`)
+
+	e := NewEvaluator(f)
+
+	tests := []struct {
+		name      string
+		want      Emptiness
+		wantText  string
+		wantLine  int
+		wantCause string
+	}{
+		{name: "EMPTY", want: Empty, wantLine: 1, wantCause: "assigned an empty value"},
+		{name: "QUOTED", want: Empty, wantText: `""`, wantLine: 2, wantCause: "assigned an empty value"},
+		{name: "LATE", want: NonEmpty, wantText: "out", wantLine: 3},
+		{name: "EARLY", want: MaybeEmpty, wantText: "$(SOON)", wantLine: 5, wantCause: "assigned from $(SOON), which is not defined in the Makefile"},
+		{name: "DEFAULT", want: NonEmpty, wantText: "build", wantLine: 7},
+		{name: "LIST", want: NonEmpty, wantText: "a b", wantLine: 10},
+		{name: "APPENDED", want: NonEmpty, wantText: "dist", wantLine: 12},
+		{name: "FORCED", want: NonEmpty, wantText: "/opt", wantLine: 13},
+		{name: "OUTPUT", want: MaybeEmpty, wantText: "$(shell git rev-parse --show-toplevel)", wantLine: 15, wantCause: "assigned from shell output"},
+		{name: "GONE", want: MaybeEmpty, wantText: "$(GONE)", wantCause: "not defined in the Makefile and empty unless set in the environment"},
+		{name: "MAYBE", want: MaybeEmpty, wantText: "ci", wantLine: 19, wantCause: "only assigned inside a conditional"},
+		{name: "SELF", want: MaybeEmpty, wantText: "$(SELF)", wantLine: 21, wantCause: "assigned from $(SELF), which may be empty"},
+		{name: "TOOLS", want: NonEmpty, wantText: "$(CC) $(HOME)", wantLine: 22},
+		{name: "GROWN", want: MaybeEmpty, wantText: "ci ", wantLine: 26, wantCause: "only assigned inside a conditional"},
+		{name: "@", want: NonEmpty, wantText: "$@"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := e.Lookup(tt.name, nil)
+
+			assert.Equal(t, tt.want, got.Emptiness)
+			assert.Equal(t, tt.wantText, got.Text)
+			assert.Equal(t, tt.wantLine, got.Line)
+			assert.Equal(t, tt.wantCause, got.Reason)
+		})
+	}
+}
+
+func TestEvaluatorTargetSpecific(t *testing.T) {
+	f := parse(t, `OUT_DIR =
+clean: OUT_DIR = build
+%.o: CFLAGS += -O2
+dist:
+	rm -rf $(OUT_DIR)/*
+clean:
+	rm -rf $(OUT_DIR)/*
+main.o:
+	$(CC) $(CFLAGS) -c main.c
+`)
+
+	require.Len(t, f.Rules, 3)
+	e := NewEvaluator(f)
+
+	assert.Equal(t, Empty, e.Reference(f.Rules[0].Recipes[0].Refs[0], f.Rules[0]).Emptiness)
+
+	clean := e.Reference(f.Rules[1].Recipes[0].Refs[0], f.Rules[1])
+	assert.Equal(t, NonEmpty, clean.Emptiness)
+	assert.Equal(t, "build", clean.Text)
+	assert.Equal(t, 2, clean.Line)
+
+	assert.Equal(t, "-O2", e.Lookup("CFLAGS", f.Rules[2]).Text)
+	assert.Equal(t, MaybeEmpty, e.Lookup("CFLAGS", nil).Emptiness)
+}
@@ -0,0 +1,102 @@
+package makefile
+
+// Position is a 1-based line and byte column in a Makefile.
+type Position struct {
+	Line int
+	Col  int
+}
+
+// File is a parsed Makefile.
+type File struct {
+	Name        string
+	Rules       []*Rule
+	Assignments []*Assignment
+}
+
+// Rule is a target declaration together with its recipe.
+type Rule struct {
+	Targets       []string
+	Prerequisites []string
+	OrderOnly     []string
+	DoubleColon   bool
+	Line          int
+	Recipes       []*Recipe
+}
+
+// Assignment is a variable definition such as `CC := gcc`.
+type Assignment struct {
+	Name string
+	// Op is one of "=", ":=", "::=", "?=", "+=", "!=" or "undefine".
+	Op       string
+	Value    string
+	Override bool
+	Export   bool
+	// Define is set for multi-line `define ... endef` blocks.
+	Define bool
+	// Targets is set for target-specific variables, e.g. `clean: DIR = out`.
+	Targets []string
+	// Conditional is set when the assignment is nested in an ifeq, ifneq,
+	// ifdef or ifndef block and therefore may not take effect.
+	Conditional bool
+	Line        int
+}
+
+// Recipe is a single recipe line of a rule, including its continuation lines.
+type Recipe struct {
+	// Silent, IgnoreErrors and Always record the `@`, `-` and `+` prefixes.
+	Silent       bool
+	IgnoreErrors bool
+	Always       bool
+
+	// Raw is the recipe as written, without the leading tab and prefixes.
+	// Continuation lines are joined with a backslash-newline, as make hands
+	// them to the shell.
+	Raw string
+
+	// Shell is the text make hands to the shell: `$$` is unescaped and every
+	// make reference is replaced with a shell parameter expansion listed in
+	// Refs.
+	Shell string
+
+	// Refs lists the make references found in the recipe, in order.
+	Refs []Reference
+
+	positions []Position
+}
+
+// Reference is a make variable reference or function call within a recipe.
+type Reference struct {
+	// Offset is the byte offset of the replacement expansion in Recipe.Shell.
+	Offset int
+	// Param is the name of the shell parameter standing in for the reference.
+	Param string
+	// Name is the referenced make variable. It is empty for function calls
+	// and computed names.
+	Name string
+	// Expr is the text between the parentheses or braces, or the single
+	// character of references like `$@`.
+	Expr string
+	// Text is the reference as written, e.g. `$(CC)`, `${CC}` or `$@`.
+	Text string
+	// Pos is where the reference starts in the Makefile.
+	Pos Position
+}
+
+// Position returns the Makefile position of the byte at offset in r.Shell.
+// Offsets at or past the end map to the column following the last byte.
+func (r *Recipe) Position(offset int) Position {
+	if len(r.positions) == 0 {
+		return Position{}
+	}
+
+	if offset < 0 {
+		offset = 0
+	}
+
+	if offset >= len(r.positions) {
+		last := r.positions[len(r.positions)-1]
+		return Position{Line: last.Line, Col: last.Col + offset - len(r.positions) + 1}
+	}
+
+	return r.positions[offset]
+}
@@ -0,0 +1,644 @@
+package makefile
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/hiteshrepo/hazardous/pkg/helpers"
+)
+
+// text is a string under construction together with the Makefile position of
+// each of its bytes, so that anything parsed out of it can be traced back to
+// the original file.
+type text struct {
+	buf []byte
+	pos []Position
+}
+
+func (t *text) add(b byte, p Position) {
+	t.buf = append(t.buf, b)
+	t.pos = append(t.pos, p)
+}
+
+// addSpan appends s, mapping every byte to start except the last one, which
+// is mapped to end. Nodes ending with s then end where the original did.
+func (t *text) addSpan(s string, start, end Position) {
+	for i := 0; i < len(s); i++ {
+		if i == len(s)-1 {
+			t.add(s[i], end)
+		} else {
+			t.add(s[i], start)
+		}
+	}
+}
+
+func (t text) slice(from, to int) text {
+	return text{buf: t.buf[from:to], pos: t.pos[from:to]}
+}
+
+func (t text) String() string {
+	return string(t.buf)
+}
+
+// trimSpace returns t without its leading and trailing blanks.
+func (t text) trimSpace() text {
+	from, to := 0, len(t.buf)
+	for from < to && isBlank(t.buf[from]) {
+		from++
+	}
+
+	for to > from && isBlank(t.buf[to-1]) {
+		to--
+	}
+
+	return t.slice(from, to)
+}
+
+type parser struct {
+	name  string
+	lines []string
+	next  int
+
+	file  *File
+	rule  *Rule
+	conds int
+}
+
+// Parse reads a Makefile and returns its rules, recipes and variable
+// assignments. Only structural problems such as unbalanced conditionals or
+// unterminated `define` blocks are reported as errors; lines that are not
+// understood are skipped.
+func Parse(r io.Reader, name string) (*File, error) {
+	p := &parser{name: name, file: &File{Name: name}}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		p.lines = append(p.lines, strings.TrimSuffix(scanner.Text(), "\r"))
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	for p.next < len(p.lines) {
+		if p.atRecipe() {
+			p.rule.Recipes = append(p.rule.Recipes, p.recipe())
+			continue
+		}
+
+		num := p.next + 1
+		if err := p.statement(p.logicalLine(), num); err != nil {
+			return nil, err
+		}
+	}
+
+	if p.conds > 0 {
+		return nil, p.errorf(len(p.lines), "missing 'endif'")
+	}
+
+	return p.file, nil
+}
+
+func (p *parser) errorf(line int, format string, args ...any) error {
+	return fmt.Errorf("%s:%d: %s", p.name, line, fmt.Sprintf(format, args...))
+}
+
+// atRecipe reports whether the next line is a recipe line of the current
+// rule. Besides tab-prefixed lines, lines indented with spaces that are not
+// valid make syntax are accepted as recipes too, as that is almost always
+// what their author meant.
+func (p *parser) atRecipe() bool {
+	if p.rule == nil {
+		return false
+	}
+
+	line := p.lines[p.next]
+	if strings.HasPrefix(line, "\t") {
+		return true
+	}
+
+	if !strings.HasPrefix(line, " ") {
+		return false
+	}
+
+	s := strings.TrimSpace(stripComment(line))
+	if len(s) == 0 || isDirective(firstWord(s)) {
+		return false
+	}
+
+	_, sep := findSeparator(s)
+
+	return len(sep) == 0
+}
+
+// recipe consumes a recipe line and its continuation lines.
+func (p *parser) recipe() *Recipe {
+	var raw text
+
+	for first := true; p.next < len(p.lines); first = false {
+		line, num := p.lines[p.next], p.next+1
+		p.next++
+
+		start := 0
+		if first {
+			for start < len(line) && isBlank(line[start]) {
+				start++
+			}
+		} else if strings.HasPrefix(line, "\t") {
+			// make strips the recipe prefix of continuation lines
+			start = 1
+		}
+
+		for i := start; i < len(line); i++ {
+			raw.add(line[i], Position{Line: num, Col: i + 1})
+		}
+
+		if !continues(line) {
+			break
+		}
+
+		raw.add('\n', Position{Line: num, Col: len(line) + 1})
+	}
+
+	return newRecipe(raw)
+}
+
+// logicalLine consumes a make line and its continuation lines, replacing each
+// backslash-newline and the blanks around it with a single space and
+// dropping comments.
+func (p *parser) logicalLine() text {
+	var t text
+
+	for p.next < len(p.lines) {
+		line, num := p.lines[p.next], p.next+1
+		p.next++
+
+		start := 0
+		if len(t.buf) > 0 {
+			for start < len(line) && isBlank(line[start]) {
+				start++
+			}
+		}
+
+		end := len(line)
+		more := continues(line)
+		if more {
+			end--
+			for end > start && isBlank(line[end-1]) {
+				end--
+			}
+		}
+
+		for i := start; i < end; i++ {
+			t.add(line[i], Position{Line: num, Col: i + 1})
+		}
+
+		if !more {
+			break
+		}
+
+		t.add(' ', Position{Line: num, Col: end + 1})
+	}
+
+	return stripCommentText(t)
+}
+
+func (p *parser) statement(t text, num int) error {
+	t = t.trimSpace()
+	s := t.String()
+	if len(s) == 0 {
+		return nil
+	}
+
+	word := firstWord(s)
+
+	switch word {
+	case "ifeq", "ifneq", "ifdef", "ifndef":
+		p.conds++
+		return nil
+
+	case "else":
+		if p.conds == 0 {
+			return p.errorf(num, "extraneous 'else'")
+		}
+		return nil
+
+	case "endif":
+		if p.conds == 0 {
+			return p.errorf(num, "extraneous 'endif'")
+		}
+		p.conds--
+		return nil
+
+	case "endef":
+		return p.errorf(num, "extraneous 'endef'")
+
+	case "include", "-include", "sinclude", "vpath", "unexport":
+		return nil
+	}
+
+	assign := &Assignment{Line: num, Conditional: p.conds > 0}
+	rest := p.modifiers(s, assign)
+
+	switch firstWord(rest) {
+	case "define":
+		p.rule = nil
+		return p.define(strings.TrimSpace(strings.TrimPrefix(rest, "define")), assign)
+
+	case "undefine":
+		p.rule = nil
+		assign.Name = strings.TrimSpace(strings.TrimPrefix(rest, "undefine"))
+		assign.Op = "undefine"
+		p.file.Assignments = append(p.file.Assignments, assign)
+		return nil
+	}
+
+	idx, sep := findSeparator(rest)
+	switch sep {
+	case "":
+		// `export VAR` and function calls such as $(eval ...) end up here
+		return nil
+
+	case ":", "::":
+		offset := len(s) - len(rest)
+		p.parseRule(t.slice(offset, len(t.buf)), idx, sep, num)
+		return nil
+
+	default:
+		name := strings.TrimSpace(rest[:idx])
+		if strings.ContainsAny(name, " \t") {
+			return nil
+		}
+
+		p.rule = nil
+		assign.Name = name
+		assign.Op = sep
+		assign.Value = strings.TrimSpace(rest[idx+len(sep):])
+		p.file.Assignments = append(p.file.Assignments, assign)
+		return nil
+	}
+}
+
+// modifiers strips the override, export and private keywords from s,
+// recording them on assign.
+func (p *parser) modifiers(s string, assign *Assignment) string {
+	for {
+		switch firstWord(s) {
+		case "override":
+			assign.Override = true
+		case "export":
+			assign.Export = true
+		case "private":
+		default:
+			return s
+		}
+
+		s = strings.TrimSpace(s[len(firstWord(s)):])
+	}
+}
+
+// parseRule handles `targets : prerequisites | order-only ; recipe`, as well
+// as target-specific variables such as `targets : VAR = value`.
+func (p *parser) parseRule(t text, idx int, sep string, num int) {
+	s := t.String()
+	targets := strings.Fields(s[:idx])
+	rest := t.slice(idx+len(sep), len(t.buf))
+
+	var inline *text
+	if semi := indexUnnested(rest.String(), ';'); semi >= 0 {
+		r := rest.slice(semi+1, len(rest.buf))
+		inline = &r
+		rest = rest.slice(0, semi)
+	}
+
+	assign := &Assignment{Line: num, Conditional: p.conds > 0, Targets: targets}
+	body := p.modifiers(strings.TrimSpace(rest.String()), assign)
+	if i, op := findSeparator(body); len(op) > 0 && op != ":" && op != "::" {
+		p.rule = nil
+		assign.Name = strings.TrimSpace(body[:i])
+		assign.Op = op
+		assign.Value = strings.TrimSpace(body[i+len(op):])
+		p.file.Assignments = append(p.file.Assignments, assign)
+		return
+	}
+
+	prereqs := rest.String()
+	if i, op := findSeparator(prereqs); op == ":" {
+		// static pattern rule: targets: target-pattern: prereq-patterns
+		prereqs = prereqs[i+1:]
+	}
+
+	rule := &Rule{Targets: targets, DoubleColon: sep == "::", Line: num}
+
+	normal, orderOnly, _ := strings.Cut(prereqs, "|")
+	rule.Prerequisites = strings.Fields(normal)
+	rule.OrderOnly = strings.Fields(orderOnly)
+
+	if inline != nil && len(inline.trimSpace().buf) > 0 {
+		rule.Recipes = append(rule.Recipes, newRecipe(*inline))
+	}
+
+	p.file.Rules = append(p.file.Rules, rule)
+	p.rule = rule
+}
+
+// define consumes a `define NAME [op]` block up to its matching endef.
+func (p *parser) define(header string, assign *Assignment) error {
+	start := p.next
+
+	assign.Define = true
+	assign.Name = header
+	assign.Op = "="
+
+	for _, op := range []string{"::=", ":=", "?=", "+=", "!=", "="} {
+		if strings.HasSuffix(header, op) {
+			assign.Name = strings.TrimSpace(strings.TrimSuffix(header, op))
+			assign.Op = op
+			break
+		}
+	}
+
+	var body []string
+	for depth := 1; p.next < len(p.lines); {
+		line := p.lines[p.next]
+		p.next++
+
+		switch firstWord(strings.TrimSpace(line)) {
+		case "define":
+			depth++
+		case "endef":
+			depth--
+		}
+
+		if depth == 0 {
+			assign.Value = strings.Join(body, "\n")
+			p.file.Assignments = append(p.file.Assignments, assign)
+			return nil
+		}
+
+		body = append(body, line)
+	}
+
+	return p.errorf(start, "missing 'endef', unterminated 'define'")
+}
+
+// newRecipe strips the @, - and + prefixes from a recipe and translates it
+// into the text make hands to the shell.
+func newRecipe(raw text) *Recipe {
+	r := &Recipe{}
+
+	start := 0
+prefixes:
+	for ; start < len(raw.buf); start++ {
+		switch raw.buf[start] {
+		case '@':
+			r.Silent = true
+		case '-':
+			r.IgnoreErrors = true
+		case '+':
+			r.Always = true
+		case ' ', '\t':
+		default:
+			break prefixes
+		}
+	}
+
+	raw = raw.slice(start, len(raw.buf))
+	r.Raw = raw.String()
+
+	var shell text
+	translate(raw, &shell, &r.Refs)
+	r.Shell = shell.String()
+	r.positions = shell.pos
+
+	return r
+}
+
+// translate performs the part of make's expansion that matters to the shell
+// parser: `$$` becomes `$`, `$(shell cmd)` becomes a command substitution and
+// every other reference becomes a `${param}` expansion recorded in refs.
+func translate(src text, out *text, refs *[]Reference) {
+	for i := 0; i < len(src.buf); {
+		c := src.buf[i]
+		if c != '$' || i+1 >= len(src.buf) {
+			out.add(c, src.pos[i])
+			i++
+			continue
+		}
+
+		next := src.buf[i+1]
+		switch {
+		case next == '$':
+			out.add('$', src.pos[i])
+			i += 2
+
+		case next == '(' || next == '{':
+			end := matchingClose(src.buf, i+1)
+			if end < 0 {
+				out.add(c, src.pos[i])
+				i++
+				continue
+			}
+
+			expr := string(src.buf[i+2 : end])
+			if fn := firstWord(expr); fn == "shell" && len(expr) > len(fn) {
+				out.add('$', src.pos[i])
+				out.add('(', src.pos[i+1])
+				translate(src.slice(i+2+len(fn)+1, end), out, refs)
+				out.add(')', src.pos[end])
+			} else {
+				addReference(out, refs, expr, string(src.buf[i:end+1]), src.pos[i], src.pos[end])
+			}
+
+			i = end + 1
+
+		case isBlank(next):
+			out.add(c, src.pos[i])
+			i++
+
+		default:
+			addReference(out, refs, string(next), string(src.buf[i:i+2]), src.pos[i], src.pos[i+1])
+			i += 2
+		}
+	}
+}
+
+func addReference(out *text, refs *[]Reference, expr, written string, start, end Position) {
+	ref := Reference{Offset: len(out.buf), Expr: expr, Text: written, Pos: start}
+
+	name := expr
+	if i := strings.IndexByte(name, ':'); i > 0 && !strings.ContainsAny(name[:i], " \t$") {
+		// substitution reference such as $(SRCS:.c=.o)
+		name = name[:i]
+	}
+
+	if len(name) > 0 && !strings.ContainsAny(name, " \t$,") {
+		ref.Name = name
+	}
+
+	ref.Param = ref.Name
+	if !helpers.IsShellName(ref.Param) || ref.Name != expr {
+		ref.Param = fmt.Sprintf("__make_%d", len(*refs))
+	}
+
+	out.addSpan("${"+ref.Param+"}", start, end)
+	*refs = append(*refs, ref)
+}
+
+// findSeparator returns the index and text of the first rule separator (":"
+// or "::") or assignment operator in a make line, ignoring those nested in
+// variable references. It returns an empty separator if there is none.
+func findSeparator(s string) (int, string) {
+	depth := 0
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+
+		if c == '$' && i+1 < len(s) && (s[i+1] == '(' || s[i+1] == '{') {
+			depth++
+			i++
+			continue
+		}
+
+		if depth > 0 {
+			switch c {
+			case '(', '{':
+				depth++
+			case ')', '}':
+				depth--
+			}
+			continue
+		}
+
+		switch c {
+		case '=':
+			if i > 0 && strings.IndexByte("?+!", s[i-1]) >= 0 {
+				return i - 1, s[i-1 : i+1]
+			}
+			return i, "="
+
+		case ':':
+			switch {
+			case strings.HasPrefix(s[i:], "::="):
+				return i, "::="
+			case strings.HasPrefix(s[i:], ":="):
+				return i, ":="
+			case strings.HasPrefix(s[i:], "::"):
+				return i, "::"
+			default:
+				return i, ":"
+			}
+		}
+	}
+
+	return -1, ""
+}
+
+func indexUnnested(s string, b byte) int {
+	depth := 0
+
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '$' && i+1 < len(s) && (s[i+1] == '(' || s[i+1] == '{'):
+			depth++
+			i++
+		case depth > 0 && (s[i] == '(' || s[i] == '{'):
+			depth++
+		case depth > 0 && (s[i] == ')' || s[i] == '}'):
+			depth--
+		case depth == 0 && s[i] == b:
+			return i
+		}
+	}
+
+	return -1
+}
+
+// matchingClose returns the index of the parenthesis or brace closing the one
+// at open, or -1.
+func matchingClose(buf []byte, open int) int {
+	opening := buf[open]
+	closing := byte(')')
+	if opening == '{' {
+		closing = '}'
+	}
+
+	depth := 0
+	for i := open; i < len(buf); i++ {
+		switch buf[i] {
+		case opening:
+			depth++
+		case closing:
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+
+	return -1
+}
+
+func stripComment(s string) string {
+	if i := commentIndex([]byte(s)); i >= 0 {
+		return s[:i]
+	}
+
+	return s
+}
+
+func stripCommentText(t text) text {
+	if i := commentIndex(t.buf); i >= 0 {
+		return t.slice(0, i)
+	}
+
+	return t
+}
+
+func commentIndex(buf []byte) int {
+	for i, c := range buf {
+		if c == '#' && (i == 0 || buf[i-1] != '\\') {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// continues reports whether line ends with an unescaped backslash.
+func continues(line string) bool {
+	n := 0
+	for i := len(line) - 1; i >= 0 && line[i] == '\\'; i-- {
+		n++
+	}
+
+	return n%2 == 1
+}
+
+func isDirective(word string) bool {
+	switch word {
+	case "ifeq", "ifneq", "ifdef", "ifndef", "else", "endif", "define", "endef",
+		"include", "-include", "sinclude", "export", "unexport", "override",
+		"private", "undefine", "vpath":
+		return true
+	default:
+		return false
+	}
+}
+
+func firstWord(s string) string {
+	s = strings.TrimLeft(s, " \t")
+	if i := strings.IndexAny(s, " \t("); i >= 0 {
+		return s[:i]
+	}
+
+	return s
+}
+
+func isBlank(c byte) bool {
+	return c == ' ' || c == '\t'
+}
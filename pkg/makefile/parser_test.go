@@ -0,0 +1,199 @@
+package makefile
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func parse(t *testing.T, content string) *File {
+	t.Helper()
+
+	f, err := Parse(strings.NewReader(content), "Makefile")
+	require.NoError(t, err)
+
+	return f
+}
+
+func TestParseRules(t *testing.T) {
+	f := parse(t, `.PHONY: all clean
+all: main.o util.o | bin
+	$(CC) -o bin/app $^
+
+clean::
+	rm -rf bin
+
+objs: %.o: %.c
+	$(CC) -c $<
+`)
+
+	require.Len(t, f.Rules, 4)
+
+	assert.Equal(t, []string{".PHONY"}, f.Rules[0].Targets)
+	assert.Equal(t, []string{"all", "clean"}, f.Rules[0].Prerequisites)
+
+	assert.Equal(t, []string{"all"}, f.Rules[1].Targets)
+	assert.Equal(t, []string{"main.o", "util.o"}, f.Rules[1].Prerequisites)
+	assert.Equal(t, []string{"bin"}, f.Rules[1].OrderOnly)
+	assert.Equal(t, 2, f.Rules[1].Line)
+	require.Len(t, f.Rules[1].Recipes, 1)
+
+	assert.True(t, f.Rules[2].DoubleColon)
+	assert.Equal(t, "rm -rf bin", f.Rules[2].Recipes[0].Shell)
+
+	assert.Equal(t, []string{"objs"}, f.Rules[3].Targets)
+	assert.Equal(t, []string{"%.c"}, f.Rules[3].Prerequisites)
+}
+
+func TestParseRecipePrefixes(t *testing.T) {
+	f := parse(t, "clean:\n\t@echo cleaning\n\t-rm -rf out\n\t+ @ $(MAKE) -C sub clean\n")
+
+	recipes := f.Rules[0].Recipes
+	require.Len(t, recipes, 3)
+
+	assert.True(t, recipes[0].Silent)
+	assert.Equal(t, "echo cleaning", recipes[0].Raw)
+
+	assert.True(t, recipes[1].IgnoreErrors)
+	assert.False(t, recipes[1].Silent)
+	assert.Equal(t, "rm -rf out", recipes[1].Raw)
+
+	assert.True(t, recipes[2].Always)
+	assert.True(t, recipes[2].Silent)
+	assert.Equal(t, "$(MAKE) -C sub clean", recipes[2].Raw)
+	assert.Equal(t, Position{Line: 4, Col: 6}, recipes[2].Position(0))
+}
+
+func TestParseRecipeContinuation(t *testing.T) {
+	f := parse(t, "clean:\n\trm -rf \\\n\t  build \\\n\tdist\n\techo done\n")
+
+	recipes := f.Rules[0].Recipes
+	require.Len(t, recipes, 2)
+
+	assert.Equal(t, "rm -rf \\\n  build \\\ndist", recipes[0].Shell)
+	assert.Equal(t, Position{Line: 2, Col: 2}, recipes[0].Position(0))
+	assert.Equal(t, Position{Line: 3, Col: 4}, recipes[0].Position(strings.Index(recipes[0].Shell, "build")))
+	assert.Equal(t, Position{Line: 4, Col: 2}, recipes[0].Position(strings.Index(recipes[0].Shell, "dist")))
+	assert.Equal(t, Position{Line: 4, Col: 6}, recipes[0].Position(len(recipes[0].Shell)))
+
+	assert.Equal(t, "echo done", recipes[1].Shell)
+}
+
+func TestParseRecipeTranslation(t *testing.T) {
+	f := parse(t, "clean:\n\trm -rf $(OUT_DIR)/* ${TMP} $@ $$HOME/$(SRCS:.c=.o) $(shell echo $(X))\n")
+
+	recipe := f.Rules[0].Recipes[0]
+	assert.Equal(t, "rm -rf ${OUT_DIR}/* ${TMP} ${__make_2} $HOME/${__make_3} $(echo ${X})", recipe.Shell)
+
+	require.Len(t, recipe.Refs, 5)
+	assert.Equal(t, Reference{Offset: 7, Param: "OUT_DIR", Name: "OUT_DIR", Expr: "OUT_DIR", Text: "$(OUT_DIR)", Pos: Position{Line: 2, Col: 9}}, recipe.Refs[0])
+	assert.Equal(t, "TMP", recipe.Refs[1].Name)
+	assert.Equal(t, "${TMP}", recipe.Refs[1].Text)
+	assert.Equal(t, "@", recipe.Refs[2].Name)
+	assert.Equal(t, "$@", recipe.Refs[2].Text)
+	assert.Equal(t, "SRCS", recipe.Refs[3].Name)
+	assert.Equal(t, "SRCS:.c=.o", recipe.Refs[3].Expr)
+	assert.Equal(t, "X", recipe.Refs[4].Name)
+
+	// the closing brace of a replaced reference maps to the closing paren
+	end := recipe.Refs[0].Offset + len("${OUT_DIR}")
+	assert.Equal(t, Position{Line: 2, Col: 18}, recipe.Position(end-1))
+	assert.Equal(t, Position{Line: 2, Col: 19}, recipe.Position(end))
+}
+
+func TestParseAssignments(t *testing.T) {
+	f := parse(t, `CC = gcc
+OUT := build
+PREFIX ?= /usr/local
+CFLAGS += -O2
+DATE != date
+IMMEDIATE ::= now
+override DEBUG = 1
+export PATH := $(PATH):/opt/bin
+clean: OUT_DIR = out
+ifdef CI
+  VERBOSE = 1
+endif
+define BANNER
+hello: world
+	not a recipe
+endef
+undefine CC
+LONG = a \
+       b
+`)
+
+	got := make([]Assignment, 0, len(f.Assignments))
+	for _, a := range f.Assignments {
+		got = append(got, *a)
+	}
+
+	assert.Equal(t, []Assignment{
+		{Name: "CC", Op: "=", Value: "gcc", Line: 1},
+		{Name: "OUT", Op: ":=", Value: "build", Line: 2},
+		{Name: "PREFIX", Op: "?=", Value: "/usr/local", Line: 3},
+		{Name: "CFLAGS", Op: "+=", Value: "-O2", Line: 4},
+		{Name: "DATE", Op: "!=", Value: "date", Line: 5},
+		{Name: "IMMEDIATE", Op: "::=", Value: "now", Line: 6},
+		{Name: "DEBUG", Op: "=", Value: "1", Override: true, Line: 7},
+		{Name: "PATH", Op: ":=", Value: "$(PATH):/opt/bin", Export: true, Line: 8},
+		{Name: "OUT_DIR", Op: "=", Value: "out", Targets: []string{"clean"}, Line: 9},
+		{Name: "VERBOSE", Op: "=", Value: "1", Conditional: true, Line: 11},
+		{Name: "BANNER", Op: "=", Value: "hello: world\n\tnot a recipe", Define: true, Line: 13},
+		{Name: "CC", Op: "undefine", Line: 17},
+		{Name: "LONG", Op: "=", Value: "a b", Line: 18},
+	}, got)
+
+	assert.Empty(t, f.Rules, "target-specific variables and define bodies are not rules")
+}
+
+func TestParseRuleContext(t *testing.T) {
+	f := parse(t, `clean:
+	rm -rf a
+
+	# a shell comment passed to the shell
+# a make comment
+	rm -rf b
+OUT = x
+	rm -rf c
+build:
+    rm -rf d
+`)
+
+	require.Len(t, f.Rules, 2)
+
+	var shells []string
+	for _, r := range f.Rules[0].Recipes {
+		shells = append(shells, r.Shell)
+	}
+
+	assert.Equal(t, []string{"rm -rf a", "# a shell comment passed to the shell", "rm -rf b"}, shells,
+		"blank lines and comments keep the rule context, assignments end it")
+
+	require.Len(t, f.Rules[1].Recipes, 1, "space indented recipes are accepted")
+	assert.Equal(t, "rm -rf d", f.Rules[1].Recipes[0].Shell)
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{name: "extraneous endif", content: "endif\n", wantErr: "Makefile:1: extraneous 'endif'"},
+		{name: "extraneous else", content: "all:\nelse\n", wantErr: "Makefile:2: extraneous 'else'"},
+		{name: "missing endif", content: "ifdef CI\nX = 1\n", wantErr: "Makefile:2: missing 'endif'"},
+		{name: "unterminated define", content: "define X\nfoo\n", wantErr: "Makefile:1: missing 'endef'"},
+		{name: "extraneous endef", content: "endef\n", wantErr: "Makefile:1: extraneous 'endef'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(strings.NewReader(tt.content), "Makefile")
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package report
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/hiteshrepo/hazardous/pkg/issue"
+)
+
+type jsonReporter struct {
+	w       io.Writer
+	issues  []issue.Issue
+	summary Summary
+}
+
+// NewJSON returns a Reporter that writes a single JSON document holding every
+// issue and a summary once the scan is complete.
+func NewJSON(w io.Writer) Reporter {
+	return &jsonReporter{
+		w:       w,
+		issues:  []issue.Issue{},
+		summary: newSummary(),
+	}
+}
+
+func (r *jsonReporter) Report(issues []issue.Issue) error {
+	r.issues = append(r.issues, issues...)
+	r.summary.add(issues)
+
+	return nil
+}
+
+func (r *jsonReporter) Close() error {
+	enc := json.NewEncoder(r.w)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(struct {
+		Issues  []issue.Issue `json:"issues"`
+		Summary Summary       `json:"summary"`
+	}{
+		Issues:  r.issues,
+		Summary: r.summary,
+	})
+}
+
+type ndjsonReporter struct {
+	enc *json.Encoder
+}
+
+// NewNDJSON returns a Reporter that streams one JSON object per issue and
+// per line, as soon as each file has been scanned.
+func NewNDJSON(w io.Writer) Reporter {
+	return &ndjsonReporter{enc: json.NewEncoder(w)}
+}
+
+func (r *ndjsonReporter) Report(issues []issue.Issue) error {
+	for _, i := range issues {
+		if err := r.enc.Encode(i); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *ndjsonReporter) Close() error {
+	return nil
+}
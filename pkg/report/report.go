@@ -0,0 +1,66 @@
+package report
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/hiteshrepo/hazardous/pkg/hazardous"
+	"github.com/hiteshrepo/hazardous/pkg/issue"
+)
+
+// Reporter renders the issues found while scanning.
+type Reporter interface {
+	// Report is called once per scanned file, as soon as the file has been
+	// checked, with the issues found in it.
+	Report(issues []issue.Issue) error
+
+	// Close is called once every file has been scanned and flushes any
+	// buffered output.
+	Close() error
+}
+
+// Formats lists the output formats accepted by New.
+var Formats = []string{"text", "json", "ndjson", "sarif"}
+
+// New returns a Reporter writing the given format to w. The registry provides
+// the rule catalog for formats that describe the rules, such as SARIF.
+func New(format string, w io.Writer, registry *hazardous.Registry) (Reporter, error) {
+	switch format {
+	case "text":
+		return NewText(w), nil
+	case "json":
+		return NewJSON(w), nil
+	case "ndjson":
+		return NewNDJSON(w), nil
+	case "sarif":
+		return NewSARIF(w, registry), nil
+	default:
+		return nil, fmt.Errorf("unknown format %q, expected one of %v", format, Formats)
+	}
+}
+
+// Summary aggregates the findings of a whole scan.
+type Summary struct {
+	Files      int            `json:"files"`
+	Issues     int            `json:"issues"`
+	BySeverity map[string]int `json:"bySeverity"`
+	// ByCategory counts the findings that have a category, e.g. disruption.
+	ByCategory map[string]int `json:"byCategory,omitempty"`
+}
+
+func newSummary() Summary {
+	return Summary{BySeverity: make(map[string]int), ByCategory: make(map[string]int)}
+}
+
+func (s *Summary) add(issues []issue.Issue) {
+	s.Files++
+	s.Issues += len(issues)
+
+	for _, i := range issues {
+		s.BySeverity[i.Severity.String()]++
+
+		if len(i.Category) > 0 {
+			s.ByCategory[string(i.Category)]++
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/hiteshrepo/hazardous/pkg/hazardous"
+	"github.com/hiteshrepo/hazardous/pkg/issue"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	shellIssue = issue.Issue{
+		Filepath: "test.sh",
+		Line:     2,
+		Col:      1,
+		EndLine:  2,
+		EndCol:   10,
+		Command:  "rm -rf",
+		RuleID:   "rm-rf",
+		Severity: issue.SeverityWarning,
+		Message:  "rm -rf deletes files recursively",
+	}
+	makefileIssue = issue.Issue{
+		Filepath: "Makefile",
+		Line:     3,
+		Col:      2,
+		Command:  "rm -rf",
+		RuleID:   "rm-rf",
+		Severity: issue.SeverityError,
+		Message:  "rm -rf deletes files recursively",
+	}
+)
+
+func TestNew(t *testing.T) {
+	for _, format := range Formats {
+		r, err := New(format, &bytes.Buffer{}, hazardous.DefaultRegistry)
+		require.NoError(t, err, format)
+		assert.NotNil(t, r, format)
+	}
+
+	_, err := New("xml", &bytes.Buffer{}, hazardous.DefaultRegistry)
+	assert.Error(t, err)
+}
+
+func TestTextReporter(t *testing.T) {
+	var buf bytes.Buffer
+
+	r := NewText(&buf)
+	require.NoError(t, r.Report([]issue.Issue{shellIssue}))
+	require.NoError(t, r.Report(nil))
+	require.NoError(t, r.Report([]issue.Issue{makefileIssue}))
+	require.NoError(t, r.Close())
+
+	assert.Equal(t, shellIssue.String()+"\n"+makefileIssue.String()+"\n", buf.String())
+}
+
+func TestJSONReporter(t *testing.T) {
+	var buf bytes.Buffer
+
+	r := NewJSON(&buf)
+	require.NoError(t, r.Report([]issue.Issue{shellIssue}))
+	require.NoError(t, r.Report(nil))
+	require.NoError(t, r.Report([]issue.Issue{makefileIssue}))
+	assert.Empty(t, buf.String(), "nothing should be written before Close")
+	require.NoError(t, r.Close())
+
+	var doc struct {
+		Issues  []issue.Issue `json:"issues"`
+		Summary Summary       `json:"summary"`
+	}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &doc))
+
+	assert.Equal(t, []issue.Issue{shellIssue, makefileIssue}, doc.Issues)
+	assert.Equal(t, Summary{
+		Files:      3,
+		Issues:     2,
+		BySeverity: map[string]int{"warning": 1, "error": 1},
+	}, doc.Summary)
+	assert.Contains(t, buf.String(), `"severity": "warning"`)
+}
+
+func TestSummaryByCategory(t *testing.T) {
+	s := newSummary()
+	s.add([]issue.Issue{shellIssue, {Severity: issue.SeverityError, Category: issue.CategoryDisruption}})
+
+	assert.Equal(t, map[string]int{"disruption": 1}, s.ByCategory)
+	assert.Equal(t, map[string]int{"warning": 1, "error": 1}, s.BySeverity)
+}
+
+func TestJSONReporterEmpty(t *testing.T) {
+	var buf bytes.Buffer
+
+	r := NewJSON(&buf)
+	require.NoError(t, r.Close())
+
+	assert.Contains(t, buf.String(), `"issues": []`)
+}
+
+func TestNDJSONReporter(t *testing.T) {
+	var buf bytes.Buffer
+
+	r := NewNDJSON(&buf)
+	require.NoError(t, r.Report([]issue.Issue{shellIssue}))
+	assert.Equal(t, 1, strings.Count(buf.String(), "\n"), "issues should be streamed per file")
+
+	require.NoError(t, r.Report([]issue.Issue{makefileIssue}))
+	require.NoError(t, r.Close())
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+
+	for i, want := range []issue.Issue{shellIssue, makefileIssue} {
+		var got issue.Issue
+		require.NoError(t, json.Unmarshal([]byte(lines[i]), &got))
+		assert.Equal(t, want, got)
+	}
+}
@@ -0,0 +1,232 @@
+package report
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/hiteshrepo/hazardous/pkg/hazardous"
+	"github.com/hiteshrepo/hazardous/pkg/issue"
+)
+
+const (
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifVersion = "2.1.0"
+	toolName     = "hazardous"
+	toolURI      = "https://github.com/hiteshrepo/hazardous"
+
+	// fingerprintKey names the partial fingerprint computed by hazardous. The
+	// version suffix must change whenever the hashing scheme does.
+	fingerprintKey = "hazardous/v1"
+)
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID                   string             `json:"id"`
+	Name                 string             `json:"name"`
+	ShortDescription     sarifMessage       `json:"shortDescription"`
+	DefaultConfiguration sarifConfiguration `json:"defaultConfiguration"`
+	Properties           *sarifProperties   `json:"properties,omitempty"`
+}
+
+// sarifProperties tags a rule with its category, e.g. disruption.
+type sarifProperties struct {
+	Tags []string `json:"tags"`
+}
+
+type sarifConfiguration struct {
+	Level string `json:"level"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID              string            `json:"ruleId"`
+	RuleIndex           *int              `json:"ruleIndex,omitempty"`
+	Level               string            `json:"level"`
+	Message             sarifMessage      `json:"message"`
+	Locations           []sarifLocation   `json:"locations"`
+	PartialFingerprints map[string]string `json:"partialFingerprints"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           sarifRegion           `json:"region"`
+}
+
+type sarifArtifactLocation struct {
+	URI       string `json:"uri"`
+	URIBaseID string `json:"uriBaseId,omitempty"`
+}
+
+type sarifRegion struct {
+	StartLine   uint `json:"startLine"`
+	StartColumn uint `json:"startColumn,omitempty"`
+	EndLine     uint `json:"endLine,omitempty"`
+	EndColumn   uint `json:"endColumn,omitempty"`
+}
+
+type sarifReporter struct {
+	w         io.Writer
+	rules     []sarifRule
+	ruleIndex map[string]int
+	results   []sarifResult
+}
+
+// NewSARIF returns a Reporter that writes a SARIF 2.1.0 log once the scan is
+// complete. The tool's rule catalog is built from the given registry.
+func NewSARIF(w io.Writer, registry *hazardous.Registry) Reporter {
+	r := &sarifReporter{
+		w:         w,
+		rules:     []sarifRule{},
+		ruleIndex: make(map[string]int),
+		results:   []sarifResult{},
+	}
+
+	for _, rule := range registry.Rules() {
+		sr := sarifRule{
+			ID:                   rule.ID(),
+			Name:                 rule.ID(),
+			ShortDescription:     sarifMessage{Text: rule.Description()},
+			DefaultConfiguration: sarifConfiguration{Level: sarifLevel(rule.Severity())},
+		}
+
+		if cr, ok := rule.(hazardous.CategorizedRule); ok && len(cr.Category()) > 0 {
+			sr.Properties = &sarifProperties{Tags: []string{string(cr.Category())}}
+		}
+
+		r.ruleIndex[rule.ID()] = len(r.rules)
+		r.rules = append(r.rules, sr)
+	}
+
+	return r
+}
+
+func (r *sarifReporter) Report(issues []issue.Issue) error {
+	occurrences := make(map[string]int)
+
+	for _, i := range issues {
+		key := fingerprintSource(i)
+		fingerprint := fmt.Sprintf("%s:%d", key, occurrences[key])
+		occurrences[key]++
+
+		r.results = append(r.results, r.result(i, fingerprint))
+	}
+
+	return nil
+}
+
+func (r *sarifReporter) result(i issue.Issue, fingerprint string) sarifResult {
+	message := i.Message
+	if len(message) == 0 {
+		message = "unsafe code found: " + i.Command
+	}
+
+	if len(i.Fix) > 0 {
+		message += ". Suggested fix: " + i.Fix
+	}
+
+	artifact := sarifArtifactLocation{URI: filepath.ToSlash(i.Filepath)}
+	if !filepath.IsAbs(i.Filepath) {
+		artifact.URIBaseID = "%SRCROOT%"
+	}
+
+	res := sarifResult{
+		RuleID:  i.RuleID,
+		Level:   sarifLevel(i.Severity),
+		Message: sarifMessage{Text: message},
+		Locations: []sarifLocation{{
+			PhysicalLocation: sarifPhysicalLocation{
+				ArtifactLocation: artifact,
+				Region: sarifRegion{
+					StartLine:   i.Line,
+					StartColumn: i.Col,
+					EndLine:     i.EndLine,
+					EndColumn:   i.EndCol,
+				},
+			},
+		}},
+		PartialFingerprints: map[string]string{fingerprintKey: fingerprint},
+	}
+
+	if idx, ok := r.ruleIndex[i.RuleID]; ok {
+		res.RuleIndex = &idx
+	}
+
+	return res
+}
+
+func (r *sarifReporter) Close() error {
+	enc := json.NewEncoder(r.w)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(sarifLog{
+		Schema:  sarifSchema,
+		Version: sarifVersion,
+		Runs: []sarifRun{{
+			Tool: sarifTool{Driver: sarifDriver{
+				Name:           toolName,
+				InformationURI: toolURI,
+				Rules:          r.rules,
+			}},
+			Results: r.results,
+		}},
+	})
+}
+
+func sarifLevel(s issue.Severity) string {
+	switch s {
+	case issue.SeverityError:
+		return "error"
+	case issue.SeverityWarning:
+		return "warning"
+	default:
+		return "note"
+	}
+}
+
+// fingerprintSource hashes what was flagged rather than where it was flagged,
+// so that the fingerprint survives lines being added or removed around it.
+// Together with the occurrence count of identical findings in the same file
+// it uniquely and stably identifies a result. Issues without their source
+// lines are fingerprinted by their command.
+func fingerprintSource(i issue.Issue) string {
+	source := i.Command
+	if len(i.Source) > 0 {
+		source = strings.Join(strings.Fields(i.Source), " ")
+	}
+
+	sum := sha256.Sum256([]byte(strings.Join([]string{i.RuleID, filepath.ToSlash(i.Filepath), source}, "\x00")))
+
+	return hex.EncodeToString(sum[:16])
+}
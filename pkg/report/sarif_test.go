@@ -0,0 +1,124 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/hiteshrepo/hazardous/pkg/hazardous"
+	"github.com/hiteshrepo/hazardous/pkg/issue"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func decodeSARIF(t *testing.T, buf *bytes.Buffer) sarifLog {
+	t.Helper()
+
+	var log sarifLog
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &log))
+
+	return log
+}
+
+func TestSARIFReporter(t *testing.T) {
+	var buf bytes.Buffer
+
+	r := NewSARIF(&buf, hazardous.DefaultRegistry)
+	require.NoError(t, r.Report([]issue.Issue{shellIssue}))
+	require.NoError(t, r.Report([]issue.Issue{makefileIssue}))
+	require.NoError(t, r.Close())
+
+	log := decodeSARIF(t, &buf)
+	assert.Equal(t, "2.1.0", log.Version)
+	assert.Equal(t, sarifSchema, log.Schema)
+	require.Len(t, log.Runs, 1)
+
+	driver := log.Runs[0].Tool.Driver
+	assert.Equal(t, "hazardous", driver.Name)
+	require.Len(t, driver.Rules, len(hazardous.DefaultRegistry.Rules()))
+	assert.Equal(t, "rm-rf", driver.Rules[0].ID)
+	assert.NotEmpty(t, driver.Rules[0].ShortDescription.Text)
+	assert.Equal(t, "warning", driver.Rules[0].DefaultConfiguration.Level)
+	assert.Nil(t, driver.Rules[0].Properties)
+
+	for _, rule := range driver.Rules {
+		if rule.ID == "system-shutdown" {
+			require.NotNil(t, rule.Properties)
+			assert.Equal(t, []string{"disruption"}, rule.Properties.Tags)
+		}
+	}
+
+	results := log.Runs[0].Results
+	require.Len(t, results, 2)
+
+	assert.Equal(t, "rm-rf", results[0].RuleID)
+	require.NotNil(t, results[0].RuleIndex)
+	assert.Equal(t, 0, *results[0].RuleIndex)
+	assert.Equal(t, "warning", results[0].Level)
+	assert.Equal(t, shellIssue.Message, results[0].Message.Text)
+	assert.Equal(t, sarifPhysicalLocation{
+		ArtifactLocation: sarifArtifactLocation{URI: "test.sh", URIBaseID: "%SRCROOT%"},
+		Region:           sarifRegion{StartLine: 2, StartColumn: 1, EndLine: 2, EndColumn: 10},
+	}, results[0].Locations[0].PhysicalLocation)
+	assert.NotEmpty(t, results[0].PartialFingerprints[fingerprintKey])
+
+	assert.Equal(t, "error", results[1].Level)
+}
+
+func TestSARIFFingerprintsSurviveLineShifts(t *testing.T) {
+	fingerprints := func(content string, lines ...uint) []string {
+		var buf bytes.Buffer
+
+		r := NewSARIF(&buf, hazardous.DefaultRegistry)
+
+		var issues []issue.Issue
+		for _, line := range lines {
+			i := shellIssue
+			i.Line, i.EndLine = line, line
+			issues = append(issues, i)
+		}
+
+		issue.AttachSource(issues, content)
+
+		require.NoError(t, r.Report(issues))
+		require.NoError(t, r.Close())
+
+		var got []string
+		for _, res := range decodeSARIF(t, &buf).Runs[0].Results {
+			got = append(got, res.PartialFingerprints[fingerprintKey])
+		}
+
+		return got
+	}
+
+	before := fingerprints("rm -rf build\nrm -rf dist\nrm -rf build\n", 1, 2, 3)
+	after := fingerprints("#!/bin/bash\n\nset -e\nrm -rf build\nrm -rf dist\n  rm -rf build\n", 4, 5, 6)
+
+	assert.Equal(t, before, after)
+	assert.Len(t, before, 3)
+	assert.NotEqual(t, before[0], before[1], "different commands should not share a fingerprint")
+	assert.NotEqual(t, before[0], before[2], "repeated commands should be told apart by occurrence")
+}
+
+func TestSARIFFingerprintsWithoutSource(t *testing.T) {
+	var buf bytes.Buffer
+
+	// issues read from standard input have no file to read the source from
+	r := NewSARIF(&buf, hazardous.DefaultRegistry)
+	require.NoError(t, r.Report([]issue.Issue{shellIssue, shellIssue}))
+	require.NoError(t, r.Close())
+
+	results := decodeSARIF(t, &buf).Runs[0].Results
+	require.Len(t, results, 2)
+	assert.NotEmpty(t, results[0].PartialFingerprints[fingerprintKey])
+	assert.NotEqual(t, results[0].PartialFingerprints[fingerprintKey], results[1].PartialFingerprints[fingerprintKey])
+}
+
+func TestSARIFReporterEmpty(t *testing.T) {
+	var buf bytes.Buffer
+
+	r := NewSARIF(&buf, hazardous.DefaultRegistry)
+	require.NoError(t, r.Close())
+
+	assert.Contains(t, buf.String(), `"results": []`)
+}
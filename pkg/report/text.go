@@ -0,0 +1,31 @@
+package report
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/hiteshrepo/hazardous/pkg/issue"
+)
+
+type textReporter struct {
+	w io.Writer
+}
+
+// NewText returns a Reporter that writes one human readable line per issue.
+func NewText(w io.Writer) Reporter {
+	return &textReporter{w: w}
+}
+
+func (r *textReporter) Report(issues []issue.Issue) error {
+	for _, i := range issues {
+		if _, err := fmt.Fprintln(r.w, i.String()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *textReporter) Close() error {
+	return nil
+}